@@ -0,0 +1,73 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// ageExt is the extension of the config files that are encrypted with age.
+const ageExt = ".age"
+
+// WithAge loads the age encrypted variant of every config file (e.g. .env.age instead of .env) when it exists and
+// decrypts it with the identities in the given file, or with the identities in AGE_IDENTITY if the path is
+// empty. Config files with the .age extension are always decrypted, with the identities in AGE_IDENTITY
+// unless this option is used.
+func WithAge(identityFile string) Option {
+	return func(o *options) {
+		o.age = true
+		o.ageIdentityFile = identityFile
+	}
+}
+
+// ageParser returns a parser that decrypts an age encrypted config file and parses it with the given parser.
+func ageParser(o *options, parse Parser) Parser {
+	return func(r io.Reader) (map[string]string, error) {
+		identities, err := ageIdentities(o)
+		if err != nil {
+			return nil, err
+		}
+
+		plaintext, err := age.Decrypt(r, identities...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt: %w", err)
+		}
+
+		return parse(plaintext)
+	}
+}
+
+// ageIdentities returns the identities in the identity file, or in AGE_IDENTITY if there is no identity file.
+func ageIdentities(o *options) ([]age.Identity, error) {
+	if o.ageIdentityFile != "" {
+		f, err := os.Open(o.ageIdentityFile)
+		if err != nil {
+			// the error is not wrapped, a missing identity file must not be taken for a missing config file
+			return nil, fmt.Errorf("failed to open the age identity file: %v", err)
+		}
+		defer f.Close()
+
+		identities, err := age.ParseIdentities(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid age identity file %s: %w", o.ageIdentityFile, err)
+		}
+
+		return identities, nil
+	}
+
+	identity := os.Getenv("AGE_IDENTITY")
+	if identity == "" {
+		return nil, errors.New("an age identity file or AGE_IDENTITY is required to decrypt the config file")
+	}
+
+	identities, err := age.ParseIdentities(strings.NewReader(identity))
+	if err != nil {
+		return nil, fmt.Errorf("invalid AGE_IDENTITY: %w", err)
+	}
+
+	return identities, nil
+}
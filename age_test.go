@@ -0,0 +1,92 @@
+package env
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"filippo.io/age"
+)
+
+// encryptAge encrypts the given plaintext to the given recipient.
+func encryptAge(t *testing.T, recipient age.Recipient, plaintext string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(plaintext)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestWithAge(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identityFile := filepath.Join(t.TempDir(), "key.txt")
+	if err := os.WriteFile(identityFile, []byte("# created: 2026-01-01\n"+identity.String()+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys := fstest.MapFS{
+		".env":     {Data: []byte("PORT=8080")},
+		".env.age": {Data: encryptAge(t, identity.Recipient(), "PORT=9090")},
+	}
+
+	tests := []struct {
+		name     string
+		identity string
+		opts     []Option
+		want     int
+		wantErr  string
+	}{
+		{name: "identity file", opts: []Option{WithAge(identityFile)}, want: 9090},
+		{name: "AGE_IDENTITY", identity: identity.String(), opts: []Option{WithAge("")}, want: 9090},
+		{name: "age file", identity: identity.String(), opts: []Option{WithFile(".env.age")}, want: 9090},
+		{name: "without age", identity: identity.String(), want: 8080},
+		{name: "no identity", opts: []Option{WithAge("")}, wantErr: "an age identity file or AGE_IDENTITY is required"},
+		{name: "other identity", identity: other.String(), opts: []Option{WithAge("")}, wantErr: "failed to decrypt"},
+		{name: "invalid identity", identity: "AGE-SECRET-KEY-INVALID", opts: []Option{WithAge("")}, wantErr: "invalid AGE_IDENTITY"},
+		{name: "missing identity file", opts: []Option{WithAge(filepath.Join(t.TempDir(), "missing.txt"))}, wantErr: "failed to open the age identity file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("AGE_IDENTITY", tt.identity)
+
+			var cfg struct {
+				Port int `mapstructure:"PORT"`
+			}
+			err := LoadE(&cfg, append([]Option{WithFS(fsys), WithPrecedence(FileOnly)}, tt.opts...)...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadE() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadE() error = %v", err)
+			}
+			if cfg.Port != tt.want {
+				t.Errorf("Port = %d, want %d", cfg.Port, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package env
+
+// Audit is the trail of the keys that were consulted while loading, in the order that they were consulted.
+type Audit []Access
+
+// Access is a single consultation of a key.
+type Access struct {
+	// Key is the key that was consulted (e.g. DB_PASSWORD), the prefix of a prefixed map followed by an asterisk
+	// (e.g. FEATURE_*) or the reference that was resolved (e.g. vault://secret/db#password).
+	Key string
+	// Found reports whether the key was present (or one of its _FILE variants), whether the prefixed map matched
+	// any keys or whether the reference was resolved.
+	Found bool
+}
+
+// WithAudit fills the given audit with every key that was consulted while loading and whether it was found, so
+// that security reviews can prove which secrets a service actually touches. The values are not recorded.
+func WithAudit(audit *Audit) Option {
+	return func(o *options) {
+		o.audit = audit
+	}
+}
+
+// access records the consultation of the given key if the keys are audited.
+func (p *parser) access(key string, found bool) {
+	if p.o.audit == nil {
+		return
+	}
+
+	p.accesses = append(p.accesses, Access{Key: key, Found: found})
+}
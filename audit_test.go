@@ -0,0 +1,90 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	type config struct {
+		Host     string            `mapstructure:"HOST"`
+		Token    string            `mapstructure:"TOKEN" alias:"API_TOKEN"`
+		URL      string            `mapstructure:"URL" expand:"true"`
+		Password string            `mapstructure:"PASSWORD" store:"db/password"`
+		Features map[string]string `prefix:"FEATURE_"`
+	}
+
+	resolver := ResolverFunc(func(_ context.Context, ref string) (string, error) {
+		if strings.Contains(ref, "missing") {
+			return "", errors.New("not found")
+		}
+		return "resolved", nil
+	})
+
+	tests := []struct {
+		name    string
+		src     string
+		want    Audit
+		wantErr bool
+	}{
+		{
+			name: "found",
+			src:  "HOST=localhost\nTOKEN=t\nURL=http://${HOST}\nPASSWORD=p\nFEATURE_BETA=on",
+			want: Audit{
+				{Key: "HOST", Found: true},
+				{Key: "TOKEN", Found: true},
+				{Key: "URL", Found: true},
+				{Key: "HOST", Found: true},
+				{Key: "PASSWORD", Found: true},
+				{Key: "FEATURE_*", Found: true},
+			},
+		},
+		{
+			name: "not found",
+			src:  "",
+			want: Audit{
+				{Key: "HOST"},
+				{Key: "TOKEN"},
+				{Key: "API_TOKEN"},
+				{Key: "URL"},
+				{Key: "PASSWORD"},
+				{Key: "db/password", Found: true},
+				{Key: "FEATURE_*"},
+			},
+		},
+		{
+			name: "alias and references",
+			src:  "API_TOKEN=vault://secret/token\nURL=${MISSING}\nPASSWORD=vault://missing",
+			want: Audit{
+				{Key: "HOST"},
+				{Key: "TOKEN"},
+				{Key: "API_TOKEN", Found: true},
+				{Key: "vault://secret/token", Found: true},
+				{Key: "URL", Found: true},
+				{Key: "MISSING"},
+				{Key: "PASSWORD", Found: true},
+				{Key: "vault://missing"},
+				{Key: "FEATURE_*"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			var audit Audit
+			err := LoadReader(strings.NewReader(tt.src), &cfg, WithPrecedence(FileOnly), WithAudit(&audit),
+				WithResolver("store", resolver), WithReferences("vault", resolver))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadReader() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(audit, tt.want) {
+				t.Errorf("Audit = %+v, want %+v", audit, tt.want)
+			}
+		})
+	}
+}
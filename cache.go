@@ -0,0 +1,85 @@
+package env
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+)
+
+// CachedProvider is a provider that memoizes the values of another provider for a TTL.
+type CachedProvider struct {
+	provider Provider
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	values  map[string]string
+	fetched time.Time
+	call    *cacheCall
+}
+
+// cacheCall is a fetch in flight that the concurrent callers wait for.
+type cacheCall struct {
+	done   chan struct{}
+	values map[string]string
+	err    error
+}
+
+// Cached returns a provider that memoizes the values of the given provider for the given TTL, which makes
+// remote providers safe to call from hot paths. The cached values are served until they are older than the
+// TTL, and concurrent fetches of expired values share a single fetch of the provider.
+func Cached(p Provider, ttl time.Duration) *CachedProvider {
+	return &CachedProvider{provider: p, ttl: ttl}
+}
+
+// Fetch returns the cached values, or fetches the values of the provider if they expired.
+func (c *CachedProvider) Fetch(ctx context.Context) (map[string]string, error) {
+	c.mu.Lock()
+	if c.values != nil && time.Since(c.fetched) < c.ttl {
+		values := maps.Clone(c.values)
+		c.mu.Unlock()
+		return values, nil
+	}
+
+	call := c.call
+	if call == nil {
+		call = &cacheCall{done: make(chan struct{})}
+		c.call = call
+
+		go c.fetch(context.WithoutCancel(ctx), call)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		if call.err != nil {
+			return nil, call.err
+		}
+
+		return maps.Clone(call.values), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Invalidate drops the cached values so that the next fetch fetches the values of the provider.
+func (c *CachedProvider) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values = nil
+}
+
+// fetch fetches the values of the provider for the given call and caches them if the fetch succeeds.
+func (c *CachedProvider) fetch(ctx context.Context, call *cacheCall) {
+	call.values, call.err = c.provider.Fetch(ctx)
+
+	c.mu.Lock()
+	if call.err == nil {
+		c.values, c.fetched = call.values, time.Now()
+	}
+	c.call = nil
+	c.mu.Unlock()
+
+	close(call.done)
+}
@@ -0,0 +1,114 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingProvider is a provider that returns the number of its fetches, after a delay if it has one.
+type countingProvider struct {
+	fetches atomic.Int32
+	delay   time.Duration
+	err     error
+}
+
+// Fetch returns the number of the fetch.
+func (p *countingProvider) Fetch(_ context.Context) (map[string]string, error) {
+	n := p.fetches.Add(1)
+	time.Sleep(p.delay)
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	return map[string]string{"FETCH": strconv.Itoa(int(n))}, nil
+}
+
+func TestCached(t *testing.T) {
+	tests := []struct {
+		name        string
+		ttl         time.Duration
+		wait        time.Duration
+		invalidate  bool
+		err         error
+		want        string
+		wantFetches int32
+	}{
+		{name: "cached", ttl: time.Hour, want: "1", wantFetches: 1},
+		{name: "expired", ttl: time.Millisecond, wait: 5 * time.Millisecond, want: "2", wantFetches: 2},
+		{name: "invalidated", ttl: time.Hour, invalidate: true, want: "2", wantFetches: 2},
+		{name: "errors are not cached", ttl: time.Hour, err: errors.New("unavailable"), wantFetches: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &countingProvider{err: tt.err}
+			c := Cached(p, tt.ttl)
+
+			if _, err := c.Fetch(context.Background()); err != tt.err {
+				t.Fatalf("Fetch() error = %v, want %v", err, tt.err)
+			}
+			time.Sleep(tt.wait)
+			if tt.invalidate {
+				c.Invalidate()
+			}
+
+			got, err := c.Fetch(context.Background())
+			if err != tt.err {
+				t.Fatalf("Fetch() error = %v, want %v", err, tt.err)
+			}
+			if got["FETCH"] != tt.want {
+				t.Errorf("Fetch() = %q, want the values of fetch %q", got, tt.want)
+			}
+			if n := p.fetches.Load(); n != tt.wantFetches {
+				t.Errorf("fetches = %d, want %d", n, tt.wantFetches)
+			}
+		})
+	}
+}
+
+func TestCachedConcurrentFetches(t *testing.T) {
+	p := &countingProvider{delay: 20 * time.Millisecond}
+	c := Cached(p, time.Hour)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			got, err := c.Fetch(context.Background())
+			if err != nil || got["FETCH"] != "1" {
+				t.Errorf("Fetch() = %q, %v, want the values of the first fetch", got, err)
+			}
+			// the callers modify the returned maps, which must not change the cached values
+			got["FETCH"] = "changed"
+		}()
+	}
+	wg.Wait()
+
+	if n := p.fetches.Load(); n != 1 {
+		t.Errorf("fetches = %d, want 1", n)
+	}
+}
+
+func TestCachedCanceled(t *testing.T) {
+	p := &countingProvider{delay: 50 * time.Millisecond}
+	c := Cached(p, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.Fetch(ctx); err != context.Canceled {
+		t.Fatalf("Fetch() error = %v, want %v", err, context.Canceled)
+	}
+
+	// the fetch goes on without the canceled caller and its values are cached
+	got, err := c.Fetch(context.Background())
+	if err != nil || got["FETCH"] != "1" {
+		t.Errorf("Fetch() = %q, %v, want the values of the first fetch", got, err)
+	}
+}
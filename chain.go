@@ -0,0 +1,58 @@
+package env
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ChainProvider is a provider that merges the values of a chain of providers, the values of the earlier
+// providers win over the values of the later ones. It records which provider supplied each value.
+type ChainProvider struct {
+	providers []Provider
+
+	mu      sync.Mutex
+	sources map[string]Provider
+}
+
+// Chain returns a provider that merges the values of the given providers in order of precedence, e.g.
+// Chain(flags, Environment{}, File{Path: ".env"}, vault) lets the flags override everything else.
+func Chain(providers ...Provider) *ChainProvider {
+	return &ChainProvider{providers: providers}
+}
+
+// Fetch fetches the values of all the providers and merges them.
+func (c *ChainProvider) Fetch(ctx context.Context) (map[string]string, error) {
+	m := make(map[string]string)
+	sources := make(map[string]Provider)
+	for i, p := range c.providers {
+		values, err := p.Fetch(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch the values of the provider %d (%T): %w", i, p, err)
+		}
+
+		for key, value := range values {
+			if _, ok := m[key]; ok {
+				continue
+			}
+
+			m[key] = value
+			sources[key] = p
+		}
+	}
+
+	c.mu.Lock()
+	c.sources = sources
+	c.mu.Unlock()
+
+	return m, nil
+}
+
+// Source returns the provider that supplied the value of the given key in the last fetch.
+func (c *ChainProvider) Source(key string) (Provider, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.sources[key]
+	return p, ok
+}
@@ -0,0 +1,96 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// errProvider is a provider that fails with an error.
+type errProvider struct {
+	err error
+}
+
+// Fetch returns the error.
+func (p errProvider) Fetch(_ context.Context) (map[string]string, error) {
+	return nil, p.err
+}
+
+func TestChain(t *testing.T) {
+	flags := staticProvider{"PORT": "9090"}
+	environment := staticProvider{"PORT": "8080", "HOST": "env-host"}
+	file := staticProvider{"PORT": "80", "HOST": "file-host", "DEBUG": "true"}
+
+	tests := []struct {
+		name        string
+		providers   []Provider
+		want        map[string]string
+		wantSources map[string]int
+		wantErr     string
+	}{
+		{
+			name:        "earlier providers win",
+			providers:   []Provider{flags, environment, file},
+			want:        map[string]string{"PORT": "9090", "HOST": "env-host", "DEBUG": "true"},
+			wantSources: map[string]int{"PORT": 0, "HOST": 1, "DEBUG": 2},
+		},
+		{
+			name:        "reversed",
+			providers:   []Provider{file, environment, flags},
+			want:        map[string]string{"PORT": "80", "HOST": "file-host", "DEBUG": "true"},
+			wantSources: map[string]int{"PORT": 0, "HOST": 0, "DEBUG": 0},
+		},
+		{name: "empty", want: map[string]string{}, wantSources: map[string]int{}},
+		{
+			name:      "error",
+			providers: []Provider{flags, errProvider{err: errors.New("unavailable")}},
+			wantErr:   "failed to fetch the values of the provider 1 (env.errProvider): unavailable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Chain(tt.providers...)
+			got, err := c.Fetch(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Fetch() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fetch() = %q, want %q", got, tt.want)
+			}
+
+			for key, i := range tt.wantSources {
+				if p, ok := c.Source(key); !ok || !reflect.DeepEqual(p, tt.providers[i]) {
+					t.Errorf("Source(%q) = %v, %v, want the provider %d", key, p, ok, i)
+				}
+			}
+			if p, ok := c.Source("MISSING"); ok {
+				t.Errorf("Source(MISSING) = %v, want no provider", p)
+			}
+		})
+	}
+}
+
+func TestLoadProviderChain(t *testing.T) {
+	t.Setenv("CHAIN_PORT", "8080")
+
+	var cfg struct {
+		Host string `mapstructure:"CHAIN_HOST"`
+		Port int    `mapstructure:"CHAIN_PORT"`
+	}
+	chain := Chain(Environment{}, staticProvider{"CHAIN_HOST": "remote", "CHAIN_PORT": "80"})
+	if err := LoadProvider(chain, &cfg); err != nil {
+		t.Fatalf("LoadProvider() error = %v", err)
+	}
+	if cfg.Host != "remote" || cfg.Port != 8080 {
+		t.Errorf("config = %+v, want the port of the environment and the host of the provider", cfg)
+	}
+}
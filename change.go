@@ -0,0 +1,220 @@
+package env
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ChangeSet describes how the keys of the config changed on a reload, the values of the fields that are tagged
+// as secret are redacted.
+type ChangeSet struct {
+	// Added contains the keys that were added.
+	Added []Change
+	// Modified contains the keys whose values were modified.
+	Modified []Change
+	// Removed contains the keys that were removed.
+	Removed []Change
+}
+
+// Change is the change of a single key.
+type Change struct {
+	// Key is the key that changed (e.g. LOG_LEVEL).
+	Key string
+	// Old is the value of the key before the reload, it is empty for the added keys.
+	Old string
+	// New is the value of the key after the reload, it is empty for the removed keys.
+	New string
+}
+
+// Diff returns the changes of the keys between the given old and new configs in the order of the fields, the keys
+// that are only in the old config (e.g. the removed entries of a prefixed map) come last. The values are formatted
+// as they would be read from the environment and the values of the secret fields are redacted, so that reload
+// handlers and deploy tooling can log exactly which settings changed. The keys are derived with the given
+// options, which should be the options that the configs were loaded with.
+func Diff[T any](old, new T, opts ...Option) []Change {
+	p := newParser(nil, newOptions(opts...))
+
+	type entry struct {
+		field reflect.StructField
+		value reflect.Value
+	}
+	oldEntries := make(map[string]entry)
+	var oldKeys []string
+	p.walk(reflect.ValueOf(&old).Elem(), p.o.keyPrefix, "", func(key, _ string, field reflect.StructField, v reflect.Value) {
+		oldEntries[key] = entry{field: field, value: v}
+		oldKeys = append(oldKeys, key)
+	})
+
+	var changes []Change
+	seen := make(map[string]bool)
+	p.walk(reflect.ValueOf(&new).Elem(), p.o.keyPrefix, "", func(key, _ string, field reflect.StructField, v reflect.Value) {
+		seen[key] = true
+
+		o, ok := oldEntries[key]
+		if ok && reflect.DeepEqual(o.value.Interface(), v.Interface()) {
+			return
+		}
+
+		change := Change{Key: key, New: redact(field, formatValue(field, v))}
+		if ok {
+			change.Old = redact(o.field, formatValue(o.field, o.value))
+		}
+		changes = append(changes, change)
+	})
+	for _, key := range oldKeys {
+		if !seen[key] {
+			o := oldEntries[key]
+			changes = append(changes, Change{Key: key, Old: redact(o.field, formatValue(o.field, o.value))})
+		}
+	}
+
+	return changes
+}
+
+// empty reports whether no keys changed.
+func (c ChangeSet) empty() bool {
+	return len(c.Added) == 0 && len(c.Modified) == 0 && len(c.Removed) == 0
+}
+
+// WithSubscriber makes Watch send a ChangeSet to the given channel whenever a reload changes the config, which
+// enables event-driven reactions and audit trails. The change sets are dropped if the channel is not ready to
+// receive them, so it should be buffered.
+func WithSubscriber(ch chan<- ChangeSet) Option {
+	return func(o *options) {
+		o.subscribers = append(o.subscribers, ch)
+	}
+}
+
+// publish sends the given change set to the subscribers.
+func (r *reloader[T]) publish(cs ChangeSet) {
+	if cs.empty() {
+		return
+	}
+
+	for _, ch := range r.o.subscribers {
+		select {
+		case ch <- cs:
+		default:
+			r.o.log().Warn("dropped a change set, the subscriber is not ready to receive it")
+		}
+	}
+}
+
+// values returns the loaded values of the keys that map to the fields of the parsed struct.
+func (p *parser) values() map[string]string {
+	m := make(map[string]string)
+	for key, value := range p.envMap {
+		if p.known(key) {
+			m[key] = value
+		}
+	}
+
+	return m
+}
+
+// diff returns the changes between the given old and new values, redacting the values of the secret fields.
+func (p *parser) diff(old, new map[string]string) ChangeSet {
+	value := func(key, value string) string {
+		if value != "" && (p.secrets[key] || p.secrets[strings.TrimSuffix(key, "_FILE")]) {
+			return redacted
+		}
+
+		return value
+	}
+
+	var cs ChangeSet
+	for _, key := range slices.Sorted(maps.Keys(new)) {
+		oldValue, ok := old[key]
+		switch {
+		case !ok:
+			cs.Added = append(cs.Added, Change{Key: key, New: value(key, new[key])})
+		case oldValue != new[key]:
+			cs.Modified = append(cs.Modified, Change{Key: key, Old: value(key, oldValue), New: value(key, new[key])})
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(old)) {
+		if _, ok := new[key]; !ok {
+			cs.Removed = append(cs.Removed, Change{Key: key, Old: value(key, old[key])})
+		}
+	}
+
+	return cs
+}
+
+// fieldCallback is a callback that is called when a field of the config changes on a reload.
+type fieldCallback struct {
+	field string
+	call  func(old, new reflect.Value) error
+}
+
+// OnChange registers a callback that Watch calls with the old and the new value of the given field whenever it
+// changes on a reload, so that only the affected subsystems react to a change. Fields of nested structs are
+// selected with their path (e.g. Database.Host), the type of the callback must match the type of the field.
+func OnChange[V any](field string, fn func(old, new V)) Option {
+	return func(o *options) {
+		o.fieldCallbacks = append(o.fieldCallbacks, fieldCallback{
+			field: field,
+			call: func(old, new reflect.Value) error {
+				if old.Type() != reflect.TypeFor[V]() {
+					return fmt.Errorf("the callback of the field %s expects %s but the field is %s", field, reflect.TypeFor[V](), old.Type())
+				}
+
+				fn(old.Interface().(V), new.Interface().(V))
+				return nil
+			},
+		})
+	}
+}
+
+// notifyFields calls the callbacks of the fields that differ between the given old and new config.
+func notifyFields(o *options, old, new any) {
+	for _, cb := range o.fieldCallbacks {
+		oldValue, err := fieldByPath(reflect.ValueOf(old), cb.field)
+		if err != nil {
+			o.log().Error("failed to call the change callback", "field", cb.field, "error", err)
+			continue
+		}
+		newValue, err := fieldByPath(reflect.ValueOf(new), cb.field)
+		if err != nil {
+			o.log().Error("failed to call the change callback", "field", cb.field, "error", err)
+			continue
+		}
+		if reflect.DeepEqual(oldValue.Interface(), newValue.Interface()) {
+			continue
+		}
+
+		if err := cb.call(oldValue, newValue); err != nil {
+			o.log().Error("failed to call the change callback", "field", cb.field, "error", err)
+		}
+	}
+}
+
+// fieldByPath returns the field of the given struct with the given dotted path, nil pointers to nested structs
+// yield the zero value of the field.
+func fieldByPath(v reflect.Value, path string) (reflect.Value, error) {
+	for _, name := range strings.Split(path, ".") {
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v = reflect.Zero(v.Type().Elem())
+				continue
+			}
+
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("no field %s in %s", path, v.Type())
+		}
+
+		f, ok := v.Type().FieldByName(name)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("no field %s in %s", path, v.Type())
+		}
+
+		v = v.FieldByIndex(f.Index)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,249 @@
+package env
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOnChange(t *testing.T) {
+	type database struct {
+		Host string
+		Port int
+	}
+	type config struct {
+		LogLevel string
+		Database database
+		Cache    *database
+	}
+
+	old := config{LogLevel: "info", Database: database{Host: "db", Port: 5432}}
+
+	tests := []struct {
+		name string
+		new  config
+		opts func(calls *[]string) []Option
+		want []string
+	}{
+		{
+			name: "changed",
+			new:  config{LogLevel: "debug", Database: old.Database},
+			opts: func(calls *[]string) []Option {
+				return []Option{OnChange("LogLevel", func(old, new string) { *calls = append(*calls, old+" -> "+new) })}
+			},
+			want: []string{"info -> debug"},
+		},
+		{
+			name: "unchanged",
+			new:  config{LogLevel: "info", Database: database{Host: "other", Port: 5432}},
+			opts: func(calls *[]string) []Option {
+				return []Option{OnChange("LogLevel", func(old, new string) { *calls = append(*calls, old+" -> "+new) })}
+			},
+		},
+		{
+			name: "nested field",
+			new:  config{LogLevel: "info", Database: database{Host: "other", Port: 5432}},
+			opts: func(calls *[]string) []Option {
+				return []Option{
+					OnChange("Database.Host", func(old, new string) { *calls = append(*calls, old+" -> "+new) }),
+					OnChange("Database.Port", func(_, _ int) { *calls = append(*calls, "port") }),
+				}
+			},
+			want: []string{"db -> other"},
+		},
+		{
+			name: "struct field",
+			new:  config{LogLevel: "info", Database: database{Host: "other", Port: 5432}},
+			opts: func(calls *[]string) []Option {
+				return []Option{OnChange("Database", func(old, new database) { *calls = append(*calls, old.Host+" -> "+new.Host) })}
+			},
+			want: []string{"db -> other"},
+		},
+		{
+			name: "nil pointer",
+			new:  config{LogLevel: "info", Database: old.Database, Cache: &database{Host: "cache"}},
+			opts: func(calls *[]string) []Option {
+				return []Option{OnChange("Cache.Host", func(old, new string) { *calls = append(*calls, old+" -> "+new) })}
+			},
+			want: []string{" -> cache"},
+		},
+		{
+			name: "type mismatch",
+			new:  config{LogLevel: "debug", Database: old.Database},
+			opts: func(calls *[]string) []Option {
+				return []Option{OnChange("LogLevel", func(_, _ int) { *calls = append(*calls, "called") })}
+			},
+		},
+		{
+			name: "missing field",
+			new:  config{LogLevel: "debug", Database: old.Database},
+			opts: func(calls *[]string) []Option {
+				return []Option{OnChange("Level", func(_, _ string) { *calls = append(*calls, "called") })}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			notifyFields(newOptions(tt.opts(&calls)...), old, tt.new)
+			if !reflect.DeepEqual(calls, tt.want) {
+				t.Errorf("calls = %q, want %q", calls, tt.want)
+			}
+		})
+	}
+}
+
+func TestParserDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		old  map[string]string
+		new  map[string]string
+		want ChangeSet
+	}{
+		{
+			name: "changes",
+			old:  map[string]string{"HOST": "db", "PORT": "5432", "DEBUG": "true"},
+			new:  map[string]string{"HOST": "other", "PORT": "5432", "LOG_LEVEL": "debug"},
+			want: ChangeSet{
+				Added:    []Change{{Key: "LOG_LEVEL", New: "debug"}},
+				Modified: []Change{{Key: "HOST", Old: "db", New: "other"}},
+				Removed:  []Change{{Key: "DEBUG", Old: "true"}},
+			},
+		},
+		{name: "unchanged", old: map[string]string{"HOST": "db"}, new: map[string]string{"HOST": "db"}},
+		{
+			name: "secrets",
+			old:  map[string]string{"PASSWORD": "old", "TOKEN_FILE": "/run/secrets/old"},
+			new:  map[string]string{"PASSWORD": "new", "TOKEN_FILE": "/run/secrets/new"},
+			want: ChangeSet{Modified: []Change{
+				{Key: "PASSWORD", Old: redacted, New: redacted},
+				{Key: "TOKEN_FILE", Old: redacted, New: redacted},
+			}},
+		},
+		{
+			name: "emptied secret",
+			old:  map[string]string{"PASSWORD": "old"},
+			new:  map[string]string{"PASSWORD": ""},
+			want: ChangeSet{Modified: []Change{{Key: "PASSWORD", Old: redacted}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newParser(nil, newOptions())
+			p.secrets = map[string]bool{"PASSWORD": true, "TOKEN": true}
+			if got := p.diff(tt.old, tt.new); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diff() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithSubscriber(t *testing.T) {
+	type config struct {
+		Host     string `mapstructure:"HOST"`
+		Password string `mapstructure:"PASSWORD" secret:"true"`
+	}
+
+	p := &mutableProvider{values: map[string]string{"HOST": "localhost", "PASSWORD": "old"}}
+	subscriber := make(chan ChangeSet, 1)
+	opts := []Option{WithPrecedence(EnvOnly), WithProvider(p), WithPollInterval(10 * time.Millisecond), WithSubscriber(subscriber)}
+
+	var cfg config
+	if err := LoadE(&cfg, opts...); err != nil {
+		t.Fatalf("LoadE() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- Watch(ctx, &cfg, nil, opts...)
+	}()
+	// the watch is seeded with the values of the current config before they change
+	time.Sleep(50 * time.Millisecond)
+
+	p.set(map[string]string{"HOST": "remote", "PASSWORD": "new"})
+
+	want := ChangeSet{Modified: []Change{{Key: "HOST", Old: "localhost", New: "remote"}, {Key: "PASSWORD", Old: redacted, New: redacted}}}
+	select {
+	case got := <-subscriber:
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ChangeSet = %+v, want %+v", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Error("no ChangeSet was published")
+	}
+
+	cancel()
+	<-done
+}
+
+func TestDiff(t *testing.T) {
+	type database struct {
+		Host     string `mapstructure:"HOST"`
+		Password string `mapstructure:"PASSWORD" secret:"true"`
+	}
+	type config struct {
+		Port     int               `mapstructure:"PORT"`
+		Timeout  time.Duration     `mapstructure:"TIMEOUT"`
+		Token    Secret[string]    `mapstructure:"TOKEN"`
+		Features map[string]string `prefix:"FEATURE_"`
+		DB       database          `prefix:"DB_"`
+	}
+
+	old := config{
+		Port:     80,
+		Timeout:  time.Second,
+		Token:    NewSecret("old"),
+		Features: map[string]string{"alpha": "on", "beta": "off"},
+		DB:       database{Host: "db", Password: "old"},
+	}
+
+	tests := []struct {
+		name string
+		new  func() config
+		want []Change
+	}{
+		{name: "unchanged", new: func() config { return old }},
+		{
+			name: "changed",
+			new: func() config {
+				c := old
+				c.Port = 8080
+				c.Timeout = time.Minute
+				c.DB.Host = "other"
+				return c
+			},
+			want: []Change{{Key: "PORT", Old: "80", New: "8080"}, {Key: "TIMEOUT", Old: "1s", New: "1m0s"}, {Key: "DB_HOST", Old: "db", New: "other"}},
+		},
+		{
+			name: "secrets",
+			new: func() config {
+				c := old
+				c.Token = NewSecret("new")
+				c.DB.Password = "new"
+				return c
+			},
+			want: []Change{{Key: "TOKEN", Old: redacted, New: redacted}, {Key: "DB_PASSWORD", Old: redacted, New: redacted}},
+		},
+		{
+			name: "prefixed map entries",
+			new: func() config {
+				c := old
+				c.Features = map[string]string{"beta": "on", "gamma": "on"}
+				return c
+			},
+			want: []Change{{Key: "FEATURE_beta", Old: "off", New: "on"}, {Key: "FEATURE_gamma", New: "on"}, {Key: "FEATURE_alpha", Old: "on"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(old, tt.new()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// check checks the loaded value of the given field against the constraints in its tags:
+//   - oneof:"debug info warn error" only accepts the given values.
+//   - min:"1" and max:"65535" bound numbers (and durations and byte sizes in their own units) and the lengths
+//     of strings, slices and maps.
+//   - exists:"file" and exists:"dir" require the paths to be readable files or directories, exists:"true"
+//     accepts either.
+//   - listenable:"true" requires host:port addresses, "free" requires the port to be free and a range
+//     (e.g. "1024-65535") restricts the port, the options are separated by commas.
+func (p *parser) check(field reflect.StructField, envKey string, fieldValue reflect.Value, envValue string) error {
+	// the constraints apply to the values that pointers point to, nil pointers are not set
+	for fieldValue.Kind() == reflect.Pointer {
+		if fieldValue.IsNil() {
+			return nil
+		}
+
+		fieldValue = fieldValue.Elem()
+	}
+	fieldValue = unwrapSecret(fieldValue)
+
+	if tag, ok := field.Tag.Lookup("oneof"); ok {
+		allowed := strings.Fields(tag)
+		for _, value := range elements(fieldValue, envValue) {
+			if !slices.Contains(allowed, value) {
+				return fmt.Errorf("%w: %s must be one of %s but it is %q", ErrInvalid, envKey, strings.Join(allowed, ", "), redact(field, value))
+			}
+		}
+	}
+
+	if tag, ok := field.Tag.Lookup("exists"); ok {
+		for _, path := range elements(fieldValue, envValue) {
+			if err := checkExists(field, envKey, path, tag); err != nil {
+				return err
+			}
+		}
+	}
+
+	if tag, ok := field.Tag.Lookup("listenable"); ok {
+		for _, addr := range elements(fieldValue, envValue) {
+			if err := checkListenable(field, envKey, addr, tag); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, bound := range []string{"min", "max"} {
+		tag, ok := field.Tag.Lookup(bound)
+		if !ok {
+			continue
+		}
+
+		if err := checkBound(field, envKey, fieldValue, envValue, bound, tag); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkBound checks the loaded value of the given field against the given min or max bound.
+func checkBound(field reflect.StructField, envKey string, fieldValue reflect.Value, envValue, bound, tag string) error {
+	var value, limit float64
+	var length bool
+	var err error
+
+	switch fieldValue.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		length = true
+		value = float64(fieldValue.Len())
+		if fieldValue.Kind() == reflect.String {
+			value = float64(utf8.RuneCountInString(fieldValue.String()))
+		}
+
+		var n int
+		n, err = strconv.Atoi(tag)
+		limit = float64(n)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value = float64(fieldValue.Int())
+		if fieldValue.Type() == durationType {
+			var d time.Duration
+			d, err = time.ParseDuration(tag)
+			limit = float64(d)
+			break
+		}
+
+		limit, err = strconv.ParseFloat(tag, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		value = float64(fieldValue.Uint())
+		if isSize(field) {
+			var size uint64
+			size, err = parseSize(tag)
+			limit = float64(size)
+			break
+		}
+
+		limit, err = strconv.ParseFloat(tag, 64)
+	case reflect.Float32, reflect.Float64:
+		value = fieldValue.Float()
+		limit, err = strconv.ParseFloat(tag, 64)
+	default:
+		return fmt.Errorf("the %s tag of field %s is not supported for %s", bound, field.Name, fieldValue.Type())
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s tag %q of field %s: %v", bound, tag, field.Name, err)
+	}
+
+	if (bound == "min" && value >= limit) || (bound == "max" && value <= limit) {
+		return nil
+	}
+
+	limitName := "at least"
+	if bound == "max" {
+		limitName = "at most"
+	}
+	if length {
+		return fmt.Errorf("%w: the length of %s must be %s %s but it is %d", ErrInvalid, envKey, limitName, tag, int(value))
+	}
+
+	return fmt.Errorf("%w: %s must be %s %s but it is %s", ErrInvalid, envKey, limitName, tag, redact(field, envValue))
+}
+
+// checkExists checks that the given path of the given field is a readable file or directory, as required by
+// the given exists tag.
+func checkExists(field reflect.StructField, envKey, path, tag string) error {
+	if path == "" {
+		return nil
+	}
+
+	var wantDir, wantFile bool
+	switch tag {
+	case "file":
+		wantFile = true
+	case "dir":
+		wantDir = true
+	case "true", "":
+	default:
+		return fmt.Errorf("invalid exists tag %q of field %s, expected file, dir or true", tag, field.Name)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%w: %s points to %s which does not exist", ErrInvalid, envKey, redact(field, path))
+		}
+
+		return fmt.Errorf("%w: %s points to %s which can not be accessed: %v", ErrInvalid, envKey, redact(field, path), err)
+	}
+	if wantFile && info.IsDir() {
+		return fmt.Errorf("%w: %s points to %s which is a directory, not a file", ErrInvalid, envKey, redact(field, path))
+	}
+	if wantDir && !info.IsDir() {
+		return fmt.Errorf("%w: %s points to %s which is not a directory", ErrInvalid, envKey, redact(field, path))
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("%w: %s points to %s which is not readable: %v", ErrInvalid, envKey, redact(field, path), err)
+	}
+
+	return f.Close()
+}
+
+// checkListenable checks that the given address of the given field is a host:port address that satisfies the
+// options of the given listenable tag.
+func checkListenable(field reflect.StructField, envKey, addr, tag string) error {
+	if addr == "" {
+		return nil
+	}
+
+	var free bool
+	minPort, maxPort := 0, 65535
+	for _, option := range strings.Split(tag, ",") {
+		option = strings.TrimSpace(option)
+		switch {
+		case option == "true" || option == "":
+		case option == "free":
+			free = true
+		case strings.Contains(option, "-"):
+			lo, hi, _ := strings.Cut(option, "-")
+			var errLo, errHi error
+			minPort, errLo = strconv.Atoi(lo)
+			maxPort, errHi = strconv.Atoi(hi)
+			if errLo != nil || errHi != nil || minPort > maxPort {
+				return fmt.Errorf("invalid port range %q in the listenable tag of field %s", option, field.Name)
+			}
+		default:
+			return fmt.Errorf("invalid listenable tag %q of field %s, expected true, free or a port range", tag, field.Name)
+		}
+	}
+
+	host, portName, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("%w: %s must be a host:port address but it is %q: %v", ErrInvalid, envKey, redact(field, addr), err)
+	}
+	if strings.ContainsAny(host, " /") {
+		return fmt.Errorf("%w: %s has the invalid host %q", ErrInvalid, envKey, redact(field, host))
+	}
+
+	port, err := net.LookupPort("tcp", portName)
+	if err != nil {
+		return fmt.Errorf("%w: %s has the invalid port %q", ErrInvalid, envKey, redact(field, portName))
+	}
+	if port < minPort || port > maxPort {
+		return fmt.Errorf("%w: the port of %s must be between %d and %d but it is %d", ErrInvalid, envKey, minPort, maxPort, port)
+	}
+
+	if free {
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("%w: %s can not be listened on: %v", ErrInvalid, envKey, err)
+		}
+
+		return l.Close()
+	}
+
+	return nil
+}
+
+// elements returns the string forms of the elements of the given slice or array, or the given value of the
+// variable for the other kinds.
+func elements(fieldValue reflect.Value, envValue string) []string {
+	if fieldValue.Kind() != reflect.Slice && fieldValue.Kind() != reflect.Array {
+		return []string{envValue}
+	}
+
+	values := make([]string, fieldValue.Len())
+	for i := range values {
+		values[i] = fmt.Sprint(fieldValue.Index(i).Interface())
+	}
+
+	return values
+}
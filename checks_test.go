@@ -0,0 +1,310 @@
+package env
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBounds(t *testing.T) {
+	type config struct {
+		Port    int      `mapstructure:"PORT" min:"1" max:"65535"`
+		Workers *int     `mapstructure:"WORKERS" min:"1" max:"8"`
+		Name    *string  `mapstructure:"NAME" min:"2" max:"4"`
+		Tags    []string `mapstructure:"TAGS" max:"2"`
+		Ratio   float64  `mapstructure:"RATIO" max:"1"`
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr bool
+	}{
+		{name: "valid", env: map[string]string{"PORT": "80", "WORKERS": "4", "NAME": "abc", "TAGS": "a,b", "RATIO": "0.5"}},
+		{name: "nil pointers", env: map[string]string{"PORT": "80"}},
+		{name: "pointer at the bounds", env: map[string]string{"PORT": "1", "WORKERS": "8", "NAME": "ab"}},
+		{name: "int below min", env: map[string]string{"PORT": "0"}, wantErr: true},
+		{name: "int above max", env: map[string]string{"PORT": "70000"}, wantErr: true},
+		{name: "*int below min", env: map[string]string{"PORT": "80", "WORKERS": "0"}, wantErr: true},
+		{name: "*int above max", env: map[string]string{"PORT": "80", "WORKERS": "9"}, wantErr: true},
+		{name: "*string too short", env: map[string]string{"PORT": "80", "NAME": "a"}, wantErr: true},
+		{name: "*string too long", env: map[string]string{"PORT": "80", "NAME": "abcde"}, wantErr: true},
+		{name: "slice too long", env: map[string]string{"PORT": "80", "TAGS": "a,b,c"}, wantErr: true},
+		{name: "float above max", env: map[string]string{"PORT": "80", "RATIO": "1.5"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := LoadProvider(staticProvider(tt.env), &cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadProvider() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrInvalid) {
+				t.Errorf("LoadProvider() error = %v, want ErrInvalid", err)
+			}
+		})
+	}
+}
+
+func TestBoundsPointerValues(t *testing.T) {
+	type config struct {
+		Workers *int    `mapstructure:"WORKERS" min:"1"`
+		Name    *string `mapstructure:"NAME" max:"4"`
+	}
+
+	var cfg config
+	if err := LoadProvider(staticProvider{"WORKERS": "3", "NAME": "abc"}, &cfg); err != nil {
+		t.Fatalf("LoadProvider() error = %v", err)
+	}
+	if cfg.Workers == nil || *cfg.Workers != 3 {
+		t.Errorf("Workers = %v, want 3", cfg.Workers)
+	}
+	if cfg.Name == nil || *cfg.Name != "abc" {
+		t.Errorf("Name = %v, want abc", cfg.Name)
+	}
+}
+
+func TestOneOf(t *testing.T) {
+	type config struct {
+		LogLevel string   `mapstructure:"LOG_LEVEL" oneof:"debug info warn error"`
+		Levels   []string `mapstructure:"LEVELS" oneof:"debug info"`
+		Password string   `mapstructure:"PASSWORD" oneof:"a b" secret:"true"`
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+	}{
+		{name: "allowed", env: map[string]string{"LOG_LEVEL": "warn", "LEVELS": "debug,info"}},
+		{name: "unset", env: map[string]string{}},
+		{name: "not allowed", env: map[string]string{"LOG_LEVEL": "trace"}, wantErr: `LOG_LEVEL must be one of debug, info, warn, error but it is "trace"`},
+		{name: "slice element", env: map[string]string{"LEVELS": "debug,warn"}, wantErr: `LEVELS must be one of debug, info but it is "warn"`},
+		{name: "secret", env: map[string]string{"PASSWORD": "hunter2"}, wantErr: `PASSWORD must be one of a, b but it is "` + redacted + `"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := LoadProvider(staticProvider(tt.env), &cfg)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("LoadProvider() error = %v", err)
+				}
+				return
+			}
+
+			if err == nil || !errors.Is(err, ErrInvalid) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("LoadProvider() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBoundUnits(t *testing.T) {
+	type config struct {
+		Timeout time.Duration     `mapstructure:"TIMEOUT" min:"1s" max:"1m"`
+		Memory  uint64            `mapstructure:"MEMORY" unit:"bytes" max:"1MiB"`
+		Name    string            `mapstructure:"NAME" max:"3"`
+		Labels  map[string]string `mapstructure:"LABELS" min:"1"`
+	}
+	type invalidConfig struct {
+		Port int `mapstructure:"PORT" min:"one"`
+	}
+	type unsupportedConfig struct {
+		Debug bool `mapstructure:"DEBUG" max:"1"`
+	}
+
+	tests := []struct {
+		name    string
+		load    func(src string) error
+		src     string
+		wantErr string
+	}{
+		{name: "valid", load: loadString[config], src: "TIMEOUT=30s\nMEMORY=512KiB\nNAME=héé\nLABELS=a=b"},
+		{name: "duration below min", load: loadString[config], src: "TIMEOUT=500ms", wantErr: "TIMEOUT must be at least 1s but it is 500ms"},
+		{name: "duration above max", load: loadString[config], src: "TIMEOUT=2m", wantErr: "TIMEOUT must be at most 1m but it is 2m"},
+		{name: "size above max", load: loadString[config], src: "MEMORY=2MiB", wantErr: "MEMORY must be at most 1MiB but it is 2MiB"},
+		{name: "string length", load: loadString[config], src: "NAME=abcd", wantErr: "the length of NAME must be at most 3 but it is 4"},
+		{name: "map length", load: loadString[config], src: "LABELS=", wantErr: "the length of LABELS must be at least 1 but it is 0"},
+		{name: "invalid tag", load: loadString[invalidConfig], src: "PORT=80", wantErr: `invalid min tag "one" of field Port`},
+		{name: "unsupported kind", load: loadString[unsupportedConfig], src: "DEBUG=true", wantErr: "the max tag of field Debug is not supported for bool"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.load(tt.src)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("LoadReader() error = %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("LoadReader() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExists(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "cert.pem")
+	if err := os.WriteFile(file, []byte("cert"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing")
+
+	type config struct {
+		Cert  string   `mapstructure:"CERT" exists:"file"`
+		Data  string   `mapstructure:"DATA" exists:"dir"`
+		Path  string   `mapstructure:"PATH_ANY" exists:"true"`
+		Paths []string `mapstructure:"PATHS" exists:"file"`
+	}
+	type invalidConfig struct {
+		Path string `mapstructure:"PATH_ANY" exists:"socket"`
+	}
+
+	tests := []struct {
+		name    string
+		load    func(src string) error
+		src     string
+		wantErr string
+	}{
+		{name: "valid", load: loadString[config], src: "CERT=" + file + "\nDATA=" + dir + "\nPATH_ANY=" + dir + "\nPATHS=" + file},
+		{name: "unset", load: loadString[config], src: ""},
+		{name: "missing file", load: loadString[config], src: "CERT=" + missing, wantErr: "CERT points to " + missing + " which does not exist"},
+		{name: "directory for a file", load: loadString[config], src: "CERT=" + dir, wantErr: "which is a directory, not a file"},
+		{name: "file for a directory", load: loadString[config], src: "DATA=" + file, wantErr: "which is not a directory"},
+		{name: "slice element", load: loadString[config], src: "PATHS=" + file + "," + missing, wantErr: "PATHS points to " + missing},
+		{name: "invalid tag", load: loadString[invalidConfig], src: "PATH_ANY=" + dir, wantErr: `invalid exists tag "socket"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.load(tt.src)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("LoadReader() error = %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("LoadReader() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestListenable(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	taken := l.Addr().String()
+
+	type config struct {
+		Addr   string `mapstructure:"ADDR" listenable:"true"`
+		Public string `mapstructure:"PUBLIC" listenable:"1024-65535"`
+		Free   string `mapstructure:"FREE" listenable:"free"`
+	}
+	type invalidConfig struct {
+		Addr string `mapstructure:"ADDR" listenable:"9000-80"`
+	}
+
+	tests := []struct {
+		name    string
+		load    func(src string) error
+		src     string
+		wantErr string
+	}{
+		{name: "valid", load: loadString[config], src: "ADDR=localhost:http\nPUBLIC=:8080\nFREE=127.0.0.1:0"},
+		{name: "missing port", load: loadString[config], src: "ADDR=localhost", wantErr: `ADDR must be a host:port address but it is "localhost"`},
+		{name: "invalid host", load: loadString[config], src: "ADDR=local/host:80", wantErr: `ADDR has the invalid host "local/host"`},
+		{name: "invalid port", load: loadString[config], src: "ADDR=localhost:port", wantErr: `ADDR has the invalid port "port"`},
+		{name: "port out of range", load: loadString[config], src: "PUBLIC=:80", wantErr: "the port of PUBLIC must be between 1024 and 65535 but it is 80"},
+		{name: "port taken", load: loadString[config], src: "FREE=" + taken, wantErr: "FREE can not be listened on"},
+		{name: "invalid range", load: loadString[invalidConfig], src: "ADDR=:80", wantErr: `invalid port range "9000-80"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.load(tt.src)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("LoadReader() error = %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("LoadReader() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSeverityWarn(t *testing.T) {
+	type config struct {
+		Pool     int    `mapstructure:"POOL" max:"10" severity:"warn"`
+		Level    string `mapstructure:"LEVEL" validate:"omitempty,oneof=debug info" severity:"warn"`
+		Replica  string `mapstructure:"REPLICA" required_with:"Pool" severity:"warn"`
+		Password string `mapstructure:"PASSWORD" oneof:"a b" secret:"true" severity:"warn"`
+		Port     int    `mapstructure:"PORT" max:"65535"`
+	}
+
+	tests := []struct {
+		name         string
+		env          map[string]string
+		wantKeys     []string
+		wantErr      bool
+		wantNotInLog string
+	}{
+		{name: "valid", env: map[string]string{"POOL": "5", "REPLICA": "r"}},
+		{name: "constraint", env: map[string]string{"POOL": "20", "REPLICA": "r"}, wantKeys: []string{"POOL"}},
+		{name: "validate tag", env: map[string]string{"LEVEL": "trace"}, wantKeys: []string{"LEVEL"}},
+		{name: "condition", env: map[string]string{"POOL": "5"}, wantKeys: []string{"REPLICA"}},
+		{name: "secret", env: map[string]string{"PASSWORD": "hunter2"}, wantKeys: []string{"PASSWORD"}, wantNotInLog: "hunter2"},
+		{name: "error and warning", env: map[string]string{"POOL": "20", "REPLICA": "r", "PORT": "70000"}, wantKeys: []string{"POOL"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			var report Report
+			var cfg config
+			err := LoadProvider(staticProvider(tt.env), &cfg, WithReport(&report), WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadProvider() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var keys []string
+			for _, w := range report.Warnings {
+				keys = append(keys, w.Key)
+				if !errors.Is(w, ErrInvalid) && !errors.Is(w, ErrMissing) {
+					t.Errorf("warning = %v, want ErrInvalid or ErrMissing", w)
+				}
+			}
+			if !slices.Equal(keys, tt.wantKeys) {
+				t.Errorf("warnings = %v, want the keys %q", report.Warnings, tt.wantKeys)
+			}
+			if n := strings.Count(buf.String(), "violates a constraint"); n != len(tt.wantKeys) {
+				t.Errorf("logged %d warnings, want %d:\n%s", n, len(tt.wantKeys), buf.String())
+			}
+			if tt.wantNotInLog != "" && strings.Contains(buf.String()+report.Warnings.Error(), tt.wantNotInLog) {
+				t.Errorf("the secret value is not redacted:\n%s", buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package env
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// validateConditions enforces the required_if and required_with tags of the fields of the given struct and its
+// nested structs, which refer to the other fields of the same struct by their names:
+//   - required_if:"TLSEnabled true" requires the field if TLSEnabled is true, several field and value pairs
+//     can be given and all of them must match.
+//   - required_with:"SMTPHost SMTPPort" requires the field if any of SMTPHost and SMTPPort is set.
+func (p *parser) validateConditions(v reflect.Value, path string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || p.skipped(field) {
+			continue
+		}
+
+		fieldName := path + field.Name
+		if isNested(field.Type) {
+			if isJSON, _ := boolTag(field, "envJSON"); !isJSON {
+				p.validateConditions(v.Field(i), fieldName+".")
+				continue
+			}
+		}
+		if p.failed(fieldName) {
+			continue
+		}
+
+		reason, err := requiredBy(v, field)
+		if err != nil {
+			p.fail(field, fieldName, p.fields[fieldName].key, "", err)
+			continue
+		}
+		if reason == "" || !v.Field(i).IsZero() {
+			continue
+		}
+
+		key := p.fields[fieldName].key
+		p.violate(field, fieldName, key, "", fmt.Errorf("%w: %s (required %s)", ErrMissing, cmp.Or(key, fieldName), reason))
+	}
+}
+
+// requiredBy returns the reason why the given field of the given struct is required by its required_if or
+// required_with tag, an empty reason is returned if it is not required.
+func requiredBy(v reflect.Value, field reflect.StructField) (string, error) {
+	if tag, ok := field.Tag.Lookup("required_if"); ok {
+		parts := strings.Fields(tag)
+		if len(parts) == 0 || len(parts)%2 != 0 {
+			return "", fmt.Errorf("invalid required_if tag %q of field %s, expected pairs of fields and values", tag, field.Name)
+		}
+
+		matches := true
+		var conditions []string
+		for i := 0; i < len(parts); i += 2 {
+			other, err := sibling(v, field, parts[i])
+			if err != nil {
+				return "", err
+			}
+
+			conditions = append(conditions, parts[i]+" is "+parts[i+1])
+			if fmt.Sprint(other.Interface()) != parts[i+1] {
+				matches = false
+			}
+		}
+		if matches {
+			return "if " + strings.Join(conditions, " and "), nil
+		}
+	}
+
+	if tag, ok := field.Tag.Lookup("required_with"); ok {
+		for _, name := range strings.Fields(tag) {
+			other, err := sibling(v, field, name)
+			if err != nil {
+				return "", err
+			}
+
+			if !other.IsZero() {
+				return "with " + name, nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// sibling returns the field with the given name of the given struct, which the tags of the given field refer to.
+func sibling(v reflect.Value, field reflect.StructField, name string) (reflect.Value, error) {
+	other := v.FieldByName(name)
+	if !other.IsValid() {
+		return reflect.Value{}, fmt.Errorf("the tags of field %s refer to the unknown field %s", field.Name, name)
+	}
+
+	return other, nil
+}
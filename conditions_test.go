@@ -0,0 +1,67 @@
+package env
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// loadString loads a config of the given type from the given dotenv source.
+func loadString[T any](src string) error {
+	var cfg T
+	return LoadReader(strings.NewReader(src), &cfg, WithPrecedence(FileOnly))
+}
+
+func TestValidateConditions(t *testing.T) {
+	type mail struct {
+		SMTPHost string `mapstructure:"SMTP_HOST"`
+		SMTPPort int    `mapstructure:"SMTP_PORT" required_with:"SMTPHost"`
+	}
+	type config struct {
+		TLSEnabled bool   `mapstructure:"TLS_ENABLED"`
+		Env        string `mapstructure:"ENV"`
+		CertFile   string `mapstructure:"CERT_FILE" required_if:"TLSEnabled true Env production"`
+		Mail       mail
+	}
+	type invalidConfig struct {
+		Host string `mapstructure:"HOST" required_if:"TLSEnabled"`
+	}
+	type unknownConfig struct {
+		Host string `mapstructure:"HOST" required_with:"Port"`
+	}
+
+	tests := []struct {
+		name        string
+		load        func(src string) error
+		src         string
+		wantErr     string
+		wantMissing bool
+	}{
+		{name: "not required", src: "TLS_ENABLED=true\nENV=staging", load: loadString[config]},
+		{name: "required if", src: "TLS_ENABLED=true\nENV=production", load: loadString[config], wantErr: "CERT_FILE (required if TLSEnabled is true and Env is production)", wantMissing: true},
+		{name: "required if set", src: "TLS_ENABLED=true\nENV=production\nCERT_FILE=cert.pem", load: loadString[config]},
+		{name: "required with", src: "SMTP_HOST=mail", load: loadString[config], wantErr: "SMTP_PORT (required with SMTPHost)", wantMissing: true},
+		{name: "required with set", src: "SMTP_HOST=mail\nSMTP_PORT=25", load: loadString[config]},
+		{name: "invalid tag", src: "HOST=a", load: loadString[invalidConfig], wantErr: "invalid required_if tag"},
+		{name: "unknown field", src: "HOST=a", load: loadString[unknownConfig], wantErr: "refer to the unknown field Port"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.load(tt.src)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("LoadReader() error = %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("LoadReader() error = %v, want %q", err, tt.wantErr)
+			}
+			if tt.wantMissing && !errors.Is(err, ErrMissing) {
+				t.Errorf("LoadReader() error = %v, want %v", err, ErrMissing)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package env
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultConsulAddress is the address of Consul when neither the Address nor CONSUL_HTTP_ADDR is set.
+	defaultConsulAddress = "http://127.0.0.1:8500"
+	// consulWait is how long the blocking queries of Watch wait for a change.
+	consulWait = 5 * time.Minute
+	// consulRetryInterval is the interval between the blocking queries after a failure.
+	consulRetryInterval = 10 * time.Second
+)
+
+// keyReplacer replaces the separators of the keys of the key-value stores with underscores.
+var keyReplacer = strings.NewReplacer("/", "_", "-", "_", ".", "_")
+
+// Consul fetches the keys under a prefix of the Consul KV store. The keys are derived from the Consul keys
+// relative to the prefix (e.g. myapp/prod/db/host becomes DB_HOST with the myapp/prod/ prefix), and Watch
+// notifies about the changes to them with blocking queries.
+type Consul struct {
+	// Address is the address of Consul, it defaults to CONSUL_HTTP_ADDR.
+	Address string
+	// Prefix is the prefix of the keys, e.g. myapp/prod/.
+	Prefix string
+	// Datacenter is the datacenter of the keys, it defaults to the datacenter of the agent.
+	Datacenter string
+	// Token is the ACL token that authenticates the requests, it defaults to CONSUL_HTTP_TOKEN.
+	Token string
+	// Client is the client that sends the requests, it defaults to a client with a 10 second timeout for
+	// Fetch and a client without a timeout for the blocking queries of Watch.
+	Client *http.Client
+
+	mu     sync.Mutex
+	index  uint64
+	values map[string]string
+}
+
+// Fetch fetches the keys under the prefix.
+func (c *Consul) Fetch(ctx context.Context) (map[string]string, error) {
+	m, index, err := c.list(ctx, c.Client, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.index, c.values = index, m
+	c.mu.Unlock()
+
+	return maps.Clone(m), nil
+}
+
+// Watch watches the keys under the prefix with blocking queries until the context is done and calls fn
+// with all the keys whenever they change, it is typically run in a goroutine that reloads the config in
+// fn. Errors are passed to fn.
+func (c *Consul) Watch(ctx context.Context, fn func(map[string]string, error)) error {
+	client := c.Client
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	c.mu.Lock()
+	index, last := c.index, c.values
+	c.mu.Unlock()
+
+	for {
+		m, next, err := c.list(ctx, client, index)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// without an index the next query would not block and the agent would be flooded with queries
+		if err == nil && next == 0 {
+			err = fmt.Errorf("the response to the query of the Consul keys under %s has no X-Consul-Index", c.Prefix)
+		}
+		if err != nil {
+			fn(nil, err)
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(consulRetryInterval):
+			}
+			continue
+		}
+
+		// the index is reset when it goes backwards, as recommended for blocking queries
+		if next < index {
+			next = 0
+		}
+
+		changed := last != nil && !maps.Equal(m, last)
+
+		c.mu.Lock()
+		c.index, c.values = next, m
+		c.mu.Unlock()
+
+		index, last = next, m
+		if changed {
+			fn(maps.Clone(m), nil)
+		}
+	}
+}
+
+// list lists the keys under the prefix and returns them with the index of the response, the request blocks
+// until the index changes if it is not zero.
+func (c *Consul) list(ctx context.Context, client *http.Client, index uint64) (map[string]string, uint64, error) {
+	if client == nil {
+		client = defaultHTTPClient
+	}
+
+	query := url.Values{"recurse": {"true"}}
+	if c.Datacenter != "" {
+		query.Set("dc", c.Datacenter)
+	}
+	if index > 0 {
+		query.Set("index", strconv.FormatUint(index, 10))
+		query.Set("wait", consulWait.String())
+	}
+
+	address := cmp.Or(c.Address, os.Getenv("CONSUL_HTTP_ADDR"), defaultConsulAddress)
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(
+		"%s/v1/kv/%s?%s",
+		strings.TrimSuffix(address, "/"),
+		strings.TrimPrefix(c.Prefix, "/"),
+		query.Encode(),
+	), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if token := cmp.Or(c.Token, os.Getenv("CONSUL_HTTP_TOKEN")); token != "" {
+		req.Header.Set("X-Consul-Token", token)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch the Consul keys under %s: %w", c.Prefix, err)
+	}
+	defer res.Body.Close()
+
+	index, _ = strconv.ParseUint(res.Header.Get("X-Consul-Index"), 10, 64)
+
+	m := make(map[string]string)
+	// Consul responds with 404 Not Found when there are no keys under the prefix
+	if res.StatusCode == http.StatusNotFound {
+		return m, index, nil
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, 0, fmt.Errorf("failed to fetch the Consul keys under %s: %w", c.Prefix, newStatusError(res))
+	}
+
+	var pairs []struct {
+		Key   string
+		Value string
+	}
+	if err := json.NewDecoder(res.Body).Decode(&pairs); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode the Consul keys under %s: %w", c.Prefix, err)
+	}
+
+	for _, pair := range pairs {
+		// folders have no values
+		if strings.HasSuffix(pair.Key, "/") {
+			continue
+		}
+
+		value, err := base64.StdEncoding.DecodeString(pair.Value)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid value of the Consul key %s: %w", pair.Key, err)
+		}
+
+		key := strings.Trim(strings.TrimPrefix(pair.Key, strings.TrimPrefix(c.Prefix, "/")), "/")
+		m[strings.ToUpper(keyReplacer.Replace(key))] = string(value)
+	}
+
+	return m, index, nil
+}
@@ -0,0 +1,144 @@
+package env
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConsulFetch(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		status int
+		body   string
+		want   map[string]string
+	}{
+		{
+			name:   "keys",
+			prefix: "myapp/prod/",
+			status: http.StatusOK,
+			body: fmt.Sprintf(`[{"Key":"myapp/prod/","Value":null},{"Key":"myapp/prod/db/host","Value":%q},{"Key":"myapp/prod/log-level","Value":%q}]`,
+				base64.StdEncoding.EncodeToString([]byte("localhost")), base64.StdEncoding.EncodeToString([]byte("debug"))),
+			want: map[string]string{"DB_HOST": "localhost", "LOG_LEVEL": "debug"},
+		},
+		{name: "no keys", prefix: "myapp/", status: http.StatusNotFound, want: map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v1/kv/"+tt.prefix || r.URL.Query().Get("recurse") != "true" || r.Header.Get("X-Consul-Token") != "token" {
+					t.Errorf("unexpected request %s with token %q", r.URL, r.Header.Get("X-Consul-Token"))
+				}
+
+				w.Header().Set("X-Consul-Index", "7")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			got, err := (&Consul{Address: srv.URL, Prefix: tt.prefix, Token: "token"}).Fetch(context.Background())
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fetch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConsulWatchWithoutIndex(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte("[]"))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	var errs atomic.Int32
+	err := (&Consul{Address: srv.URL, Prefix: "myapp/"}).Watch(ctx, func(_ map[string]string, err error) {
+		if err != nil && strings.Contains(err.Error(), "X-Consul-Index") {
+			errs.Add(1)
+		}
+	})
+	if err != context.DeadlineExceeded {
+		t.Fatalf("Watch() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("requests = %d, want 1 as the queries without an index are backed off", got)
+	}
+	if errs.Load() != 1 {
+		t.Errorf("fn was called with %d index errors, want 1", errs.Load())
+	}
+}
+
+func TestConsulWatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		changed string
+		want    []map[string]string
+	}{
+		{name: "changed", changed: "remote", want: []map[string]string{{"DB_HOST": "remote"}}},
+		{name: "unchanged", changed: "localhost"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				value := "localhost"
+				switch r.URL.Query().Get("index") {
+				case "":
+					w.Header().Set("X-Consul-Index", "7")
+				case "7":
+					if r.URL.Query().Get("wait") == "" {
+						t.Errorf("unexpected query %s without a wait", r.URL)
+					}
+					w.Header().Set("X-Consul-Index", "8")
+					value = tt.changed
+				default:
+					// the keys do not change again, the watch is stopped
+					cancel()
+					<-r.Context().Done()
+					return
+				}
+
+				_, _ = fmt.Fprintf(w, `[{"Key":"myapp/db/host","Value":%q}]`, base64.StdEncoding.EncodeToString([]byte(value)))
+			}))
+			defer srv.Close()
+
+			c := &Consul{Address: srv.URL, Prefix: "myapp/"}
+			if _, err := c.Fetch(ctx); err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+
+			var got []map[string]string
+			err := c.Watch(ctx, func(values map[string]string, err error) {
+				if err != nil {
+					t.Errorf("Watch() called fn with error = %v", err)
+				}
+				got = append(got, values)
+			})
+			if err != context.Canceled {
+				t.Fatalf("Watch() error = %v, want %v", err, context.Canceled)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Watch() values = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
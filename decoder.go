@@ -0,0 +1,57 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// DecoderFunc parses the given value into a value of the type that it is registered for.
+type DecoderFunc func(value string) (any, error)
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = make(map[reflect.Type]DecoderFunc)
+)
+
+// RegisterDecoder registers the given decoder for the given type, fields of the given type are parsed
+// with the decoder instead of the built in parsers. Registering a decoder for a type that already has
+// a decoder replaces it.
+func RegisterDecoder(t reflect.Type, fn DecoderFunc) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+
+	decoders[t] = fn
+}
+
+// RegisterDecoderFunc registers the given decoder for the type T.
+func RegisterDecoderFunc[T any](fn func(value string) (T, error)) {
+	RegisterDecoder(reflect.TypeOf((*T)(nil)).Elem(), func(value string) (any, error) {
+		return fn(value)
+	})
+}
+
+// decoderFor returns the decoder that is registered for the given type.
+func decoderFor(t reflect.Type) (DecoderFunc, bool) {
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+
+	fn, ok := decoders[t]
+	return fn, ok
+}
+
+// decode parses the given value with the given decoder and sets it.
+func decode(fieldValue reflect.Value, fn DecoderFunc, envKey, envValue string) error {
+	val, err := fn(envValue)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s as %s: %v", envKey, fieldValue.Type(), err)
+	}
+
+	v := reflect.ValueOf(val)
+	if !v.IsValid() || !v.Type().AssignableTo(fieldValue.Type()) {
+		return fmt.Errorf("failed to parse %s as %s: decoder returned %T", envKey, fieldValue.Type(), val)
+	}
+
+	fieldValue.Set(v)
+	return nil
+}
@@ -0,0 +1,71 @@
+package env
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// color is a type that is parsed with a registered decoder.
+type color struct {
+	R, G, B uint8
+}
+
+// hostPort is a type whose registered decoder returns a value of the wrong type.
+type hostPort string
+
+func TestRegisterDecoder(t *testing.T) {
+	RegisterDecoderFunc(func(value string) (color, error) {
+		switch value {
+		case "red":
+			return color{R: 255}, nil
+		case "blue":
+			return color{B: 255}, nil
+		}
+
+		return color{}, errors.New("unknown color")
+	})
+	RegisterDecoder(reflect.TypeOf(hostPort("")), func(value string) (any, error) {
+		return value, nil
+	})
+
+	type config struct {
+		Color   color    `mapstructure:"COLOR"`
+		Palette []color  `mapstructure:"PALETTE"`
+		Accent  *color   `mapstructure:"ACCENT"`
+		Address hostPort `mapstructure:"ADDRESS"`
+	}
+
+	tests := []struct {
+		name    string
+		src     string
+		want    config
+		wantErr string
+	}{
+		{name: "value", src: "COLOR=red", want: config{Color: color{R: 255}}},
+		{name: "items", src: "PALETTE=red,blue", want: config{Palette: []color{{R: 255}, {B: 255}}}},
+		{name: "pointer", src: "ACCENT=blue", want: config{Accent: &color{B: 255}}},
+		{name: "decoder error", src: "COLOR=green", wantErr: "failed to parse COLOR as env.color: unknown color"},
+		{name: "wrong type", src: "ADDRESS=localhost:80", wantErr: "decoder returned string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := LoadReader(strings.NewReader(tt.src), &cfg, WithPrecedence(FileOnly))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadReader() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if !reflect.DeepEqual(cfg, tt.want) {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+)
+
+// ErrDeprecated is the error of the warnings about the variables of the fields that are tagged as deprecated.
+var ErrDeprecated = errors.New("deprecated environment variable")
+
+// migrateDeprecated warns about the variables of the fields of the given struct type and its nested structs that
+// are tagged with deprecated (e.g. deprecated:"use DB_DSN instead") when they are set. If the field is tagged
+// with replacedBy (e.g. replacedBy:"DB_DSN") and the replacement is not set, the value of the deprecated variable
+// is copied to the replacement so that the fields can be renamed without breaking the existing deployments.
+func (p *parser) migrateDeprecated(t reflect.Type, prefix, path string) {
+	prefix = p.canonical(prefix)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldName := path + field.Name
+		if p.skipped(field) {
+			continue
+		}
+
+		if isNested(field.Type) {
+			if isJSON, _ := boolTag(field, "envJSON"); !isJSON {
+				p.migrateDeprecated(field.Type, prefix+p.nestedPrefix(field), fieldName+".")
+				continue
+			}
+		}
+
+		message, ok := field.Tag.Lookup("deprecated")
+		if !ok {
+			continue
+		}
+
+		key, ok := p.fieldKey(field)
+		if !ok || key == "" {
+			continue
+		}
+
+		envKey := prefix + key
+		envValue, ok := p.envMap[envKey]
+		if !ok {
+			continue
+		}
+
+		p.warnings = append(p.warnings, FieldError{
+			Key:       envKey,
+			FieldName: fieldName,
+			Value:     redact(field, envValue),
+			Err:       fmt.Errorf("%w: %s, %s", ErrDeprecated, envKey, message),
+		})
+		p.o.log().Warn("the environment variable is deprecated", "key", envKey, "field", fieldName, "message", message)
+
+		replacement, ok := field.Tag.Lookup("replacedBy")
+		if !ok {
+			continue
+		}
+		replacement = p.canonical(prefix + replacement)
+		if _, ok := p.envMap[replacement]; ok {
+			continue
+		}
+
+		// the map is cloned as it can be the cache of a provider
+		p.envMap = maps.Clone(p.envMap)
+		p.envMap[replacement] = envValue
+	}
+}
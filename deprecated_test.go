@@ -0,0 +1,70 @@
+package env
+
+import (
+	"errors"
+	"maps"
+	"reflect"
+	"testing"
+)
+
+func TestMigrateDeprecated(t *testing.T) {
+	type database struct {
+		URL string `mapstructure:"URL" deprecated:"use DB_DSN instead" replacedBy:"DSN"`
+		DSN string `mapstructure:"DSN"`
+	}
+	type config struct {
+		Host     string   `mapstructure:"HOST" deprecated:"it is not used anymore"`
+		Database database `prefix:"DB_"`
+	}
+
+	tests := []struct {
+		name         string
+		env          map[string]string
+		want         config
+		wantWarnings []string
+	}{
+		{name: "not set", env: map[string]string{"DB_DSN": "postgres://new"}, want: config{Database: database{DSN: "postgres://new"}}},
+		{name: "deprecated", env: map[string]string{"HOST": "localhost"}, want: config{Host: "localhost"}, wantWarnings: []string{"HOST"}},
+		{
+			name:         "replaced",
+			env:          map[string]string{"DB_URL": "postgres://old"},
+			want:         config{Database: database{URL: "postgres://old", DSN: "postgres://old"}},
+			wantWarnings: []string{"DB_URL"},
+		},
+		{
+			name:         "replacement set",
+			env:          map[string]string{"DB_URL": "postgres://old", "DB_DSN": "postgres://new"},
+			want:         config{Database: database{URL: "postgres://old", DSN: "postgres://new"}},
+			wantWarnings: []string{"DB_URL"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := maps.Clone(tt.env)
+			var report Report
+			var cfg config
+			if err := LoadProvider(staticProvider(env), &cfg, WithReport(&report)); err != nil {
+				t.Fatalf("LoadProvider() error = %v", err)
+			}
+
+			if cfg != tt.want {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+			if !maps.Equal(env, tt.env) {
+				t.Errorf("the values of the provider were modified: %q", env)
+			}
+
+			var keys []string
+			for _, w := range report.Warnings {
+				keys = append(keys, w.Key)
+				if !errors.Is(w, ErrDeprecated) {
+					t.Errorf("warning = %v, want %v", w, ErrDeprecated)
+				}
+			}
+			if !reflect.DeepEqual(keys, tt.wantWarnings) {
+				t.Errorf("warnings = %v, want the keys %q", report.Warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
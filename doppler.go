@@ -0,0 +1,52 @@
+package env
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// defaultDopplerAPI is the URL of the Doppler API.
+const defaultDopplerAPI = "https://api.doppler.com"
+
+// Doppler fetches the secrets of a Doppler config, it replaces the doppler run wrapper for Go services.
+type Doppler struct {
+	// Token is the service token that authenticates the requests, it defaults to DOPPLER_TOKEN. A service
+	// token is scoped to a config, so the Project and the Config are only needed for the other tokens.
+	Token string
+	// Project is the project of the secrets, it defaults to DOPPLER_PROJECT.
+	Project string
+	// Config is the config of the secrets (e.g. prd), it defaults to DOPPLER_CONFIG.
+	Config string
+	// API is the URL of the Doppler API, it defaults to https://api.doppler.com.
+	API string
+	// Client is the client that sends the requests, it defaults to a client with a 10 second timeout.
+	Client *http.Client
+}
+
+// Fetch fetches the secrets of the config.
+func (d *Doppler) Fetch(ctx context.Context) (map[string]string, error) {
+	token := cmp.Or(d.Token, os.Getenv("DOPPLER_TOKEN"))
+	if token == "" {
+		return nil, fmt.Errorf("the Doppler token is required")
+	}
+
+	query := url.Values{"format": {"json"}}
+	if project := cmp.Or(d.Project, os.Getenv("DOPPLER_PROJECT")); project != "" {
+		query.Set("project", project)
+	}
+	if config := cmp.Or(d.Config, os.Getenv("DOPPLER_CONFIG")); config != "" {
+		query.Set("config", config)
+	}
+
+	var m map[string]string
+	header := http.Header{"Authorization": {"Bearer " + token}}
+	if err := doJSON(ctx, d.Client, http.MethodGet, cmp.Or(d.API, defaultDopplerAPI)+"/v3/configs/config/secrets/download?"+query.Encode(), header, nil, &m); err != nil {
+		return nil, fmt.Errorf("failed to fetch the Doppler secrets: %w", err)
+	}
+
+	return m, nil
+}
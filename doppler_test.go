@@ -0,0 +1,73 @@
+package env
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDopplerFetch(t *testing.T) {
+	tests := []struct {
+		name      string
+		doppler   Doppler
+		env       map[string]string
+		wantToken string
+		wantQuery string
+		wantErr   string
+	}{
+		{
+			name:      "service token",
+			doppler:   Doppler{Token: "dp.st.prd"},
+			wantToken: "dp.st.prd",
+			wantQuery: "format=json",
+		},
+		{
+			name:      "project and config",
+			doppler:   Doppler{Token: "dp.pt.token", Project: "billing", Config: "prd"},
+			wantToken: "dp.pt.token",
+			wantQuery: "config=prd&format=json&project=billing",
+		},
+		{
+			name:      "environment",
+			env:       map[string]string{"DOPPLER_TOKEN": "dp.st.env", "DOPPLER_PROJECT": "billing", "DOPPLER_CONFIG": "dev"},
+			wantToken: "dp.st.env",
+			wantQuery: "config=dev&format=json&project=billing",
+		},
+		{name: "no token", wantErr: "the Doppler token is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"DOPPLER_TOKEN", "DOPPLER_PROJECT", "DOPPLER_CONFIG"} {
+				t.Setenv(key, tt.env[key])
+			}
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v3/configs/config/secrets/download" || r.URL.RawQuery != tt.wantQuery || r.Header.Get("Authorization") != "Bearer "+tt.wantToken {
+					t.Errorf("unexpected request %s with Authorization %q", r.URL, r.Header.Get("Authorization"))
+				}
+				_, _ = w.Write([]byte(`{"DB_HOST":"localhost","DB_PASSWORD":"s3cret"}`))
+			}))
+			defer srv.Close()
+
+			d := tt.doppler
+			d.API = srv.URL
+			got, err := d.Fetch(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Fetch() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if want := map[string]string{"DB_HOST": "localhost", "DB_PASSWORD": "s3cret"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("Fetch() = %q, want %q", got, want)
+			}
+		})
+	}
+}
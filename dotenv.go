@@ -0,0 +1,197 @@
+package env
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Parser parses the contents of a config file into a map of its keys and values.
+type Parser func(r io.Reader) (map[string]string, error)
+
+// ParseDotenv parses the contents of a dotenv file, it is the default parser for the config files.
+// It follows the syntax of the reference dotenv implementations:
+//
+//   - every line contains a KEY=value pair which can be prefixed with export
+//   - empty lines and lines starting with # are ignored
+//   - unquoted values end at a # that starts the value or is preceded by a whitespace (an inline comment)
+//   - values wrapped in single quotes or backticks are taken literally
+//   - values wrapped in double quotes expand the \n, \r, \t, \", \\ and \$ escapes
+//   - quoted values can span multiple lines (e.g. PEM blocks)
+func ParseDotenv(r io.Reader) (map[string]string, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &dotenvParser{
+		src:  strings.ReplaceAll(string(b), "\r\n", "\n"),
+		line: 1,
+	}
+
+	m := make(map[string]string)
+	for {
+		p.skipBlank()
+		if p.done() {
+			return m, nil
+		}
+
+		if p.peek() == '#' {
+			p.skipLine()
+			continue
+		}
+
+		key, value, err := p.pair()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", p.line, err)
+		}
+
+		m[key] = value
+	}
+}
+
+// dotenvParser holds the state of ParseDotenv.
+type dotenvParser struct {
+	src  string
+	pos  int
+	line int
+}
+
+// pair parses a KEY=value pair that starts at the current position.
+func (p *dotenvParser) pair() (string, string, error) {
+	if rest := p.src[p.pos:]; strings.HasPrefix(rest, "export ") || strings.HasPrefix(rest, "export\t") {
+		p.pos += len("export")
+		p.skipSpaces()
+	}
+
+	end := strings.IndexAny(p.src[p.pos:], "=\n")
+	if end == -1 || p.src[p.pos+end] != '=' {
+		return "", "", fmt.Errorf("expected a KEY=value pair")
+	}
+
+	key := strings.TrimSpace(p.src[p.pos : p.pos+end])
+	if key == "" {
+		return "", "", fmt.Errorf("empty key")
+	}
+	if strings.ContainsAny(key, " \t") {
+		return "", "", fmt.Errorf("invalid key %q", key)
+	}
+
+	p.pos += end + 1
+	p.skipSpaces()
+
+	if !p.done() {
+		if q := p.peek(); q == '"' || q == '\'' || q == '`' {
+			value, err := p.quoted(q)
+			if err != nil {
+				return "", "", fmt.Errorf("%s: %w", key, err)
+			}
+
+			return key, value, nil
+		}
+	}
+
+	return key, p.unquoted(), nil
+}
+
+// quoted parses a value wrapped in the given quote, the rest of the line after the closing quote
+// may only contain a comment.
+func (p *dotenvParser) quoted(q byte) (string, error) {
+	start := p.pos + 1
+
+	i := start
+	for ; i < len(p.src) && p.src[i] != q; i++ {
+		if p.src[i] == '\\' && q == '"' {
+			i++
+		}
+	}
+	if i >= len(p.src) {
+		return "", fmt.Errorf("unterminated quoted value")
+	}
+
+	value := p.src[start:i]
+	p.line += strings.Count(value, "\n")
+	p.pos = i + 1
+
+	p.skipSpaces()
+	if !p.done() && p.peek() != '\n' && p.peek() != '#' {
+		return "", fmt.Errorf("unexpected characters after the quoted value")
+	}
+	p.skipLine()
+
+	if q == '"' {
+		value = unescape(value)
+	}
+
+	return value, nil
+}
+
+// unquoted parses a value that is not wrapped in quotes, it ends at the end of the line or at an inline comment.
+func (p *dotenvParser) unquoted() string {
+	end := strings.IndexByte(p.src[p.pos:], '\n')
+	if end == -1 {
+		end = len(p.src) - p.pos
+	}
+
+	// the leading whitespaces are already skipped, so a # at the start of the value follows the = or a whitespace
+	value := p.src[p.pos : p.pos+end]
+	for i := 0; i < len(value); i++ {
+		if value[i] == '#' && (i == 0 || value[i-1] == ' ' || value[i-1] == '\t') {
+			value = value[:i]
+			break
+		}
+	}
+
+	p.pos += end
+	return strings.TrimSpace(value)
+}
+
+// unescape expands the escape sequences that are supported in values wrapped in double quotes.
+func unescape(value string) string {
+	return strings.NewReplacer(
+		`\n`, "\n",
+		`\r`, "\r",
+		`\t`, "\t",
+		`\"`, `"`,
+		`\\`, `\`,
+		`\$`, `$`,
+	).Replace(value)
+}
+
+// skipBlank skips the whitespaces and the empty lines.
+func (p *dotenvParser) skipBlank() {
+	for !p.done() && strings.IndexByte(" \t\n", p.peek()) != -1 {
+		if p.peek() == '\n' {
+			p.line++
+		}
+		p.pos++
+	}
+}
+
+// skipSpaces skips the spaces and the tabs in the current line.
+func (p *dotenvParser) skipSpaces() {
+	for !p.done() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+// skipLine skips the rest of the current line.
+func (p *dotenvParser) skipLine() {
+	end := strings.IndexByte(p.src[p.pos:], '\n')
+	if end == -1 {
+		p.pos = len(p.src)
+		return
+	}
+
+	p.pos += end
+}
+
+// peek returns the byte at the current position.
+func (p *dotenvParser) peek() byte {
+	return p.src[p.pos]
+}
+
+// done reports whether the whole source has been parsed.
+func (p *dotenvParser) done() bool {
+	return p.pos >= len(p.src)
+}
@@ -0,0 +1,98 @@
+package env
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDotenvComments(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want map[string]string
+	}{
+		{name: "comment after whitespace", src: "A= # comment", want: map[string]string{"A": ""}},
+		{name: "comment after the equal sign", src: "A=# comment", want: map[string]string{"A": ""}},
+		{name: "comment after tab", src: "A=\t# comment\nB=1", want: map[string]string{"A": "", "B": "1"}},
+		{name: "inline comment", src: "A=value # comment", want: map[string]string{"A": "value"}},
+		{name: "hash inside the value", src: "A=val#ue", want: map[string]string{"A": "val#ue"}},
+		{name: "comment line", src: "# comment\nA=1", want: map[string]string{"A": "1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDotenv(strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatalf("ParseDotenv() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDotenv() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDotenv(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want map[string]string
+	}{
+		{name: "empty", src: "", want: map[string]string{}},
+		{name: "blank lines", src: "\n\n  \nA=1\n\n", want: map[string]string{"A": "1"}},
+		{name: "pair", src: "A=1", want: map[string]string{"A": "1"}},
+		{name: "spaces around the equal sign", src: "A = 1 ", want: map[string]string{"A": "1"}},
+		{name: "empty value", src: "A=\nB=2", want: map[string]string{"A": "", "B": "2"}},
+		{name: "export", src: "export A=1\nexport\tB=2", want: map[string]string{"A": "1", "B": "2"}},
+		{name: "export as a key", src: "export=1", want: map[string]string{"export": "1"}},
+		{name: "later keys override", src: "A=1\nA=2", want: map[string]string{"A": "2"}},
+		{name: "crlf", src: "A=1\r\nB=2\r\n", want: map[string]string{"A": "1", "B": "2"}},
+		{name: "equal sign in the value", src: "A=b=c", want: map[string]string{"A": "b=c"}},
+		{name: "single quotes", src: `A='a\nb ${C} # d'`, want: map[string]string{"A": `a\nb ${C} # d`}},
+		{name: "backticks", src: "A=`it's \"quoted\"`", want: map[string]string{"A": `it's "quoted"`}},
+		{name: "double quotes", src: `A="a # b"`, want: map[string]string{"A": "a # b"}},
+		{name: "escapes", src: `A="a\nb\rc\td\"e\\f\$g"`, want: map[string]string{"A": "a\nb\rc\td\"e\\f$g"}},
+		{name: "escaped quote at the end", src: `A="a\""`, want: map[string]string{"A": `a"`}},
+		{name: "comment after the quotes", src: "A=\"1\" # comment\nB=2", want: map[string]string{"A": "1", "B": "2"}},
+		{name: "multiline", src: "A=\"line 1\nline 2\"\nB=2", want: map[string]string{"A": "line 1\nline 2", "B": "2"}},
+		{name: "multiline single quotes", src: "A='-----BEGIN KEY-----\nabc\n-----END KEY-----'", want: map[string]string{"A": "-----BEGIN KEY-----\nabc\n-----END KEY-----"}},
+		{name: "leading whitespaces", src: "  A=1\n\tB=2", want: map[string]string{"A": "1", "B": "2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDotenv(strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatalf("ParseDotenv() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDotenv() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDotenvErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{name: "no equal sign", src: "A", wantErr: "line 1: expected a KEY=value pair"},
+		{name: "empty key", src: "=1", wantErr: "line 1: empty key"},
+		{name: "key with spaces", src: "A B=1", wantErr: `line 1: invalid key "A B"`},
+		{name: "unterminated quotes", src: "A=1\nB=\"2", wantErr: "line 2: B: unterminated quoted value"},
+		{name: "characters after the quotes", src: `A="1" 2`, wantErr: "line 1: A: unexpected characters after the quoted value"},
+		{name: "line after a multiline value", src: "A=\"1\n2\"\nB", wantErr: "line 3: expected a KEY=value pair"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDotenv(strings.NewReader(tt.src))
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("ParseDotenv() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package env
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// readDotenvVault decrypts the block of the environment of the given DOTENV_KEY in the .env.vault file next to
+// the config file, following the dotenv-vault format. The DOTENV_KEY can contain several comma separated keys
+// (e.g. during a key rotation) which are tried in order. os.ErrNotExist is returned if there is no .env.vault
+// file. The signature of the file is verified like the signatures of the config files, but it is always parsed
+// as a dotenv file as the format is fixed by dotenv-vault, so the parser of WithParser is not used.
+func readDotenvVault(o *options, dotenvKey string) (map[string]string, error) {
+	vaultPath := filepath.Join(o.path, o.file+".vault")
+	if o.fsys != nil {
+		vaultPath = path.Join(o.path, o.file+".vault")
+	}
+
+	parse := Parser(ParseDotenv)
+	if o.verify != nil {
+		parse = verifiedParser(o, vaultPath, parse)
+	}
+
+	vault, err := readConfigFile(o.fsys, vaultPath, parse)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, key := range strings.Split(dotenvKey, ",") {
+		m, err := decryptDotenvVault(vault, strings.TrimSpace(key))
+		if err == nil {
+			o.log().Debug("loaded the encrypted config file", "path", vaultPath, "keys", len(m))
+			o.trackFile("file", vaultPath, m)
+			return m, nil
+		}
+
+		errs = append(errs, err)
+	}
+
+	return nil, fmt.Errorf("failed to decrypt %s: %w", vaultPath, errors.Join(errs...))
+}
+
+// decryptDotenvVault decrypts the block of the environment of the given key (in the
+// dotenv://:key_<hex>@dotenv.org/vault/.env.vault?environment=<environment> form) in the given vault.
+func decryptDotenvVault(vault map[string]string, dotenvKey string) (map[string]string, error) {
+	u, err := url.Parse(dotenvKey)
+	if err != nil || u.Scheme != "dotenv" {
+		return nil, errors.New("invalid DOTENV_KEY, expected dotenv://:key_<key>@dotenv.org/vault/.env.vault?environment=<environment>")
+	}
+
+	password, _ := u.User.Password()
+	key, err := hex.DecodeString(strings.TrimPrefix(password, "key_"))
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("invalid DOTENV_KEY, the key must be 64 hexadecimal characters")
+	}
+
+	environment := u.Query().Get("environment")
+	if environment == "" {
+		return nil, errors.New("invalid DOTENV_KEY, the environment is missing")
+	}
+
+	block, ok := vault["DOTENV_VAULT_"+strings.ToUpper(environment)]
+	if !ok {
+		return nil, fmt.Errorf("no DOTENV_VAULT_%s block in the vault", strings.ToUpper(environment))
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(block)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DOTENV_VAULT_%s block: %w", strings.ToUpper(environment), err)
+	}
+
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(c)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid DOTENV_VAULT_%s block: the ciphertext is too short", strings.ToUpper(environment))
+	}
+
+	plaintext, err := gcm.Open(nil, ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt the DOTENV_VAULT_%s block, the key may be wrong: %w", strings.ToUpper(environment), err)
+	}
+
+	return ParseDotenv(bytes.NewReader(plaintext))
+}
@@ -0,0 +1,172 @@
+package env
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDecryptDotenvVault(t *testing.T) {
+	key, other := strings.Repeat("ab", 32), strings.Repeat("cd", 32)
+	vault := map[string]string{
+		"DOTENV_VAULT_PRODUCTION": encryptVaultBlock(t, key, "PORT=80\nHOST=prod"),
+		"DOTENV_VAULT_STAGING":    encryptVaultBlock(t, other, "PORT=8080"),
+		"DOTENV_VAULT_INVALID":    "!",
+	}
+	dotenvKey := func(key, environment string) string {
+		return "dotenv://:key_" + key + "@dotenv.org/vault/.env.vault?environment=" + environment
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		want    map[string]string
+		wantErr string
+	}{
+		{name: "production", key: dotenvKey(key, "production"), want: map[string]string{"PORT": "80", "HOST": "prod"}},
+		{name: "staging", key: dotenvKey(other, "staging"), want: map[string]string{"PORT": "8080"}},
+		{name: "wrong key", key: dotenvKey(other, "production"), wantErr: "the key may be wrong"},
+		{name: "missing environment", key: dotenvKey(key, "development"), wantErr: "no DOTENV_VAULT_DEVELOPMENT block"},
+		{name: "invalid block", key: dotenvKey(key, "invalid"), wantErr: "invalid DOTENV_VAULT_INVALID block"},
+		{name: "no environment", key: "dotenv://:key_" + key + "@dotenv.org/vault/.env.vault", wantErr: "the environment is missing"},
+		{name: "short key", key: dotenvKey("abcd", "production"), wantErr: "the key must be 64 hexadecimal characters"},
+		{name: "invalid scheme", key: "https://dotenv.org", wantErr: "invalid DOTENV_KEY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decryptDotenvVault(vault, tt.key)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("decryptDotenvVault() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decryptDotenvVault() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decryptDotenvVault() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadDotenvVault(t *testing.T) {
+	key, other := strings.Repeat("ab", 32), strings.Repeat("cd", 32)
+	dotenvKey := func(key string) string {
+		return "dotenv://:key_" + key + "@dotenv.org/vault/.env.vault?environment=production"
+	}
+	fsys := fstest.MapFS{
+		".env":       {Data: []byte("PORT=8080")},
+		".env.vault": {Data: []byte("DOTENV_VAULT_PRODUCTION=" + encryptVaultBlock(t, key, "PORT=80"))},
+	}
+
+	tests := []struct {
+		name      string
+		fsys      fstest.MapFS
+		dotenvKey string
+		want      int
+		wantErr   string
+	}{
+		{name: "vault", fsys: fsys, dotenvKey: dotenvKey(key), want: 80},
+		{name: "rotated key", fsys: fsys, dotenvKey: dotenvKey(other) + ", " + dotenvKey(key), want: 80},
+		{name: "without key", fsys: fsys, want: 8080},
+		{name: "without vault", fsys: fstest.MapFS{".env": fsys[".env"]}, dotenvKey: dotenvKey(key), want: 8080},
+		{name: "wrong key", fsys: fsys, dotenvKey: dotenvKey(other), wantErr: "failed to decrypt .env.vault"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DOTENV_KEY", tt.dotenvKey)
+
+			var cfg struct {
+				Port int `mapstructure:"PORT"`
+			}
+			err := LoadE(&cfg, WithFS(tt.fsys), WithPrecedence(FileOnly))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadE() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadE() error = %v", err)
+			}
+			if cfg.Port != tt.want {
+				t.Errorf("Port = %d, want %d", cfg.Port, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadDotenvVaultSignature(t *testing.T) {
+	key := strings.Repeat("ab", 32)
+	t.Setenv("DOTENV_KEY", "dotenv://:key_"+key+"@dotenv.org/vault/.env.vault?environment=production")
+
+	vault := "DOTENV_VAULT_PRODUCTION=" + encryptVaultBlock(t, key, "PORT=8080") + "\n"
+	hmacKey := []byte("secret")
+	mac := hmac.New(sha256.New, hmacKey)
+	mac.Write([]byte(vault))
+	signed := vault + signatureComment + " " + hex.EncodeToString(mac.Sum(nil)) + "\n"
+
+	tests := []struct {
+		name    string
+		vault   string
+		opts    []Option
+		want    map[string]string
+		wantErr string
+	}{
+		{name: "unverified", vault: vault, want: map[string]string{"PORT": "8080"}},
+		{name: "signed", vault: signed, opts: []Option{WithHMAC(hmacKey)}, want: map[string]string{"PORT": "8080"}},
+		{name: "unsigned", vault: vault, opts: []Option{WithHMAC(hmacKey)}, wantErr: "not signed"},
+		{name: "other key", vault: signed, opts: []Option{WithHMAC([]byte("other"))}, wantErr: "invalid signature"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{".env.vault": {Data: []byte(tt.vault)}}
+			got, err := readConfigFiles(newOptions(append([]Option{WithFS(fsys)}, tt.opts...)...))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("readConfigFiles() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readConfigFiles() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readConfigFiles() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// encryptVaultBlock encrypts the given plaintext with the given hex key in the format of the .env.vault blocks.
+func encryptVaultBlock(t *testing.T, key, plaintext string) string {
+	t.Helper()
+
+	k, err := hex.DecodeString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := aes.NewCipher(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plaintext), nil))
+}
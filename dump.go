@@ -0,0 +1,216 @@
+package env
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConfigDump is a rendering of the effective config with the values of the secret fields redacted, which is
+// suitable for printing at startup. It is rendered as KEY=value lines by String and as a JSON object of the keys
+// and their values by MarshalJSON.
+type ConfigDump []DumpEntry
+
+// DumpEntry is a single key of a ConfigDump.
+type DumpEntry struct {
+	// Key is the key of the field (e.g. DB_PORT).
+	Key string
+	// FieldName is the name of the field including the names of the structs it is nested in (e.g. DB.Port).
+	FieldName string
+	// Value is the value of the field formatted as it would be read from the environment, it is redacted if the
+	// field is a secret.
+	Value string
+}
+
+// Dump renders the loaded config in the given struct with the values of the secret fields (the fields that are
+// tagged with secret:"true" and the Secret fields) redacted. The keys are derived with the given options, which
+// should be the options that the struct was loaded with (e.g. WithTagName or WithInferredKeys).
+func Dump[T any](e *T, opts ...Option) ConfigDump {
+	p := newParser(nil, newOptions(opts...))
+
+	var d ConfigDump
+	p.walk(reflect.ValueOf(e).Elem(), p.o.keyPrefix, "", func(key, fieldName string, field reflect.StructField, v reflect.Value) {
+		d = append(d, DumpEntry{Key: key, FieldName: fieldName, Value: redact(field, formatValue(field, v))})
+	})
+
+	return d
+}
+
+// String renders the dump as KEY=value lines in the order of the fields, the values are quoted if needed.
+func (d ConfigDump) String() string {
+	var b strings.Builder
+	for _, entry := range d {
+		value := entry.Value
+		if strings.ContainsAny(value, " \t\r\n\"'#\\$`") {
+			value = strconv.Quote(value)
+		}
+
+		fmt.Fprintf(&b, "%s=%s\n", entry.Key, value)
+	}
+
+	return b.String()
+}
+
+// MarshalJSON renders the dump as a JSON object of the keys and their values.
+func (d ConfigDump) MarshalJSON() ([]byte, error) {
+	m := make(map[string]string, len(d))
+	for _, entry := range d {
+		m[entry.Key] = entry.Value
+	}
+
+	return json.Marshal(m)
+}
+
+// walk calls fn with the key, the name and the value of every field of the given struct that maps to a key,
+// following the same rules as parseStruct. The entries of the prefixed maps are passed as separate keys.
+func (p *parser) walk(objValue reflect.Value, prefix, path string, fn func(key, fieldName string, field reflect.StructField, v reflect.Value)) {
+	objType := objValue.Type()
+	prefix = p.canonical(prefix)
+
+	for i := 0; i < objType.NumField(); i++ {
+		field := objType.Field(i)
+		fieldValue := objValue.Field(i)
+		fieldName := path + field.Name
+		if !field.IsExported() || p.skipped(field) {
+			continue
+		}
+
+		if isJSON, _ := boolTag(field, "envJSON"); isNested(field.Type) && !isJSON {
+			p.walk(fieldValue, prefix+p.nestedPrefix(field), fieldName+".", fn)
+			continue
+		}
+
+		if mapPrefix, ok := field.Tag.Lookup("prefix"); ok && field.Type.Kind() == reflect.Map {
+			mapPrefix = p.canonical(prefix + mapPrefix)
+			keys := fieldValue.MapKeys()
+			slices.SortFunc(keys, func(a, b reflect.Value) int {
+				return strings.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+			})
+			for _, k := range keys {
+				key := mapPrefix + fmt.Sprint(k.Interface())
+				fn(key, fmt.Sprintf("%s[%v]", fieldName, k.Interface()), field, fieldValue.MapIndex(k))
+			}
+
+			continue
+		}
+
+		key, ok := p.fieldKey(field)
+		if !ok || key == "" {
+			continue
+		}
+
+		fn(prefix+key, fieldName, field, fieldValue)
+	}
+}
+
+// formatValue formats the given value of the given field as it would be read from the environment, the Secret
+// values are redacted.
+func formatValue(field reflect.StructField, v reflect.Value) string {
+	if isJSON, _ := boolTag(field, "envJSON"); isJSON {
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return fmt.Sprint(v.Interface())
+		}
+
+		return string(b)
+	}
+
+	if reflect.PointerTo(v.Type()).Implements(secretType) {
+		if v.IsZero() {
+			return ""
+		}
+
+		return redacted
+	}
+
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+
+		v = v.Elem()
+	}
+
+	switch v.Type() {
+	case durationType:
+		return time.Duration(v.Int()).String()
+	case timeType:
+		t := v.Interface().(time.Time)
+		if t.IsZero() {
+			return ""
+		}
+
+		return t.Format(time.RFC3339Nano)
+	case urlType:
+		// the password of the URL is redacted even if the field is not a secret
+		u := v.Interface().(url.URL)
+		return u.Redacted()
+	}
+
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		if b, err := m.MarshalText(); err == nil {
+			return string(b)
+		}
+	}
+	if v.CanAddr() {
+		if m, ok := v.Addr().Interface().(encoding.TextMarshaler); ok {
+			if b, err := m.MarshalText(); err == nil {
+				return string(b)
+			}
+		}
+	}
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+	if v.CanAddr() {
+		if s, ok := v.Addr().Interface().(fmt.Stringer); ok {
+			return s.String()
+		}
+	}
+
+	separator, ok := field.Tag.Lookup("envSeparator")
+	if !ok {
+		separator = ","
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 && v.Kind() == reflect.Slice {
+			return base64.StdEncoding.EncodeToString(v.Bytes())
+		}
+
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = formatValue(field, v.Index(i))
+		}
+
+		return strings.Join(items, separator)
+	case reflect.Map:
+		keyValSeparator, ok := field.Tag.Lookup("envKeyValSeparator")
+		if !ok {
+			keyValSeparator = "="
+		}
+
+		pairs := make(map[string]string, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			pairs[formatValue(field, iter.Key())] = formatValue(field, iter.Value())
+		}
+
+		items := make([]string, 0, len(pairs))
+		for _, key := range slices.Sorted(maps.Keys(pairs)) {
+			items = append(items, key+keyValSeparator+pairs[key])
+		}
+
+		return strings.Join(items, separator)
+	}
+
+	return fmt.Sprint(v.Interface())
+}
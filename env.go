@@ -3,138 +3,406 @@
 package env
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
 	"os"
+	"path"
 	"reflect"
-	"strconv"
 	"strings"
-	"time"
-
-	"github.com/VinukaThejana/go-utils/logger"
-	"github.com/spf13/viper"
 )
 
 // Env is an interface that defines the methods for loading environment variables.
 type Env interface {
-	Load(path ...string)
+	Load(opts ...Option)
 }
 
-// Load loads environment variables from the given path and unmarshals them into the given struct.
-func Load[T any](e *T, path ...string) {
-	configPath := "."
-	configFile := ".env"
+// Load loads environment variables with the given options and unmarshals them into the given struct.
+// If an error occurs while loading the environment variables the error is logged with the logger provided
+// with WithLogger (or slog.Default if no logger is provided) and the program exits.
+func Load[T any](e *T, opts ...Option) {
+	if err := LoadE(e, opts...); err != nil {
+		logger := newOptions(opts...).logger
+		if logger == nil {
+			logger = slog.Default()
+		}
 
-	v := viper.New()
+		logger.Error("failed to load the environment variables", "error", err)
+		os.Exit(1)
+	}
+}
 
+// LoadPath loads environment variables from the config file in the given directory and with the given file
+// name (in that order) and unmarshals them into the given struct, it is the path-based signature of Load
+// before it took options.
+//
+// Deprecated: Use Load with WithPath and WithFile instead.
+func LoadPath[T any](e *T, path ...string) {
 	if len(path) > 2 {
-		logger.Errorf(fmt.Errorf("invalid set of parameters are provided"))
+		slog.Default().Error("invalid set of parameters are provided", "path", path)
 	}
 
+	Load(e, pathOptions(path)...)
+}
+
+// pathOptions returns the options of the directory and the file name that are passed to LoadPath.
+func pathOptions(path []string) []Option {
+	var opts []Option
 	if len(path) > 0 {
-		if len(path) == 2 {
-			configFile = path[1]
+		opts = append(opts, WithPath(path[0]))
+	}
+	if len(path) > 1 {
+		opts = append(opts, WithFile(path[1]))
+	}
+
+	return opts
+}
+
+// LoadE loads environment variables with the given options and unmarshals them into the given struct.
+// Unlike Load it never exits the program, instead a descriptive error is returned which joins the errors
+// of every field so that all the problems can be fixed in one pass.
+func LoadE[T any](e *T, opts ...Option) error {
+	return LoadContext(context.Background(), e, opts...)
+}
+
+// LoadContext is like LoadE but the given context bounds the loading, it is passed to the fetches of the
+// providers, the resolvers and the commands so that they honor its cancellation and deadline.
+func LoadContext[T any](ctx context.Context, e *T, opts ...Option) error {
+	o := newOptions(append([]Option{withContext(ctx)}, opts...)...)
+
+	envMap, fileMap, err := loadEnvMap(o)
+	if err != nil {
+		return err
+	}
+
+	_, err = load(o, e, envMap, fileMap)
+	return err
+}
+
+// load unmarshals the given values into the given struct and validates it, the given file map contains the
+// values of the config files. The parser is returned for the diagnostics of the loaded values.
+func load(o *options, e any, envMap, fileMap map[string]string) (*parser, error) {
+	var errs []error
+
+	p := newParser(envMap, o)
+	_ = p.parse(e)
+	p.validateConditions(reflect.ValueOf(e).Elem(), "")
+	p.validate(e)
+	p.validateHooks(reflect.ValueOf(e).Elem(), "")
+	p.redactErrors()
+	if len(p.errs) > 0 {
+		errs = append(errs, p.errs)
+	}
+
+	if o.report != nil {
+		*o.report = p.report(fileMap)
+	}
+	if o.provenance != nil {
+		*o.provenance = p.provenance
+	}
+	if o.audit != nil {
+		*o.audit = p.accesses
+	}
+
+	if o.strict {
+		if keys := p.unknown(fileMap); len(keys) > 0 {
+			errs = append(errs, fmt.Errorf("unknown keys in the config file: %s", strings.Join(keys, ", ")))
 		}
-		configPath = path[0]
+	}
+
+	return p, errors.Join(errs...)
+}
+
+// LoadFS loads the config file with the given name from the given file system (e.g. an embed.FS) and unmarshals
+// it into the given struct, the values of the config file are overridden by the environment variables.
+// It accepts the same options as LoadE and returns an error instead of exiting the program.
+func LoadFS[T any](fsys fs.FS, e *T, name string, opts ...Option) error {
+	return LoadE(e, append([]Option{
+		WithFS(fsys),
+		WithPath(path.Dir(name)),
+		WithFile(path.Base(name)),
+		WithPrecedence(EnvOverFile),
+	}, opts...)...)
+}
 
-		if strings.HasSuffix(path[0], "/") {
-			configFile = fmt.Sprintf("%s%s", configPath, configFile)
-		} else {
-			configFile = fmt.Sprintf("%s/%s", configPath, configFile)
+// LoadReader reads the config from the given reader (e.g. stdin, a network stream or a test fixture) instead
+// of the config files and unmarshals it into the given struct. The config is parsed with the parser provided
+// with WithParser, which defaults to ParseDotenv. It accepts the same options as LoadE and returns an error
+// instead of exiting the program.
+func LoadReader[T any](r io.Reader, e *T, opts ...Option) error {
+	return LoadE(e, append([]Option{withReader(r)}, opts...)...)
+}
+
+// LoadProvider loads the values of the given provider (e.g. a Chain) instead of the config files and the
+// environment variables and unmarshals them into the given struct. It accepts the same options as LoadE and
+// returns an error instead of exiting the program.
+func LoadProvider[T any](p Provider, e *T, opts ...Option) error {
+	return LoadE(e, append([]Option{withSource(p)}, opts...)...)
+}
+
+// loadEnvMap reads the config files and the environment variables and merges them according to the precedence,
+// or fetches the source instead of them if it is provided. The systemd credentials (unless only the config files
+// or the source are loaded) and the values of the providers are merged on top of them.
+// The values that are read from the config files are returned separately as well.
+func loadEnvMap(o *options) (map[string]string, map[string]string, error) {
+	var envMap, fileMap map[string]string
+
+	if o.source != nil {
+		var err error
+		envMap, err = o.retry.fetch(o.ctx, o.source)
+		if err != nil {
+			return nil, nil, err
+		}
+		envMap = o.fold(envMap)
+		o.trackSource(envMap)
+	} else if o.precedence == EnvOnly {
+		envMap = o.environ()
+		o.track("environment", "", envMap)
+	} else {
+		var err error
+		fileMap, err = readConfigFiles(o)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, nil, err
+		}
+		fileMap = o.fold(trimPrefix(fileMap, o.envPrefix))
+
+		switch o.precedence {
+		case FileOnly:
+			envMap = merge(fileMap)
+		case EnvOverFile:
+			environ := o.environ()
+			o.track("environment", "", environ)
+			envMap = merge(fileMap, environ)
+		case FileOverEnv:
+			environ := o.environ()
+			o.track("environment", "", withoutKeys(environ, fileMap))
+			envMap = merge(environ, fileMap)
+		default:
+			envMap = fileMap
+			if err != nil {
+				o.log().Debug("no config file found, loading the environment variables")
+				envMap = o.environ()
+				o.track("environment", "", envMap)
+			}
+		}
+	}
+
+	if o.source == nil && o.precedence != FileOnly {
+		credentials, err := systemdCredentials(o)
+		if err != nil {
+			return nil, nil, err
 		}
+
+		credentials = o.fold(credentials)
+		o.track("credentials", "", credentials)
+		envMap = merge(envMap, credentials)
 	}
 
-	_, err := os.Stat(configFile)
+	envMap, err := fetchProviders(o.ctx, o, envMap)
 	if err != nil {
-		if !errors.Is(err, os.ErrNotExist) {
-			lf(err)
+		return nil, nil, err
+	}
+
+	if o.profile != "" {
+		o.trackProfile(envMap, o.profile)
+		applyProfile(envMap, o.profile)
+	}
+
+	return envMap, fileMap, nil
+}
+
+// trimPrefix trims the given prefix from the keys of the given map that start with it, the values of the
+// prefixed keys override the values of their unprefixed keys.
+func trimPrefix(m map[string]string, prefix string) map[string]string {
+	if prefix == "" || m == nil {
+		return m
+	}
+
+	trimmed := make(map[string]string, len(m))
+	for key, value := range m {
+		if _, ok := strings.CutPrefix(key, prefix); !ok {
+			trimmed[key] = value
+		}
+	}
+	for key, value := range m {
+		if key, ok := strings.CutPrefix(key, prefix); ok && key != "" {
+			trimmed[key] = value
 		}
+	}
 
-		lf(parseEnvVars(environ(), e))
-	} else {
-		v.AddConfigPath(configPath)
-		v.SetConfigFile(configFile)
+	return trimmed
+}
 
-		lf(v.ReadInConfig())
-		lf(v.Unmarshal(e))
+// withoutKeys returns the keys and values of the given map whose keys are not in the other given map.
+func withoutKeys(m, other map[string]string) map[string]string {
+	without := make(map[string]string, len(m))
+	for key, value := range m {
+		if _, ok := other[key]; !ok {
+			without[key] = value
+		}
 	}
 
-	logger.Validatef(e)
+	return without
 }
 
-// environ returns a map of environment variables and their values.
-func environ() map[string]string {
+// merge merges the given maps into a new map, the values in the later maps override the values in the earlier maps.
+func merge(maps ...map[string]string) map[string]string {
 	m := make(map[string]string)
-	for _, s := range os.Environ() {
-		a := strings.Split(s, "=")
-		m[a[0]] = a[1]
+	for _, mm := range maps {
+		for key, value := range mm {
+			m[key] = value
+		}
 	}
 
 	return m
 }
 
-// parseEnvVars parses the environment variables in the given map and unmarshals them into the given struct.
-func parseEnvVars[T any](envMap map[string]string, e *T) error {
-	objValue := reflect.ValueOf(e).Elem()
-	objType := objValue.Type()
+// applyProfile overrides the keys in the given map with the values of the keys that are prefixed with the
+// upper cased name of the given profile (e.g. STAGING_PORT overrides PORT).
+func applyProfile(envMap map[string]string, profile string) {
+	prefix := strings.ToUpper(profile) + "_"
 
-	for i := 0; i < objType.NumField(); i++ {
-		field := objType.Field(i)
-		envKey := field.Tag.Get("mapstructure")
-		envValue, ok := envMap[envKey]
-		if !ok {
-			continue
+	overrides := make(map[string]string)
+	for key, value := range envMap {
+		if strings.HasPrefix(key, prefix) && len(key) > len(prefix) {
+			overrides[strings.TrimPrefix(key, prefix)] = value
 		}
+	}
+
+	for key, value := range overrides {
+		envMap[key] = value
+	}
+}
 
-		fieldValue := objValue.Field(i)
-		if !fieldValue.CanSet() {
-			return fmt.Errorf("field %s is not settable", field.Name)
+// readConfigFiles reads the config files in order, the values in the later files
+// override the values in the earlier files. os.ErrNotExist is returned if none of the files exist.
+// If a reader is provided it is read instead of the config files, and if DOTENV_KEY is set the encrypted
+// .env.vault file is read instead of them when it exists.
+func readConfigFiles(o *options) (map[string]string, error) {
+	if o.reader != nil {
+		m, err := o.parser(o.reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the config: %w", err)
 		}
+		o.trackFile("reader", "", m)
 
-		switch fieldValue.Kind() {
-		case reflect.String:
-			fieldValue.SetString(envValue)
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			val, err := strconv.ParseInt(envValue, 10, 64)
-			if err != nil {
-				return fmt.Errorf("failed to parse %s as int: %v", envKey, err)
-			}
+		return m, nil
+	}
 
-			fieldValue.SetInt(val)
-		case reflect.Float32, reflect.Float64:
-			val, err := strconv.ParseFloat(envValue, 64)
-			if err != nil {
-				return fmt.Errorf("failed to parse %s as float: %v", envKey, err)
-			}
+	if dotenvKey := os.Getenv("DOTENV_KEY"); dotenvKey != "" {
+		m, err := readDotenvVault(o, dotenvKey)
+		if err == nil {
+			return m, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
 
-			fieldValue.SetFloat(val)
-		case reflect.Bool:
-			val, err := strconv.ParseBool(envValue)
-			if err != nil {
-				return fmt.Errorf("failed to parse %s as bool: %v", envKey, err)
-			}
+		o.log().Warn("DOTENV_KEY is set but there is no .env.vault file, loading the plaintext config files")
+	}
 
-			fieldValue.SetBool(val)
-		default:
-			if fieldValue.Type() == reflect.TypeOf(time.Time{}) {
-				val, err := time.Parse(time.RFC3339, envValue)
-				if err != nil {
-					return fmt.Errorf("failed to parse %s as time.Time: %v", envKey, err)
-				}
-
-				fieldValue.Set(reflect.ValueOf(val))
-			} else {
-				return fmt.Errorf("unsupported type for field %s", field.Name)
+	var envMap map[string]string
+	for _, path := range o.configFiles() {
+		m, err := readEncryptedVariant(o, path)
+		if err == nil {
+			o.trackFile("file", path, m)
+			envMap = merge(envMap, m)
+			continue
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		m, err = File{Path: path, FS: o.fsys, Parser: o.fileParser(path)}.Fetch(o.ctx)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				o.log().Debug("skipping the config file that does not exist", "path", path)
+				continue
 			}
+
+			return nil, err
 		}
+
+		o.log().Debug("loaded the config file", "path", path, "keys", len(m))
+		o.trackFile("file", path, m)
+		envMap = merge(envMap, m)
+	}
+
+	if envMap == nil {
+		return nil, os.ErrNotExist
 	}
 
-	return nil
+	return envMap, nil
 }
 
-// lf logs the error and exits the program.
-func lf(err error) {
+// readEncryptedVariant reads the encrypted variant of the config file in the given path (e.g. .env.age for .env)
+// if decryption is enabled for its format, os.ErrNotExist is returned if there is no such variant.
+func readEncryptedVariant(o *options, path string) (map[string]string, error) {
+	for _, ext := range []string{ageExt, gpgExt} {
+		enabled := (ext == ageExt && o.age) || (ext == gpgExt && o.gpg)
+		if !enabled || strings.HasSuffix(path, ext) {
+			continue
+		}
+
+		m, err := readConfigFile(o.fsys, path+ext, o.fileParser(path+ext))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		o.log().Debug("loaded the encrypted config file", "path", path+ext, "keys", len(m))
+		return m, nil
+	}
+
+	return nil, os.ErrNotExist
+}
+
+// readConfigFile reads the config file in the given path with the given parser and returns a map of its keys and values.
+// The config file is read from the given file system, or from the disk if the file system is nil.
+func readConfigFile(fsys fs.FS, path string, parse Parser) (map[string]string, error) {
+	var f fs.File
+	var err error
+	if fsys != nil {
+		f, err = fsys.Open(path)
+	} else {
+		f, err = os.Open(path)
+	}
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("failed to open the config file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	m, err := parse(f)
 	if err != nil {
-		logger.Errorf(err)
+		return nil, fmt.Errorf("failed to read the config file %s: %w", path, err)
 	}
+
+	return m, nil
+}
+
+// environ returns the environment variables that start with the prefix provided with WithPrefix, trimmed from
+// their keys, or all the environment variables if there is no prefix.
+func (o *options) environ() map[string]string {
+	m, _ := Environment{Prefix: o.envPrefix}.Fetch(o.ctx)
+	return o.fold(m)
+}
+
+// environ returns a map of environment variables and their values.
+func environ() map[string]string {
+	m := make(map[string]string)
+	for _, s := range os.Environ() {
+		key, value, _ := strings.Cut(s, "=")
+		m[key] = value
+	}
+
+	return m
 }
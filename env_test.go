@@ -0,0 +1,317 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"testing/iotest"
+)
+
+// staticProvider is a provider of fixed keys and values.
+type staticProvider map[string]string
+
+// Fetch returns the keys and values.
+func (p staticProvider) Fetch(_ context.Context) (map[string]string, error) {
+	return p, nil
+}
+
+func TestLoadExpand(t *testing.T) {
+	t.Setenv("EXPAND_SECRET", "from-env")
+	t.Setenv("APP_EXPAND_HOST", "prefixed-host")
+
+	type config struct {
+		URL string `mapstructure:"URL" expand:"true"`
+	}
+
+	tests := []struct {
+		name string
+		src  string
+		opts []Option
+		want string
+	}{
+		{name: "loaded value", src: "HOST=db\nURL=postgres://${HOST}:${PORT:-5432}", want: "postgres://db:5432"},
+		{name: "environment fallback", src: "URL=${EXPAND_SECRET}", want: "from-env"},
+		{name: "file only", src: "URL=${EXPAND_SECRET}", opts: []Option{WithPrecedence(FileOnly)}, want: ""},
+		{name: "prefix", src: "URL=${EXPAND_HOST}", opts: []Option{WithPrefix("APP_")}, want: "prefixed-host"},
+		{name: "unprefixed with prefix", src: "URL=${EXPAND_SECRET:-hidden}", opts: []Option{WithPrefix("APP_")}, want: "hidden"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			if err := LoadReader(strings.NewReader(tt.src), &cfg, tt.opts...); err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if cfg.URL != tt.want {
+				t.Errorf("URL = %q, want %q", cfg.URL, tt.want)
+			}
+		})
+	}
+
+	t.Run("source", func(t *testing.T) {
+		var cfg config
+		if err := LoadProvider(staticProvider{"URL": "${EXPAND_SECRET:-hidden}"}, &cfg); err != nil {
+			t.Fatalf("LoadProvider() error = %v", err)
+		}
+		if cfg.URL != "hidden" {
+			t.Errorf("URL = %q, want %q", cfg.URL, "hidden")
+		}
+	})
+}
+
+func TestPathOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     []string
+		wantPath string
+		wantFile string
+	}{
+		{name: "defaults", wantPath: ".", wantFile: ".env"},
+		{name: "directory", path: []string{"/etc/app"}, wantPath: "/etc/app", wantFile: ".env"},
+		{name: "directory and file", path: []string{"/etc/app", "config.env"}, wantPath: "/etc/app", wantFile: "config.env"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newOptions(pathOptions(tt.path)...)
+			if o.path != tt.wantPath || o.file != tt.wantFile {
+				t.Errorf("path, file = %q, %q, want %q, %q", o.path, o.file, tt.wantPath, tt.wantFile)
+			}
+		})
+	}
+
+	t.Run("load", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "config.env"), []byte("PATH_PORT=8080\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		var cfg struct {
+			Port int `mapstructure:"PATH_PORT"`
+		}
+		LoadPath(&cfg, dir, "config.env")
+		if cfg.Port != 8080 {
+			t.Errorf("Port = %d, want 8080", cfg.Port)
+		}
+	})
+}
+
+func TestLoadStrict(t *testing.T) {
+	type config struct {
+		DatabaseURL string          `mapstructure:"DATABASE_URL"`
+		Password    string          `mapstructure:"PASSWORD"`
+		Features    map[string]bool `prefix:"FEATURE_"`
+	}
+
+	tests := []struct {
+		name    string
+		src     string
+		strict  bool
+		wantErr string
+	}{
+		{name: "known keys", src: "DATABASE_URL=postgres://db\nPASSWORD_FILE=/dev/null\nFEATURE_SEARCH=true", strict: true},
+		{name: "unknown keys", src: "DATABSE_URL=postgres://db\nPORT=80", strict: true, wantErr: "unknown keys in the config file: DATABSE_URL, PORT"},
+		{name: "not strict", src: "DATABSE_URL=postgres://db"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []Option{WithPrecedence(FileOnly)}
+			if tt.strict {
+				opts = append(opts, WithStrict())
+			}
+
+			var cfg config
+			err := LoadReader(strings.NewReader(tt.src), &cfg, opts...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadReader() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+		})
+	}
+
+	t.Run("environment", func(t *testing.T) {
+		t.Setenv("STRICT_UNKNOWN", "true")
+
+		var cfg config
+		if err := LoadReader(strings.NewReader("DATABASE_URL=postgres://db"), &cfg, WithPrecedence(EnvOverFile), WithStrict()); err != nil {
+			t.Errorf("LoadReader() error = %v, want the unknown environment variables to be allowed", err)
+		}
+	})
+}
+
+func TestLoadFS(t *testing.T) {
+	t.Setenv("LOADFS_DEBUG", "true")
+
+	fsys := fstest.MapFS{
+		"config/.env":       {Data: []byte("LOADFS_PORT=8080\nLOADFS_DEBUG=false")},
+		"config/app.env":    {Data: []byte("LOADFS_PORT=9090")},
+		"config/broken.env": {Data: []byte(`LOADFS_PORT="8080`)},
+	}
+
+	type config struct {
+		Port  int  `mapstructure:"LOADFS_PORT"`
+		Debug bool `mapstructure:"LOADFS_DEBUG"`
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		opts    []Option
+		want    config
+		wantErr bool
+	}{
+		{name: "environment over file", file: "config/.env", want: config{Port: 8080, Debug: true}},
+		{name: "named file", file: "config/app.env", want: config{Port: 9090, Debug: true}},
+		{name: "file only", file: "config/.env", opts: []Option{WithPrecedence(FileOnly)}, want: config{Port: 8080}},
+		{name: "missing file", file: "config/missing.env", want: config{Debug: true}},
+		{name: "invalid file", file: "config/broken.env", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := LoadFS(fsys, &cfg, tt.file, tt.opts...)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), tt.file) {
+					t.Errorf("LoadFS() error = %v, want an error for %s", err, tt.file)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadFS() error = %v", err)
+			}
+			if cfg != tt.want {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadReader(t *testing.T) {
+	type config struct {
+		Host string `mapstructure:"HOST"`
+		Port int    `mapstructure:"PORT"`
+	}
+
+	tests := []struct {
+		name    string
+		src     io.Reader
+		opts    []Option
+		want    config
+		wantErr string
+	}{
+		{name: "dotenv", src: strings.NewReader("HOST=db\nPORT=5432"), want: config{Host: "db", Port: 5432}},
+		{
+			name: "parser",
+			src:  strings.NewReader(`{"HOST":"db","PORT":5432}`),
+			opts: []Option{WithParser(ParseJSON)},
+			want: config{Host: "db", Port: 5432},
+		},
+		{name: "invalid", src: strings.NewReader(`HOST="db`), wantErr: "failed to read the config"},
+		{name: "read error", src: iotest.ErrReader(errors.New("broken pipe")), wantErr: "broken pipe"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := LoadReader(tt.src, &cfg, append([]Option{WithPrecedence(FileOnly)}, tt.opts...)...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadReader() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if cfg != tt.want {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
+
+// contextKey is the key of the value that TestLoadContext passes through the context.
+type contextKey struct{}
+
+// contextProvider is a provider that returns the value of the contextKey in its context, or the error of
+// its context.
+type contextProvider struct{}
+
+// Fetch returns the value of the contextKey.
+func (contextProvider) Fetch(ctx context.Context) (map[string]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	value, _ := ctx.Value(contextKey{}).(string)
+	return map[string]string{"PROVIDER": value}, nil
+}
+
+func TestLoadContext(t *testing.T) {
+	resolver := ResolverFunc(func(ctx context.Context, _ string) (string, error) {
+		value, _ := ctx.Value(contextKey{}).(string)
+		return value, nil
+	})
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	type config struct {
+		Provider string `mapstructure:"PROVIDER"`
+		Resolved string `mapstructure:"RESOLVED" ctx:"ref"`
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		opts    []Option
+		want    config
+		wantErr error
+	}{
+		{
+			name: "value",
+			ctx:  context.WithValue(context.Background(), contextKey{}, "from-ctx"),
+			want: config{Provider: "from-ctx", Resolved: "from-ctx"},
+		},
+		{name: "canceled", ctx: canceled, wantErr: context.Canceled},
+		{
+			name:    "canceled exec",
+			ctx:     canceled,
+			opts:    []Option{WithExec("sh", "-c", "echo PORT=8080")},
+			wantErr: context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			// the providers of the case are fetched before the contextProvider
+			opts := append(append([]Option{WithPrecedence(EnvOnly)}, tt.opts...), WithProvider(contextProvider{}), WithResolver("ctx", resolver))
+			err := LoadContext(tt.ctx, &cfg, opts...)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("LoadContext() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadContext() error = %v", err)
+			}
+			if cfg != tt.want {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package envaws
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VinukaThejana/env"
+	"github.com/VinukaThejana/env/envviper"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/appconfigdata"
+)
+
+// minPollInterval is the minimum poll interval that AppConfig accepts for a configuration session.
+const minPollInterval = 15 * time.Second
+
+// AppConfig fetches a configuration profile that is deployed with AWS AppConfig through its data plane.
+// The configuration session is kept between the fetches, so the profile is only fetched again after the
+// poll interval that AppConfig returns has elapsed and the last configuration is reused until then.
+type AppConfig struct {
+	// Application is the name or the identifier of the application.
+	Application string
+	// Environment is the name or the identifier of the environment.
+	Environment string
+	// Profile is the name or the identifier of the configuration profile.
+	Profile string
+	// PollInterval is the minimum interval between the polls, AppConfig uses its default interval if it
+	// is zero. It is raised to 15 seconds, the shortest interval that AppConfig accepts.
+	PollInterval time.Duration
+	// Parser parses the configuration, it defaults to a parser that is derived from its content type.
+	Parser env.Parser
+	// Client is the client that fetches the configuration, it defaults to a client that is created with the
+	// default credential chain.
+	Client *appconfigdata.Client
+
+	mu     sync.Mutex
+	token  *string
+	next   time.Time
+	values map[string]string
+}
+
+// Fetch returns the latest deployed configuration.
+func (a *AppConfig) Fetch(ctx context.Context) (map[string]string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, err := a.poll(ctx); err != nil {
+		return nil, err
+	}
+
+	return maps.Clone(a.values), nil
+}
+
+// Watch polls AppConfig for deployments until the context is done and calls fn with the configuration
+// whenever a new one is deployed, it is typically run in a goroutine that reloads the config in fn.
+// Errors of the polls are passed to fn with the last configuration.
+func (a *AppConfig) Watch(ctx context.Context, fn func(map[string]string, error)) error {
+	for {
+		a.mu.Lock()
+		wait := time.Until(a.next)
+		a.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+
+		a.mu.Lock()
+		changed, err := a.poll(ctx)
+		values := maps.Clone(a.values)
+		if err != nil {
+			// back off so that a failing poll is not retried in a busy loop
+			a.next = time.Now().Add(max(a.PollInterval, time.Minute))
+		}
+		a.mu.Unlock()
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if changed || err != nil {
+			fn(values, err)
+		}
+	}
+}
+
+// poll fetches the latest configuration when the poll interval has elapsed and reports whether a new
+// configuration was deployed, it must be called with the mutex held.
+func (a *AppConfig) poll(ctx context.Context) (bool, error) {
+	if a.values != nil && time.Now().Before(a.next) {
+		return false, nil
+	}
+
+	if a.Client == nil {
+		cfg, err := loadConfig(ctx)
+		if err != nil {
+			return false, err
+		}
+
+		a.Client = appconfigdata.NewFromConfig(cfg)
+	}
+
+	if a.token == nil {
+		input := &appconfigdata.StartConfigurationSessionInput{
+			ApplicationIdentifier:          aws.String(a.Application),
+			EnvironmentIdentifier:          aws.String(a.Environment),
+			ConfigurationProfileIdentifier: aws.String(a.Profile),
+		}
+		if interval := a.pollInterval(); interval > 0 {
+			input.RequiredMinimumPollIntervalInSeconds = aws.Int32(int32(interval / time.Second))
+		}
+
+		out, err := a.Client.StartConfigurationSession(ctx, input)
+		if err != nil {
+			return false, fmt.Errorf("failed to start the AppConfig session for %s: %w", a.Profile, err)
+		}
+
+		a.token = out.InitialConfigurationToken
+	}
+
+	out, err := a.Client.GetLatestConfiguration(ctx, &appconfigdata.GetLatestConfigurationInput{
+		ConfigurationToken: a.token,
+	})
+	if err != nil {
+		// the token can only be used once, so a new session is started on the next poll
+		a.token = nil
+		return false, fmt.Errorf("failed to fetch the AppConfig profile %s: %w", a.Profile, err)
+	}
+
+	a.token = out.NextPollConfigurationToken
+	a.next = time.Now().Add(time.Duration(out.NextPollIntervalInSeconds) * time.Second)
+
+	// an empty configuration means that the configuration has not changed since the last poll
+	if len(out.Configuration) == 0 && a.values != nil {
+		return false, nil
+	}
+
+	parse := a.Parser
+	if parse == nil {
+		parse = parserFor(aws.ToString(out.ContentType))
+	}
+
+	values := make(map[string]string)
+	if len(out.Configuration) > 0 {
+		values, err = parse(bytes.NewReader(out.Configuration))
+		if err != nil {
+			return false, fmt.Errorf("failed to parse the AppConfig profile %s: %w", a.Profile, err)
+		}
+	}
+
+	a.values = values
+
+	return true, nil
+}
+
+// pollInterval returns the PollInterval raised to the minimum that AppConfig accepts, or zero if it is not set.
+func (a *AppConfig) pollInterval() time.Duration {
+	if a.PollInterval <= 0 {
+		return 0
+	}
+
+	return max(a.PollInterval, minPollInterval)
+}
+
+// parserFor returns a parser for the given content type.
+func parserFor(contentType string) env.Parser {
+	switch {
+	case strings.Contains(contentType, "json"):
+		return envviper.Parser("json")
+	case strings.Contains(contentType, "yaml"):
+		return envviper.Parser("yaml")
+	default:
+		return env.ParseDotenv
+	}
+}
@@ -0,0 +1,28 @@
+package envaws
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAppConfigPollInterval(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		want     time.Duration
+	}{
+		{name: "unset", interval: 0, want: 0},
+		{name: "below the minimum", interval: time.Second, want: minPollInterval},
+		{name: "minimum", interval: minPollInterval, want: minPollInterval},
+		{name: "above the minimum", interval: time.Minute, want: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &AppConfig{PollInterval: tt.interval}
+			if got := a.pollInterval(); got != tt.want {
+				t.Errorf("pollInterval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+// Package envaws provides env.Provider implementations for the configuration and the secrets that are
+// stored in AWS, it lives in a separate package so that the AWS SDK is only linked into the binaries that
+// need it. Clients are created with the default credential chain of the AWS SDK unless they are provided.
+package envaws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// loadConfig loads the AWS config with the default credential chain.
+func loadConfig(ctx context.Context) (aws.Config, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("failed to load the AWS config: %w", err)
+	}
+
+	return cfg, nil
+}
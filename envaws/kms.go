@@ -0,0 +1,68 @@
+package envaws
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+)
+
+// KMS is an env.KMS that decrypts an encrypted data key (e.g. the CiphertextBlob of GenerateDataKey)
+// with AWS KMS, use it with env.WithKMS to decrypt the enc:v1: values.
+type KMS struct {
+	// EncryptedDataKey is the base64 encoded encrypted data key.
+	EncryptedDataKey string
+	// KeyID is the identifier of the KMS key that encrypted the data key, it is only required for
+	// asymmetric keys.
+	KeyID string
+	// Client is the client that decrypts the data key, it defaults to a client that is created with the
+	// default credential chain.
+	Client *kms.Client
+
+	mu sync.Mutex
+}
+
+// DataKey decrypts the data key.
+func (k *KMS) DataKey(ctx context.Context) ([]byte, error) {
+	c, err := k.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	blob, err := base64.StdEncoding.DecodeString(k.EncryptedDataKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encrypted data key: %w", err)
+	}
+
+	input := &kms.DecryptInput{CiphertextBlob: blob}
+	if k.KeyID != "" {
+		input.KeyId = aws.String(k.KeyID)
+	}
+
+	out, err := c.Decrypt(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt the data key: %w", err)
+	}
+
+	return out.Plaintext, nil
+}
+
+// client returns the Client, the default client is created on the first call that can load the AWS config.
+func (k *KMS) client(ctx context.Context) (*kms.Client, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.Client == nil {
+		cfg, err := loadConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		k.Client = kms.NewFromConfig(cfg)
+	}
+
+	return k.Client, nil
+}
@@ -0,0 +1,75 @@
+package envaws
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+)
+
+func TestKMSDataKey(t *testing.T) {
+	key := bytes.Repeat([]byte{0xab}, 32)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			CiphertextBlob []byte
+			KeyId          string
+		}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if string(input.CiphertextBlob) != "encrypted" {
+			w.Header().Set("X-Amzn-ErrorType", "InvalidCiphertextException")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"InvalidCiphertextException","message":"invalid ciphertext"}`))
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{"KeyId": input.KeyId, "Plaintext": key})
+	}))
+	defer srv.Close()
+
+	client := kms.New(kms.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(srv.URL),
+		Credentials:  aws.AnonymousCredentials{},
+	})
+
+	tests := []struct {
+		name             string
+		encryptedDataKey string
+		keyID            string
+		wantErr          string
+	}{
+		{name: "symmetric key", encryptedDataKey: base64.StdEncoding.EncodeToString([]byte("encrypted"))},
+		{name: "asymmetric key", encryptedDataKey: base64.StdEncoding.EncodeToString([]byte("encrypted")), keyID: "alias/app"},
+		{name: "wrong data key", encryptedDataKey: base64.StdEncoding.EncodeToString([]byte("other")), wantErr: "failed to decrypt the data key"},
+		{name: "invalid data key", encryptedDataKey: "!", wantErr: "invalid encrypted data key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := &KMS{EncryptedDataKey: tt.encryptedDataKey, KeyID: tt.keyID, Client: client}
+			got, err := k.DataKey(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("DataKey() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DataKey() error = %v", err)
+			}
+			if !bytes.Equal(got, key) {
+				t.Errorf("DataKey() = %x, want %x", got, key)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package envaws
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/VinukaThejana/env"
+	"github.com/VinukaThejana/env/envviper"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// S3 fetches a dotenv, JSON or YAML config object from an S3 bucket.
+type S3 struct {
+	// URL is the URL of the object in the s3://bucket/key form.
+	URL string
+	// VersionID pins the version of the object, the latest version is fetched if it is empty.
+	VersionID string
+	// Parser parses the object, it defaults to a parser that is derived from the extension of the key.
+	Parser env.Parser
+	// Client is the client that fetches the object, it defaults to a client that is created with the
+	// default credential chain.
+	Client *s3.Client
+
+	mu sync.Mutex
+}
+
+// Fetch fetches the config object from S3.
+func (s *S3) Fetch(ctx context.Context) (map[string]string, error) {
+	bucket, key, err := parseS3URL(s.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := s.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if s.VersionID != "" {
+		input.VersionId = aws.String(s.VersionID)
+	}
+
+	out, err := c.GetObject(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", s.URL, err)
+	}
+	defer out.Body.Close()
+
+	parse := s.Parser
+	if parse == nil {
+		parse = envviper.ParserFor(key)
+	}
+
+	m, err := parse(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", s.URL, err)
+	}
+
+	return m, nil
+}
+
+// client returns the Client, the default client is only kept once it is created so that a failure to load
+// the AWS config (e.g. missing credentials) is retried by the next fetch.
+func (s *S3) client(ctx context.Context) (*s3.Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Client == nil {
+		cfg, err := loadConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		s.Client = s3.NewFromConfig(cfg)
+	}
+
+	return s.Client, nil
+}
+
+// parseS3URL returns the bucket and the key of the given s3://bucket/key URL.
+func parseS3URL(rawURL string) (string, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid S3 URL %s: %w", rawURL, err)
+	}
+
+	key := strings.TrimPrefix(u.Path, "/")
+	if u.Scheme != "s3" || u.Host == "" || key == "" {
+		return "", "", fmt.Errorf("invalid S3 URL %s, expected s3://bucket/key", rawURL)
+	}
+
+	return u.Host, key, nil
+}
@@ -0,0 +1,106 @@
+package envaws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func TestParseS3URL(t *testing.T) {
+	tests := []struct {
+		url        string
+		wantBucket string
+		wantKey    string
+		wantErr    bool
+	}{
+		{url: "s3://config/app/.env", wantBucket: "config", wantKey: "app/.env"},
+		{url: "s3://config/config.yaml", wantBucket: "config", wantKey: "config.yaml"},
+		{url: "https://config.s3.amazonaws.com/.env", wantErr: true},
+		{url: "s3://config", wantErr: true},
+		{url: "s3://config/", wantErr: true},
+		{url: "s3:///.env", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			bucket, key, err := parseS3URL(tt.url)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseS3URL() = %q, %q, want an error", bucket, key)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseS3URL() error = %v", err)
+			}
+			if bucket != tt.wantBucket || key != tt.wantKey {
+				t.Errorf("parseS3URL() = %q, %q, want %q, %q", bucket, key, tt.wantBucket, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestS3Fetch(t *testing.T) {
+	objects := map[string]string{
+		"/config/app/.env":        "PORT=8080\nHOST=db",
+		"/config/app/config.json": `{"PORT":8080,"DB":{"HOST":"db"}}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := objects[r.URL.Path]
+		if !ok || (r.URL.Query().Has("versionId") && r.URL.Query().Get("versionId") != "v1") {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`<Error><Code>NoSuchKey</Code></Error>`))
+			return
+		}
+
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(srv.URL),
+		UsePathStyle: true,
+		Credentials:  aws.AnonymousCredentials{},
+	})
+
+	tests := []struct {
+		name      string
+		url       string
+		versionID string
+		want      map[string]string
+		wantErr   string
+	}{
+		{name: "dotenv", url: "s3://config/app/.env", want: map[string]string{"PORT": "8080", "HOST": "db"}},
+		{name: "json", url: "s3://config/app/config.json", want: map[string]string{"PORT": "8080", "DB_HOST": "db"}},
+		{name: "version", url: "s3://config/app/.env", versionID: "v1", want: map[string]string{"PORT": "8080", "HOST": "db"}},
+		{name: "missing version", url: "s3://config/app/.env", versionID: "v2", wantErr: "failed to fetch s3://config/app/.env"},
+		{name: "missing object", url: "s3://config/missing.env", wantErr: "failed to fetch s3://config/missing.env"},
+		{name: "invalid url", url: "config/.env", wantErr: "invalid S3 URL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &S3{URL: tt.url, VersionID: tt.versionID, Client: client}
+			got, err := s.Fetch(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Fetch() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fetch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package envaws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+
+	"github.com/VinukaThejana/env"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// Secret is a secret that is fetched from Secrets Manager.
+type Secret struct {
+	// ID is the name or the ARN of the secret.
+	ID string
+	// Prefix is prepended to the keys of the secret (e.g. DB_ maps the username of a JSON secret to
+	// DB_USERNAME) so that the secret can be mapped to a nested struct.
+	Prefix string
+	// Key is the key of a plain string secret, it defaults to the name of the secret.
+	Key string
+	// VersionStage selects the version of the secret by its staging label (e.g. AWSPREVIOUS), the
+	// AWSCURRENT version is fetched if both the VersionStage and the VersionID are empty.
+	VersionStage string
+	// VersionID selects the version of the secret by its identifier.
+	VersionID string
+}
+
+// SecretsManager fetches secrets from AWS Secrets Manager. Secrets that contain a JSON object are flattened
+// into one key for each of their (upper-cased) fields, other secrets are stored as a single key.
+type SecretsManager struct {
+	// Secrets are the secrets to fetch, the keys of the later secrets override the keys of the earlier ones.
+	Secrets []Secret
+	// Client is the client that fetches the secrets, it defaults to a client that is created with the
+	// default credential chain.
+	Client *secretsmanager.Client
+
+	mu sync.Mutex
+}
+
+// Fetch fetches the secrets from Secrets Manager.
+func (s *SecretsManager) Fetch(ctx context.Context) (map[string]string, error) {
+	c, err := s.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]string)
+	for _, secret := range s.Secrets {
+		input := &secretsmanager.GetSecretValueInput{
+			SecretId: aws.String(secret.ID),
+		}
+		if secret.VersionStage != "" {
+			input.VersionStage = aws.String(secret.VersionStage)
+		}
+		if secret.VersionID != "" {
+			input.VersionId = aws.String(secret.VersionID)
+		}
+
+		out, err := c.GetSecretValue(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch the secret %s: %w", secret.ID, err)
+		}
+
+		value := aws.ToString(out.SecretString)
+		if out.SecretString == nil {
+			value = string(out.SecretBinary)
+		}
+
+		maps.Copy(m, secretValues(secret, aws.ToString(out.Name), value))
+	}
+
+	return m, nil
+}
+
+// client returns the Client, the default client is created on the first fetch that can load the AWS config.
+func (s *SecretsManager) client(ctx context.Context) (*secretsmanager.Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Client == nil {
+		cfg, err := loadConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		s.Client = secretsmanager.NewFromConfig(cfg)
+	}
+
+	return s.Client, nil
+}
+
+// secretValues returns the keys and the values of the given secret. JSON objects are flattened, other
+// values are stored under the key of the secret, which defaults to the given name.
+func secretValues(secret Secret, name, value string) map[string]string {
+	if strings.HasPrefix(strings.TrimSpace(value), "{") && json.Valid([]byte(value)) {
+		fields, err := env.ParseJSON(strings.NewReader(value))
+		if err == nil {
+			m := make(map[string]string, len(fields))
+			for k, v := range fields {
+				m[secret.Prefix+strings.ToUpper(k)] = v
+			}
+
+			return m
+		}
+	}
+
+	key := secret.Key
+	if key == "" {
+		if name == "" {
+			name = secret.ID
+		}
+
+		key = strings.ToUpper(keyReplacer.Replace(name))
+	}
+
+	return map[string]string{secret.Prefix + key: value}
+}
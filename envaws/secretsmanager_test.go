@@ -0,0 +1,112 @@
+package envaws
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+func TestSecretValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret Secret
+		value  string
+		want   map[string]string
+	}{
+		{name: "json", secret: Secret{ID: "prod/db"}, value: `{"username":"admin","port":5432}`, want: map[string]string{"USERNAME": "admin", "PORT": "5432"}},
+		{name: "json with prefix", secret: Secret{ID: "prod/db", Prefix: "DB_"}, value: `{"username":"admin"}`, want: map[string]string{"DB_USERNAME": "admin"}},
+		{name: "string", secret: Secret{ID: "arn:aws:secretsmanager:us-east-1:1:secret:api-token"}, value: "s3cret", want: map[string]string{"PROD_API_TOKEN": "s3cret"}},
+		{name: "string with key", secret: Secret{ID: "prod/api-token", Key: "TOKEN", Prefix: "API_"}, value: "s3cret", want: map[string]string{"API_TOKEN": "s3cret"}},
+		{name: "invalid json", secret: Secret{ID: "prod/api-token"}, value: `{"token":`, want: map[string]string{"PROD_API_TOKEN": `{"token":`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := secretValues(tt.secret, "prod/api-token", tt.value); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("secretValues() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecretsManagerFetch(t *testing.T) {
+	secrets := map[string]string{
+		"prod/db":        `{"username":"admin","password":"s3cret"}`,
+		"prod/api-token": "token",
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			SecretId     string
+			VersionStage string
+		}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		value, ok := secrets[input.SecretId]
+		if !ok {
+			w.Header().Set("X-Amzn-ErrorType", "ResourceNotFoundException")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"not found"}`))
+			return
+		}
+		if input.VersionStage == "AWSPREVIOUS" {
+			value = "old-token"
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{"Name": input.SecretId, "SecretString": value})
+	}))
+	defer srv.Close()
+
+	client := secretsmanager.New(secretsmanager.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(srv.URL),
+		Credentials:  aws.AnonymousCredentials{},
+	})
+
+	tests := []struct {
+		name    string
+		secrets []Secret
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:    "secrets",
+			secrets: []Secret{{ID: "prod/db", Prefix: "DB_"}, {ID: "prod/api-token"}},
+			want:    map[string]string{"DB_USERNAME": "admin", "DB_PASSWORD": "s3cret", "PROD_API_TOKEN": "token"},
+		},
+		{
+			name:    "later secrets override",
+			secrets: []Secret{{ID: "prod/api-token", Key: "TOKEN"}, {ID: "prod/api-token", Key: "TOKEN", VersionStage: "AWSPREVIOUS"}},
+			want:    map[string]string{"TOKEN": "old-token"},
+		},
+		{name: "missing secret", secrets: []Secret{{ID: "prod/missing"}}, wantErr: "failed to fetch the secret prod/missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SecretsManager{Secrets: tt.secrets, Client: client}
+			got, err := s.Fetch(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Fetch() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fetch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package envaws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/VinukaThejana/env"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// keyReplacer replaces the separators of the parameter and the secret names with underscores.
+var keyReplacer = strings.NewReplacer("/", "_", "-", "_", ".", "_")
+
+// SSM fetches all the parameters under a path of the AWS Systems Manager Parameter Store, recursively and
+// with SecureString parameters decrypted. The keys are derived from the names of the parameters relative
+// to the path (e.g. /myapp/prod/db/host becomes DB_HOST with the /myapp/prod/ path), so chamber-style
+// hierarchies map to nested structs.
+type SSM struct {
+	// Path is the path of the parameters, e.g. /myapp/prod/.
+	Path string
+	// Client is the client that fetches the parameters, it defaults to a client that is created with the
+	// default credential chain.
+	Client *ssm.Client
+
+	mu sync.Mutex
+}
+
+// WithSSM merges the parameters under the given path of the Parameter Store on top of the loaded values.
+func WithSSM(path string) env.Option {
+	return env.WithProvider(&SSM{Path: path})
+}
+
+// Fetch fetches the parameters from the Parameter Store.
+func (s *SSM) Fetch(ctx context.Context) (map[string]string, error) {
+	c, err := s.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	path := "/" + strings.Trim(s.Path, "/")
+	paginator := ssm.NewGetParametersByPathPaginator(c, &ssm.GetParametersByPathInput{
+		Path:           aws.String(path),
+		Recursive:      aws.Bool(true),
+		WithDecryption: aws.Bool(true),
+	})
+
+	m := make(map[string]string)
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch the parameters under %s: %w", path, err)
+		}
+
+		for _, param := range out.Parameters {
+			name := strings.Trim(strings.TrimPrefix(aws.ToString(param.Name), path), "/")
+			m[strings.ToUpper(keyReplacer.Replace(name))] = aws.ToString(param.Value)
+		}
+	}
+
+	return m, nil
+}
+
+// client returns the Client, or creates the default client if it is not set. The failures to create it are
+// not cached so that the next fetch retries them.
+func (s *SSM) client(ctx context.Context) (*ssm.Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Client == nil {
+		cfg, err := loadConfig(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		s.Client = ssm.NewFromConfig(cfg)
+	}
+
+	return s.Client, nil
+}
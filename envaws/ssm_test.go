@@ -0,0 +1,100 @@
+package envaws
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+func TestSSMFetch(t *testing.T) {
+	type parameter struct {
+		Name  string
+		Value string
+	}
+	// the parameters are served in pages of two
+	params := []parameter{
+		{Name: "/myapp/prod/db/host", Value: "db"},
+		{Name: "/myapp/prod/db/password", Value: "s3cret"},
+		{Name: "/myapp/prod/log-level", Value: "debug"},
+		{Name: "/myapp/staging/port", Value: "9090"},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			Path           string
+			Recursive      bool
+			WithDecryption bool
+			NextToken      string
+		}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil || !input.Recursive || !input.WithDecryption {
+			http.Error(w, "invalid input", http.StatusBadRequest)
+			return
+		}
+		if input.Path == "/denied" {
+			w.Header().Set("X-Amzn-ErrorType", "AccessDeniedException")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"AccessDeniedException","message":"denied"}`))
+			return
+		}
+
+		var matched []parameter
+		for _, p := range params {
+			if strings.HasPrefix(p.Name, input.Path+"/") {
+				matched = append(matched, p)
+			}
+		}
+
+		out := map[string]any{"Parameters": matched}
+		if input.NextToken == "" && len(matched) > 2 {
+			out = map[string]any{"Parameters": matched[:2], "NextToken": "page-2"}
+		} else if input.NextToken == "page-2" {
+			out = map[string]any{"Parameters": matched[2:]}
+		}
+		json.NewEncoder(w).Encode(out)
+	}))
+	defer srv.Close()
+
+	client := ssm.New(ssm.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(srv.URL),
+		Credentials:  aws.AnonymousCredentials{},
+	})
+
+	tests := []struct {
+		name    string
+		path    string
+		want    map[string]string
+		wantErr string
+	}{
+		{name: "pages", path: "/myapp/prod/", want: map[string]string{"DB_HOST": "db", "DB_PASSWORD": "s3cret", "LOG_LEVEL": "debug"}},
+		{name: "path without slashes", path: "myapp/staging", want: map[string]string{"PORT": "9090"}},
+		{name: "empty", path: "/other", want: map[string]string{}},
+		{name: "error", path: "/denied", wantErr: "failed to fetch the parameters under /denied"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &SSM{Path: tt.path, Client: client}
+			got, err := s.Fetch(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Fetch() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fetch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package envazure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azappconfig"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+)
+
+// keyVaultRefContentType is the content type of the settings that reference Key Vault secrets.
+const keyVaultRefContentType = "application/vnd.microsoft.appconfig.keyvaultref+json"
+
+// keyReplacer replaces the separators of the setting keys with underscores.
+var keyReplacer = strings.NewReplacer(":", "_", "/", "_", ".", "_", "-", "_")
+
+// AppConfiguration fetches the key-values of an Azure App Configuration store. The keys are mapped to env
+// keys by upper casing them and replacing the separators with underscores (e.g. db:host becomes DB_HOST)
+// and the settings that reference Key Vault secrets are resolved to the values of the secrets.
+type AppConfiguration struct {
+	// Endpoint is the endpoint of the store, e.g. https://mystore.azconfig.io.
+	Endpoint string
+	// ConnectionString is the connection string of the store, it is used instead of the Endpoint and
+	// the Credential when it is set.
+	ConnectionString string
+	// KeyFilter selects the keys that are fetched (e.g. myapp:*), all the keys are fetched if it is empty.
+	KeyFilter string
+	// TrimPrefix is trimmed from the keys before they are mapped to env keys (e.g. myapp:).
+	TrimPrefix string
+	// Labels are the labels of the key-values that are fetched, the values of the later labels override
+	// the values of the earlier ones (e.g. "\x00", "prod" overrides the unlabeled values with the prod
+	// values). Only the key-values without a label are fetched if it is empty.
+	Labels []string
+	// Credential authenticates the requests to the store and to the Key Vaults of the references, it
+	// defaults to the DefaultAzureCredential.
+	Credential azcore.TokenCredential
+	// Client is the client that fetches the key-values, it defaults to a client that is created from the
+	// ConnectionString or the Endpoint.
+	Client *azappconfig.Client
+
+	// mu guards the Client and the vaults, which are created lazily
+	mu     sync.Mutex
+	vaults map[string]*azsecrets.Client
+}
+
+// Fetch fetches the key-values from the store.
+func (a *AppConfiguration) Fetch(ctx context.Context) (map[string]string, error) {
+	c, err := a.client()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the App Configuration client: %w", err)
+	}
+
+	labels := a.Labels
+	if len(labels) == 0 {
+		labels = []string{"\x00"}
+	}
+
+	m := make(map[string]string)
+	for _, label := range labels {
+		selector := azappconfig.SettingSelector{
+			LabelFilter: &label,
+			Fields:      []azappconfig.SettingFields{azappconfig.SettingFieldsKey, azappconfig.SettingFieldsValue, azappconfig.SettingFieldsContentType},
+		}
+		if a.KeyFilter != "" {
+			selector.KeyFilter = &a.KeyFilter
+		}
+
+		pager := c.NewListSettingsPager(selector, nil)
+		for pager.More() {
+			page, err := pager.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list the key-values: %w", err)
+			}
+
+			for _, setting := range page.Settings {
+				key := derefString(setting.Key)
+				// feature flags and the other reserved keys are not config values
+				if key == "" || strings.HasPrefix(key, ".appconfig.") {
+					continue
+				}
+
+				value := derefString(setting.Value)
+				if strings.HasPrefix(derefString(setting.ContentType), keyVaultRefContentType) {
+					value, err = a.resolveRef(ctx, value)
+					if err != nil {
+						return nil, fmt.Errorf("failed to resolve the Key Vault reference of %s: %w", key, err)
+					}
+				}
+
+				m[strings.ToUpper(keyReplacer.Replace(strings.TrimPrefix(key, a.TrimPrefix)))] = value
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// client returns the Client, or creates it from the ConnectionString or the Endpoint. A client that can not be
+// created is not cached so that the next fetch tries again.
+func (a *AppConfiguration) client() (*azappconfig.Client, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.Client != nil {
+		return a.Client, nil
+	}
+
+	if a.ConnectionString != "" {
+		c, err := azappconfig.NewClientFromConnectionString(a.ConnectionString, nil)
+		if err != nil {
+			return nil, err
+		}
+		a.Client = c
+
+		return c, nil
+	}
+
+	cred, err := credential(a.Credential)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := azappconfig.NewClient(a.Endpoint, cred, nil)
+	if err != nil {
+		return nil, err
+	}
+	a.Client = c
+
+	return c, nil
+}
+
+// resolveRef returns the value of the Key Vault secret that the given reference points to.
+func (a *AppConfiguration) resolveRef(ctx context.Context, ref string) (string, error) {
+	var v struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal([]byte(ref), &v); err != nil {
+		return "", fmt.Errorf("invalid reference: %w", err)
+	}
+
+	u, err := url.Parse(v.URI)
+	if err != nil {
+		return "", fmt.Errorf("invalid secret URI %s: %w", v.URI, err)
+	}
+
+	// the path of the secret URI is /secrets/name or /secrets/name/version
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] != "secrets" {
+		return "", fmt.Errorf("invalid secret URI %s", v.URI)
+	}
+
+	name, version := parts[1], ""
+	if len(parts) > 2 {
+		version = parts[2]
+	}
+
+	c, err := a.vault(u.Scheme + "://" + u.Host)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := c.GetSecret(ctx, name, version, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return derefString(res.Value), nil
+}
+
+// vault returns the client of the Key Vault with the given URL, the clients are cached as the references of a
+// store usually point to the same vaults. It is safe to call from the concurrent fetches of the reloads.
+func (a *AppConfiguration) vault(vaultURL string) (*azsecrets.Client, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if c, ok := a.vaults[vaultURL]; ok {
+		return c, nil
+	}
+
+	cred, err := credential(a.Credential)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := azsecrets.NewClient(vaultURL, cred, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.vaults == nil {
+		a.vaults = make(map[string]*azsecrets.Client)
+	}
+	a.vaults[vaultURL] = c
+
+	return c, nil
+}
+
+// derefString returns the string that the given pointer points to, or an empty string if it is nil.
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
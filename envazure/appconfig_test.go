@@ -0,0 +1,128 @@
+package envazure
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+)
+
+func TestAppConfigurationFetch(t *testing.T) {
+	vault := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.Header().Set("WWW-Authenticate", `Bearer authorization="https://login.microsoftonline.com/tenant", resource="https://vault.azure.net"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if strings.TrimSuffix(r.URL.Path, "/") != "/secrets/db-password/2" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":"SecretNotFound","message":"not found"}}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id": "https://vault/secrets/db-password/2", "value": "s3cret"})
+	}))
+	defer vault.Close()
+
+	vaultClient, err := azsecrets.NewClient(vault.URL, staticCredential{}, &azsecrets.ClientOptions{
+		ClientOptions:                        azcore.ClientOptions{Transport: vault.Client()},
+		DisableChallengeResourceVerification: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type setting struct {
+		Key         string `json:"key"`
+		Label       string `json:"label,omitempty"`
+		Value       string `json:"value"`
+		ContentType string `json:"content_type,omitempty"`
+	}
+	ref := func(uri string) string {
+		return `{"uri":"` + uri + `"}`
+	}
+	settings := []setting{
+		{Key: "myapp:db:host", Value: "db"},
+		{Key: "myapp:db:host", Label: "prod", Value: "prod-db"},
+		{Key: "myapp:log-level", Value: "debug"},
+		{Key: "myapp:db:password", Value: ref(vault.URL + "/secrets/db-password/2"), ContentType: keyVaultRefContentType + ";charset=utf-8"},
+		{Key: "other:port", Label: "broken", Value: ref(vault.URL + "/secrets/missing"), ContentType: keyVaultRefContentType},
+		{Key: ".appconfig.featureflag/beta", Value: `{"enabled":true}`},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		label := r.URL.Query().Get("label")
+		filter := strings.TrimSuffix(r.URL.Query().Get("key"), "*")
+
+		var items []setting
+		for _, s := range settings {
+			if (s.Label == label || (label == "\x00" && s.Label == "")) && strings.HasPrefix(s.Key, filter) {
+				items = append(items, s)
+			}
+		}
+		w.Header().Set("Sync-Token", "id=1;sn=1")
+		json.NewEncoder(w).Encode(map[string]any{"items": items})
+	}))
+	defer srv.Close()
+
+	secret := base64.StdEncoding.EncodeToString([]byte("secret"))
+	connectionString := "Endpoint=" + srv.URL + ";Id=id;Secret=" + secret
+
+	tests := []struct {
+		name    string
+		store   func() *AppConfiguration
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "unlabeled",
+			store: func() *AppConfiguration {
+				return &AppConfiguration{KeyFilter: "myapp:*", TrimPrefix: "myapp:"}
+			},
+			want: map[string]string{"DB_HOST": "db", "LOG_LEVEL": "debug", "DB_PASSWORD": "s3cret"},
+		},
+		{
+			name: "labels",
+			store: func() *AppConfiguration {
+				return &AppConfiguration{KeyFilter: "myapp:*", TrimPrefix: "myapp:", Labels: []string{"\x00", "prod"}}
+			},
+			want: map[string]string{"DB_HOST": "prod-db", "LOG_LEVEL": "debug", "DB_PASSWORD": "s3cret"},
+		},
+		{
+			name: "missing secret",
+			store: func() *AppConfiguration {
+				return &AppConfiguration{Labels: []string{"broken"}}
+			},
+			wantErr: "failed to resolve the Key Vault reference of other:port",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := tt.store()
+			a.ConnectionString = connectionString
+			a.vaults = map[string]*azsecrets.Client{vault.URL: vaultClient}
+
+			got, err := a.Fetch(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Fetch() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fetch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
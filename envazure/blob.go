@@ -0,0 +1,130 @@
+package envazure
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/VinukaThejana/env"
+	"github.com/VinukaThejana/env/envviper"
+)
+
+// Blob fetches a dotenv, JSON or YAML config blob from an Azure Storage container. The blob is either
+// addressed by its URL and fetched with a token credential, or by its container and name and fetched
+// with a connection string.
+type Blob struct {
+	// URL is the URL of the blob in the https://account.blob.core.windows.net/container/blob form.
+	URL string
+	// ConnectionString is the connection string of the storage account, the blob is addressed by the
+	// Container and the Name when it is set.
+	ConnectionString string
+	// Container is the container of the blob when the ConnectionString is set.
+	Container string
+	// Name is the name of the blob when the ConnectionString is set.
+	Name string
+	// VersionID pins the version of the blob, the current version is fetched if it is empty.
+	VersionID string
+	// Parser parses the blob, it defaults to a parser that is derived from the extension of the blob.
+	Parser env.Parser
+	// Credential authenticates the requests when the URL is set, it defaults to the DefaultAzureCredential.
+	Credential azcore.TokenCredential
+
+	mu     sync.Mutex
+	client *blob.Client
+	name   string
+}
+
+// Fetch fetches the config blob from Azure Storage.
+func (b *Blob) Fetch(ctx context.Context) (map[string]string, error) {
+	c, name, err := b.blobClient()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.DownloadStream(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", name, err)
+	}
+	defer res.Body.Close()
+
+	parse := b.Parser
+	if parse == nil {
+		parse = envviper.ParserFor(name)
+	}
+
+	m, err := parse(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+
+	return m, nil
+}
+
+// blobClient returns the client of the blob with the name of the blob, the client is created on the first call
+// that succeeds so that the invalid credentials are retried by the next fetch.
+func (b *Blob) blobClient() (*blob.Client, string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.client == nil {
+		c, name, err := b.newClient()
+		if err != nil {
+			return nil, "", err
+		}
+
+		b.client, b.name = c, name
+	}
+
+	return b.client, b.name, nil
+}
+
+// newClient creates the client of the blob and returns it with the name of the blob.
+func (b *Blob) newClient() (*blob.Client, string, error) {
+	if b.ConnectionString != "" {
+		if b.Container == "" || b.Name == "" {
+			return nil, "", fmt.Errorf("the container and the name of the blob are required with a connection string")
+		}
+
+		c, err := blob.NewClientFromConnectionString(b.ConnectionString, b.Container, b.Name, nil)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid connection string: %w", err)
+		}
+
+		return b.withVersion(c, b.Name)
+	}
+
+	u, err := url.Parse(b.URL)
+	if err != nil || u.Scheme != "https" || u.Host == "" || path.Dir(u.Path) == "/" {
+		return nil, "", fmt.Errorf("invalid blob URL %s, expected https://account.blob.core.windows.net/container/blob", b.URL)
+	}
+
+	cred, err := credential(b.Credential)
+	if err != nil {
+		return nil, "", err
+	}
+
+	c, err := blob.NewClient(b.URL, cred, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid blob URL %s: %w", b.URL, err)
+	}
+
+	return b.withVersion(c, path.Base(u.Path))
+}
+
+// withVersion pins the client to the VersionID when it is set.
+func (b *Blob) withVersion(c *blob.Client, name string) (*blob.Client, string, error) {
+	if b.VersionID == "" {
+		return c, name, nil
+	}
+
+	c, err := c.WithVersionID(b.VersionID)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid version %s: %w", b.VersionID, err)
+	}
+
+	return c, name, nil
+}
@@ -0,0 +1,125 @@
+package envazure
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBlobNewClient(t *testing.T) {
+	tests := []struct {
+		name     string
+		blob     func() *Blob
+		wantName string
+		wantErr  string
+	}{
+		{
+			name: "connection string",
+			blob: func() *Blob {
+				return &Blob{ConnectionString: connectionString("http://127.0.0.1"), Container: "config", Name: "app/.env"}
+			},
+			wantName: "app/.env",
+		},
+		{
+			name: "connection string without name",
+			blob: func() *Blob {
+				return &Blob{ConnectionString: connectionString("http://127.0.0.1"), Container: "config"}
+			},
+			wantErr: "the container and the name of the blob are required",
+		},
+		{
+			name:    "invalid connection string",
+			blob:    func() *Blob { return &Blob{ConnectionString: "AccountName=x", Container: "config", Name: ".env"} },
+			wantErr: "invalid connection string",
+		},
+		{
+			name:    "http url",
+			blob:    func() *Blob { return &Blob{URL: "http://account.blob.core.windows.net/config/.env"} },
+			wantErr: "invalid blob URL",
+		},
+		{
+			name:    "url without container",
+			blob:    func() *Blob { return &Blob{URL: "https://account.blob.core.windows.net/.env"} },
+			wantErr: "invalid blob URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, name, err := tt.blob().newClient()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("newClient() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newClient() error = %v", err)
+			}
+			if name != tt.wantName {
+				t.Errorf("newClient() name = %q, want %q", name, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestBlobFetch(t *testing.T) {
+	blobs := map[string]string{
+		"/account/config/.env":        "PORT=8080",
+		"/account/config/config.json": `{"DB":{"HOST":"db"}}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := blobs[r.URL.Path]
+		if !ok || (r.URL.Query().Has("versionid") && r.URL.Query().Get("versionid") != "v1") {
+			w.Header().Set("x-ms-error-code", "BlobNotFound")
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name      string
+		blob      string
+		versionID string
+		want      map[string]string
+		wantErr   string
+	}{
+		{name: "dotenv", blob: ".env", want: map[string]string{"PORT": "8080"}},
+		{name: "json", blob: "config.json", want: map[string]string{"DB_HOST": "db"}},
+		{name: "version", blob: ".env", versionID: "v1", want: map[string]string{"PORT": "8080"}},
+		{name: "missing version", blob: ".env", versionID: "v2", wantErr: "failed to fetch .env"},
+		{name: "missing blob", blob: "missing.env", wantErr: "failed to fetch missing.env"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Blob{ConnectionString: connectionString(srv.URL + "/account"), Container: "config", Name: tt.blob, VersionID: tt.versionID}
+			got, err := b.Fetch(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Fetch() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fetch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// connectionString returns the connection string of a storage account whose blob endpoint is the given URL.
+func connectionString(endpoint string) string {
+	key := base64.StdEncoding.EncodeToString([]byte("account-key"))
+	return "DefaultEndpointsProtocol=http;AccountName=account;AccountKey=" + key + ";BlobEndpoint=" + endpoint + ";"
+}
@@ -0,0 +1,26 @@
+// Package envazure provides env.Provider implementations for the configuration and the secrets that are
+// stored in Azure, it lives in a separate package so that the Azure SDK is only linked into the binaries
+// that need it. Requests are authenticated with the DefaultAzureCredential (including managed identities)
+// unless a credential is provided.
+package envazure
+
+import (
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+// credential returns the given credential if it is not nil, otherwise the DefaultAzureCredential.
+func credential(cred azcore.TokenCredential) (azcore.TokenCredential, error) {
+	if cred != nil {
+		return cred, nil
+	}
+
+	c, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the default credentials: %w", err)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,110 @@
+package envazure
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+)
+
+// KeyVault fetches secrets from an Azure Key Vault. Secret names can only contain alphanumerics and dashes,
+// so the names are mapped to keys by upper casing them and replacing the dashes with underscores
+// (e.g. db-password becomes DB_PASSWORD) unless the Secrets map them explicitly.
+type KeyVault struct {
+	// URL is the URL of the vault, e.g. https://myvault.vault.azure.net.
+	URL string
+	// Secrets maps the names of the secrets to fetch to their keys, all the enabled secrets of the vault are
+	// fetched if it is empty. Empty keys are derived from the names of the secrets.
+	Secrets map[string]string
+	// Credential authenticates the requests, it defaults to the DefaultAzureCredential.
+	Credential azcore.TokenCredential
+	// Client is the client that fetches the secrets, it defaults to a client for the URL that is
+	// authenticated with the Credential.
+	Client *azsecrets.Client
+
+	mu sync.Mutex
+}
+
+// Fetch fetches the secrets from the vault.
+func (k *KeyVault) Fetch(ctx context.Context) (map[string]string, error) {
+	c, err := k.client()
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := k.Secrets
+	if len(secrets) == 0 {
+		if secrets, err = k.list(ctx, c); err != nil {
+			return nil, err
+		}
+	}
+
+	m := make(map[string]string, len(secrets))
+	for name, key := range secrets {
+		res, err := c.GetSecret(ctx, name, "", nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch the secret %s: %w", name, err)
+		}
+
+		if key == "" {
+			key = secretKey(name)
+		}
+		if res.Value != nil {
+			m[key] = *res.Value
+		}
+	}
+
+	return m, nil
+}
+
+// client returns the Client, or the client for the URL that is created on the first call that succeeds.
+func (k *KeyVault) client() (*azsecrets.Client, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.Client == nil {
+		cred, err := credential(k.Credential)
+		if err != nil {
+			return nil, err
+		}
+
+		c, err := azsecrets.NewClient(k.URL, cred, nil)
+		if err != nil {
+			return nil, err
+		}
+		k.Client = c
+	}
+
+	return k.Client, nil
+}
+
+// list returns the names of the enabled secrets of the vault with the given client.
+func (k *KeyVault) list(ctx context.Context, c *azsecrets.Client) (map[string]string, error) {
+	secrets := make(map[string]string)
+
+	pager := c.NewListSecretPropertiesPager(nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the secrets of %s: %w", k.URL, err)
+		}
+
+		for _, secret := range page.Value {
+			if secret.ID == nil || (secret.Attributes != nil && secret.Attributes.Enabled != nil && !*secret.Attributes.Enabled) {
+				continue
+			}
+
+			secrets[secret.ID.Name()] = ""
+		}
+	}
+
+	return secrets, nil
+}
+
+// secretKey returns the key of the secret with the given name.
+func secretKey(name string) string {
+	return strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
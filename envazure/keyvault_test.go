@@ -0,0 +1,124 @@
+package envazure
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+)
+
+// staticCredential is a credential with a fixed token.
+type staticCredential struct{}
+
+// GetToken returns the fixed token.
+func (staticCredential) GetToken(context.Context, policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func TestSecretKey(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "db-password", want: "DB_PASSWORD"},
+		{name: "apiKey", want: "APIKEY"},
+		{name: "tls-cert-2", want: "TLS_CERT_2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := secretKey(tt.name); got != tt.want {
+				t.Errorf("secretKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyVaultFetch(t *testing.T) {
+	secrets := map[string]struct {
+		value   string
+		enabled bool
+	}{
+		"db-password": {value: "s3cret", enabled: true},
+		"api-key":     {value: "key", enabled: true},
+		"old-key":     {value: "old", enabled: false},
+	}
+
+	var srv *httptest.Server
+	srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the first request of the client is answered with the challenge that the token is requested for
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.Header().Set("WWW-Authenticate", `Bearer authorization="https://login.microsoftonline.com/tenant", resource="https://vault.azure.net"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.URL.Path == "/secrets" {
+			var items []map[string]any
+			for name, secret := range secrets {
+				items = append(items, map[string]any{
+					"id":         srv.URL + "/secrets/" + name,
+					"attributes": map[string]bool{"enabled": secret.enabled},
+				})
+			}
+			json.NewEncoder(w).Encode(map[string]any{"value": items})
+			return
+		}
+
+		name := strings.TrimPrefix(strings.TrimSuffix(r.URL.Path, "/"), "/secrets/")
+		secret, ok := secrets[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":"SecretNotFound","message":"not found"}}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id": srv.URL + "/secrets/" + name + "/1", "value": secret.value})
+	}))
+	defer srv.Close()
+
+	client, err := azsecrets.NewClient(srv.URL, staticCredential{}, &azsecrets.ClientOptions{
+		ClientOptions:                        azcore.ClientOptions{Transport: srv.Client()},
+		DisableChallengeResourceVerification: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		secrets map[string]string
+		want    map[string]string
+		wantErr string
+	}{
+		{name: "enabled secrets", want: map[string]string{"DB_PASSWORD": "s3cret", "API_KEY": "key"}},
+		{name: "mapped secrets", secrets: map[string]string{"db-password": "DATABASE_PASSWORD", "old-key": ""}, want: map[string]string{"DATABASE_PASSWORD": "s3cret", "OLD_KEY": "old"}},
+		{name: "missing secret", secrets: map[string]string{"missing": ""}, wantErr: "failed to fetch the secret missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := &KeyVault{URL: srv.URL, Secrets: tt.secrets, Client: client}
+			got, err := k.Fetch(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Fetch() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fetch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package env
+
+import "strings"
+
+// Process loads the environment variables into the given struct following the conventions of
+// kelseyhightower/envconfig, for drop-in migration from that package. The keys are derived from the names of
+// the fields (e.g. Port becomes MYAPP_PORT with the myapp prefix) unless they are tagged with envconfig, the
+// names of the fields that are tagged with split_words:"true" are split into words (e.g. MaxIdleConns becomes
+// MYAPP_MAX_IDLE_CONNS), nested structs add their names to the prefix and the fields tagged with
+// ignored:"true" are skipped. Only the environment variables are loaded unless other options are given.
+func Process[T any](prefix string, e *T, opts ...Option) error {
+	if prefix != "" {
+		prefix = strings.ToUpper(prefix) + "_"
+	}
+
+	return LoadE(e, append([]Option{
+		WithPrecedence(EnvOnly),
+		WithTagName("envconfig"),
+		withPrefix(prefix),
+		withKeyInference(inferEnvconfig),
+	}, opts...)...)
+}
+
+// withPrefix prepends the given prefix to the keys of all the fields.
+func withPrefix(prefix string) Option {
+	return func(o *options) {
+		o.keyPrefix = prefix
+	}
+}
@@ -0,0 +1,64 @@
+package env
+
+import "testing"
+
+func TestProcess(t *testing.T) {
+	type database struct {
+		Host string
+	}
+	type config struct {
+		Port         int
+		MaxIdleConns int    `split_words:"true"`
+		Name         string `envconfig:"service_name"`
+		Debug        bool   `default:"true"`
+		Ignored      string `ignored:"true"`
+		Database     database
+	}
+
+	tests := []struct {
+		name   string
+		prefix string
+		env    map[string]string
+		want   config
+	}{
+		{
+			name:   "prefix",
+			prefix: "myapp",
+			env: map[string]string{
+				"MYAPP_PORT":           "8080",
+				"MYAPP_MAX_IDLE_CONNS": "4",
+				"MYAPP_SERVICE_NAME":   "api",
+				"MYAPP_IGNORED":        "x",
+				"MYAPP_DATABASE_HOST":  "db",
+			},
+			want: config{Port: 8080, MaxIdleConns: 4, Name: "api", Debug: true, Database: database{Host: "db"}},
+		},
+		{
+			name: "no prefix",
+			env:  map[string]string{"PORT": "80", "MAXIDLECONNS": "2", "DEBUG": "false"},
+			want: config{Port: 80},
+		},
+		{
+			name:   "unsplit words",
+			prefix: "myapp",
+			env:    map[string]string{"MYAPP_MAXIDLECONNS": "4"},
+			want:   config{Debug: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			var cfg config
+			if err := Process(tt.prefix, &cfg); err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+			if cfg != tt.want {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
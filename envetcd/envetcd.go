@@ -0,0 +1,175 @@
+// Package envetcd provides an env.Provider for the configuration that is stored in etcd v3, it lives in a
+// separate package so that the etcd client is only linked into the binaries that need it.
+package envetcd
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// defaultDialTimeout is the timeout of the connection to etcd.
+const defaultDialTimeout = 5 * time.Second
+
+// restartDelay is the delay before a watch that ended is restarted, so that a cluster without a leader is not
+// flooded with requests.
+const restartDelay = time.Second
+
+// keyReplacer replaces the separators of the etcd keys with underscores.
+var keyReplacer = strings.NewReplacer("/", "_", "-", "_", ".", "_")
+
+// Etcd fetches the keys under a prefix of etcd. The keys are derived from the etcd keys relative to the
+// prefix (e.g. /myapp/prod/db/host becomes DB_HOST with the /myapp/prod/ prefix), and Watch notifies about
+// the changes that are pushed to them.
+type Etcd struct {
+	// Endpoints are the endpoints of the etcd cluster.
+	Endpoints []string
+	// Prefix is the prefix of the keys, e.g. /myapp/prod/.
+	Prefix string
+	// Username is the username of the authentication, it is not used if it is empty.
+	Username string
+	// Password is the password of the authentication.
+	Password string
+	// TLS is the TLS configuration of the connection.
+	TLS *tls.Config
+	// Client is the client that fetches the keys, it defaults to a client for the Endpoints.
+	Client *clientv3.Client
+
+	mu       sync.Mutex
+	values   map[string]string
+	revision int64
+}
+
+// Fetch fetches the keys under the prefix.
+func (e *Etcd) Fetch(ctx context.Context) (map[string]string, error) {
+	if err := e.connect(); err != nil {
+		return nil, err
+	}
+
+	res, err := e.Client.Get(ctx, e.Prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the keys under %s: %w", e.Prefix, err)
+	}
+
+	m := make(map[string]string, len(res.Kvs))
+	for _, kv := range res.Kvs {
+		m[e.key(string(kv.Key))] = string(kv.Value)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.values = m
+	e.revision = res.Header.Revision
+
+	return maps.Clone(m), nil
+}
+
+// Watch watches the keys under the prefix until the context is done and calls fn with all the keys
+// whenever they change, it is typically run in a goroutine that reloads the config in fn. The keys are
+// fetched first if they have not been fetched yet. When the watch ends (e.g. after a compaction or the loss
+// of the leader) the keys are fetched again and the watch is restarted from the latest revision, an error
+// is returned if the keys can not be fetched or the watch is closed by the client.
+func (e *Etcd) Watch(ctx context.Context, fn func(map[string]string, error)) error {
+	e.mu.Lock()
+	fetched := e.values != nil
+	e.mu.Unlock()
+
+	if !fetched {
+		if _, err := e.Fetch(ctx); err != nil {
+			return err
+		}
+	}
+
+	for {
+		err := e.watch(ctx, fn)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == nil {
+			return fmt.Errorf("the watch of the keys under %s was closed", e.Prefix)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(restartDelay):
+		}
+
+		// the changes since the end of the watch are picked up by fetching the keys again
+		values, err := e.Fetch(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to restart the watch of the keys under %s: %w", e.Prefix, err)
+		}
+
+		fn(values, nil)
+	}
+}
+
+// watch watches the keys under the prefix from the revision after the last fetch or change until the watch
+// ends, the error that ended it is returned (e.g. rpctypes.ErrCompacted). Nil is returned if the watch was
+// closed without an error (e.g. the context is done).
+func (e *Etcd) watch(ctx context.Context, fn func(map[string]string, error)) error {
+	e.mu.Lock()
+	revision := e.revision
+	e.mu.Unlock()
+
+	for res := range e.Client.Watch(clientv3.WithRequireLeader(ctx), e.Prefix, clientv3.WithPrefix(), clientv3.WithRev(revision+1)) {
+		// the responses with an error are the last responses of the watch
+		if err := res.Err(); err != nil {
+			return err
+		}
+
+		e.mu.Lock()
+		for _, event := range res.Events {
+			key := e.key(string(event.Kv.Key))
+			if event.Type == clientv3.EventTypeDelete {
+				delete(e.values, key)
+			} else {
+				e.values[key] = string(event.Kv.Value)
+			}
+		}
+		e.revision = res.Header.Revision
+		values := maps.Clone(e.values)
+		e.mu.Unlock()
+
+		fn(values, nil)
+	}
+
+	return nil
+}
+
+// connect creates the client if it is not provided, a failed connection is retried by the next fetch.
+func (e *Etcd) connect() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.Client != nil {
+		return nil
+	}
+
+	c, err := clientv3.New(clientv3.Config{
+		Endpoints:   e.Endpoints,
+		Username:    e.Username,
+		Password:    e.Password,
+		TLS:         e.TLS,
+		DialTimeout: defaultDialTimeout,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to etcd: %w", err)
+	}
+	e.Client = c
+
+	return nil
+}
+
+// key returns the env key of the given etcd key.
+func (e *Etcd) key(key string) string {
+	return strings.ToUpper(keyReplacer.Replace(strings.Trim(strings.TrimPrefix(key, e.Prefix), "/")))
+}
@@ -0,0 +1,206 @@
+package envetcd
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// fakeKV serves the Get of the keys under a prefix from a map, the keys are replaced by the restarted keys
+// at the next revision after the first Get if they are set.
+type fakeKV struct {
+	clientv3.KV
+
+	kvs       map[string]string
+	revision  int64
+	restarted map[string]string
+}
+
+func (f *fakeKV) Get(_ context.Context, key string, _ ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	defer func() {
+		if f.restarted != nil {
+			f.kvs, f.restarted = f.restarted, nil
+			f.revision = 10
+		}
+	}()
+
+	res := &clientv3.GetResponse{Header: &pb.ResponseHeader{Revision: f.revision}}
+	for k, v := range f.kvs {
+		if strings.HasPrefix(k, key) {
+			res.Kvs = append(res.Kvs, &mvccpb.KeyValue{Key: []byte(k), Value: []byte(v)})
+		}
+	}
+
+	return res, nil
+}
+
+// fakeWatcher sends the responses of a watch for every call to Watch and records the revisions that the
+// watches start from, the channel of a watch is closed after its responses.
+type fakeWatcher struct {
+	clientv3.Watcher
+
+	watches   [][]clientv3.WatchResponse
+	revisions []int64
+}
+
+func (f *fakeWatcher) Watch(_ context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	f.revisions = append(f.revisions, clientv3.OpGet(key, opts...).Rev())
+
+	ch := make(chan clientv3.WatchResponse, 10)
+	if len(f.watches) > 0 {
+		for _, res := range f.watches[0] {
+			ch <- res
+		}
+		f.watches = f.watches[1:]
+	}
+	close(ch)
+
+	return ch
+}
+
+func event(typ mvccpb.Event_EventType, key, value string) *clientv3.Event {
+	return &clientv3.Event{Type: typ, Kv: &mvccpb.KeyValue{Key: []byte(key), Value: []byte(value)}}
+}
+
+func TestEtcdFetch(t *testing.T) {
+	kvs := map[string]string{
+		"/myapp/prod/db/host":    "localhost",
+		"/myapp/prod/log-level":  "debug",
+		"/myapp/prod/cache.ttl":  "5m",
+		"/myapp/staging/db/host": "staging",
+		"/otherapp/prod/db/host": "other",
+	}
+
+	tests := []struct {
+		name   string
+		prefix string
+		want   map[string]string
+	}{
+		{
+			name:   "prefix",
+			prefix: "/myapp/prod/",
+			want:   map[string]string{"DB_HOST": "localhost", "LOG_LEVEL": "debug", "CACHE_TTL": "5m"},
+		},
+		{
+			name:   "prefix without separator",
+			prefix: "/myapp/staging",
+			want:   map[string]string{"DB_HOST": "staging"},
+		},
+		{name: "no keys", prefix: "/missing/", want: map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := clientv3.NewCtxClient(context.Background())
+			client.KV = &fakeKV{kvs: kvs, revision: 5}
+
+			e := &Etcd{Prefix: tt.prefix, Client: client}
+			got, err := e.Fetch(context.Background())
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fetch() = %q, want %q", got, tt.want)
+			}
+			if e.revision != 5 {
+				t.Errorf("revision = %d, want 5", e.revision)
+			}
+		})
+	}
+}
+
+func TestEtcdWatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		watches [][]clientv3.WatchResponse
+		// restarted are the keys that are fetched when the watch is restarted
+		restarted     map[string]string
+		want          []map[string]string
+		wantRevisions []int64
+	}{
+		{
+			name: "changes",
+			watches: [][]clientv3.WatchResponse{{
+				{Header: pb.ResponseHeader{Revision: 6}, Events: []*clientv3.Event{event(mvccpb.PUT, "/app/port", "80")}},
+				{Header: pb.ResponseHeader{Revision: 8}, Events: []*clientv3.Event{
+					event(mvccpb.DELETE, "/app/host", ""),
+					event(mvccpb.PUT, "/app/port", "443"),
+				}},
+			}},
+			want: []map[string]string{
+				{"HOST": "localhost", "PORT": "80"},
+				{"PORT": "443"},
+			},
+			wantRevisions: []int64{6},
+		},
+		{
+			name: "compacted",
+			watches: [][]clientv3.WatchResponse{
+				{{Header: pb.ResponseHeader{Revision: 9}, CompactRevision: 9}},
+				{{Header: pb.ResponseHeader{Revision: 11}, Events: []*clientv3.Event{event(mvccpb.PUT, "/app/port", "443")}}},
+			},
+			restarted: map[string]string{"/app/host": "remote"},
+			want: []map[string]string{
+				{"HOST": "remote"},
+				{"HOST": "remote", "PORT": "443"},
+			},
+			wantRevisions: []int64{6, 11},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kv := &fakeKV{kvs: map[string]string{"/app/host": "localhost"}, revision: 5, restarted: tt.restarted}
+			watcher := &fakeWatcher{watches: tt.watches}
+
+			client := clientv3.NewCtxClient(context.Background())
+			client.KV, client.Watcher = kv, watcher
+
+			e := &Etcd{Prefix: "/app/", Client: client}
+			var got []map[string]string
+			err := e.Watch(context.Background(), func(values map[string]string, err error) {
+				if err != nil {
+					t.Errorf("Watch() called fn with error = %v", err)
+				}
+				got = append(got, values)
+			})
+			// the last watch of the fake is closed by the client
+			if err == nil || !strings.Contains(err.Error(), "was closed") {
+				t.Errorf("Watch() error = %v, want the watch to be closed", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Watch() values = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(watcher.revisions, tt.wantRevisions) {
+				t.Errorf("Watch() revisions = %v, want %v", watcher.revisions, tt.wantRevisions)
+			}
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		prefix string
+		key    string
+		want   string
+	}{
+		{prefix: "/myapp/prod/", key: "/myapp/prod/db/host", want: "DB_HOST"},
+		{prefix: "/myapp/prod", key: "/myapp/prod/db/host", want: "DB_HOST"},
+		{prefix: "", key: "/feature.flags/new-ui", want: "FEATURE_FLAGS_NEW_UI"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			e := &Etcd{Prefix: tt.prefix}
+			if got := e.key(tt.key); got != tt.want {
+				t.Errorf("key(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
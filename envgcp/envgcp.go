@@ -0,0 +1,65 @@
+// Package envgcp provides env.Provider implementations for the configuration and the secrets that are
+// stored in Google Cloud, it lives in a separate package so that its dependencies are only linked into the
+// binaries that need them. Requests are authenticated with the Application Default Credentials (including
+// workload identity) unless a client is provided.
+package envgcp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/VinukaThejana/env"
+	"golang.org/x/oauth2/google"
+)
+
+// scope is the OAuth2 scope of the requests to the Google Cloud APIs.
+const scope = "https://www.googleapis.com/auth/cloud-platform"
+
+// client lazily creates an HTTP client that is authenticated with the Application Default Credentials, the
+// client is only kept once the credentials are found so that the next fetch retries them.
+type client struct {
+	mu sync.Mutex
+	c  *http.Client
+}
+
+// get returns the given client if it is not nil, otherwise the authenticated client.
+func (c *client) get(ctx context.Context, custom *http.Client) (*http.Client, error) {
+	if custom != nil {
+		return custom, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.c == nil {
+		hc, err := google.DefaultClient(context.WithoutCancel(ctx), scope)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the default credentials: %w", err)
+		}
+		c.c = hc
+	}
+
+	return c.c, nil
+}
+
+// do sends the given request and returns the body of the response, an error is returned for the
+// responses that are not successful.
+func do(c *http.Client, req *http.Request) (io.ReadCloser, error) {
+	res, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		defer res.Body.Close()
+
+		b, _ := io.ReadAll(io.LimitReader(res.Body, 1<<10))
+		return nil, &env.StatusError{StatusCode: res.StatusCode, Status: res.Status, Body: strings.TrimSpace(string(b))}
+	}
+
+	return res.Body, nil
+}
@@ -0,0 +1,113 @@
+package envgcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/VinukaThejana/env"
+	"github.com/VinukaThejana/env/envviper"
+)
+
+// defaultStorageEndpoint is the endpoint of the Cloud Storage JSON API.
+const defaultStorageEndpoint = "https://storage.googleapis.com"
+
+// GCS fetches a dotenv, JSON or YAML config object from a Google Cloud Storage bucket. Objects that are
+// encrypted with customer-managed encryption keys (Cloud KMS) are decrypted transparently, objects that are
+// encrypted with customer-supplied encryption keys need the EncryptionKey.
+type GCS struct {
+	// URL is the URL of the object in the gs://bucket/object form.
+	URL string
+	// Generation pins the generation of the object so that all the instances load the same revision,
+	// the latest generation is fetched if it is zero.
+	Generation int64
+	// EncryptionKey is the customer-supplied AES-256 key that the object is encrypted with.
+	EncryptionKey []byte
+	// Parser parses the object, it defaults to a parser that is derived from the extension of the object.
+	Parser env.Parser
+	// Client is the client that sends the requests, it defaults to a client that is authenticated with
+	// the Application Default Credentials.
+	Client *http.Client
+	// Endpoint is the endpoint of the Cloud Storage JSON API, it defaults to https://storage.googleapis.com.
+	Endpoint string
+
+	client client
+}
+
+// Fetch fetches the config object from Cloud Storage.
+func (g *GCS) Fetch(ctx context.Context) (map[string]string, error) {
+	bucket, object, err := parseGCSURL(g.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := g.client.get(ctx, g.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := g.Endpoint
+	if endpoint == "" {
+		endpoint = defaultStorageEndpoint
+	}
+
+	query := url.Values{"alt": {"media"}}
+	if g.Generation != 0 {
+		query.Set("generation", strconv.FormatInt(g.Generation, 10))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(
+		"%s/storage/v1/b/%s/o/%s?%s",
+		strings.TrimSuffix(endpoint, "/"),
+		url.PathEscape(bucket),
+		url.PathEscape(object),
+		query.Encode(),
+	), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", g.URL, err)
+	}
+	if len(g.EncryptionKey) > 0 {
+		sum := sha256.Sum256(g.EncryptionKey)
+		req.Header.Set("x-goog-encryption-algorithm", "AES256")
+		req.Header.Set("x-goog-encryption-key", base64.StdEncoding.EncodeToString(g.EncryptionKey))
+		req.Header.Set("x-goog-encryption-key-sha256", base64.StdEncoding.EncodeToString(sum[:]))
+	}
+
+	body, err := do(c, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", g.URL, err)
+	}
+	defer body.Close()
+
+	parse := g.Parser
+	if parse == nil {
+		parse = envviper.ParserFor(object)
+	}
+
+	m, err := parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", g.URL, err)
+	}
+
+	return m, nil
+}
+
+// parseGCSURL returns the bucket and the object of the given gs://bucket/object URL.
+func parseGCSURL(rawURL string) (string, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid Cloud Storage URL %s: %w", rawURL, err)
+	}
+
+	object := strings.TrimPrefix(u.Path, "/")
+	if u.Scheme != "gs" || u.Host == "" || object == "" {
+		return "", "", fmt.Errorf("invalid Cloud Storage URL %s, expected gs://bucket/object", rawURL)
+	}
+
+	return u.Host, object, nil
+}
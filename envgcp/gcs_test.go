@@ -0,0 +1,117 @@
+package envgcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/VinukaThejana/env"
+)
+
+func TestParseGCSURL(t *testing.T) {
+	tests := []struct {
+		url        string
+		wantBucket string
+		wantObject string
+		wantErr    bool
+	}{
+		{url: "gs://config/app/.env", wantBucket: "config", wantObject: "app/.env"},
+		{url: "gs://config/config.yaml", wantBucket: "config", wantObject: "config.yaml"},
+		{url: "s3://config/.env", wantErr: true},
+		{url: "gs://config", wantErr: true},
+		{url: "gs:///.env", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			bucket, object, err := parseGCSURL(tt.url)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseGCSURL() = %q, %q, want an error", bucket, object)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseGCSURL() error = %v", err)
+			}
+			if bucket != tt.wantBucket || object != tt.wantObject {
+				t.Errorf("parseGCSURL() = %q, %q, want %q, %q", bucket, object, tt.wantBucket, tt.wantObject)
+			}
+		})
+	}
+}
+
+func TestGCSFetch(t *testing.T) {
+	key := []byte(strings.Repeat("k", 32))
+	sum := sha256.Sum256(key)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("alt") != "media" {
+			http.Error(w, "not media", http.StatusBadRequest)
+			return
+		}
+
+		switch r.URL.EscapedPath() {
+		case "/storage/v1/b/config/o/app%2F.env":
+			if generation := r.URL.Query().Get("generation"); generation != "" && generation != "7" {
+				http.Error(w, "no such generation", http.StatusNotFound)
+				return
+			}
+			w.Write([]byte("PORT=8080"))
+		case "/storage/v1/b/config/o/config.json":
+			w.Write([]byte(`{"DB":{"HOST":"db"}}`))
+		case "/storage/v1/b/config/o/encrypted.env":
+			if r.Header.Get("x-goog-encryption-key-sha256") != base64.StdEncoding.EncodeToString(sum[:]) {
+				http.Error(w, "wrong key", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte("PORT=9090"))
+		default:
+			http.Error(w, "no such object", http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		url        string
+		generation int64
+		key        []byte
+		want       map[string]string
+		wantStatus int
+	}{
+		{name: "dotenv", url: "gs://config/app/.env", want: map[string]string{"PORT": "8080"}},
+		{name: "json", url: "gs://config/config.json", want: map[string]string{"DB_HOST": "db"}},
+		{name: "generation", url: "gs://config/app/.env", generation: 7, want: map[string]string{"PORT": "8080"}},
+		{name: "missing generation", url: "gs://config/app/.env", generation: 8, wantStatus: http.StatusNotFound},
+		{name: "encryption key", url: "gs://config/encrypted.env", key: key, want: map[string]string{"PORT": "9090"}},
+		{name: "without encryption key", url: "gs://config/encrypted.env", wantStatus: http.StatusBadRequest},
+		{name: "missing object", url: "gs://config/missing.env", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &GCS{URL: tt.url, Generation: tt.generation, EncryptionKey: tt.key, Client: srv.Client(), Endpoint: srv.URL}
+			got, err := g.Fetch(context.Background())
+			if tt.wantStatus != 0 {
+				var statusErr *env.StatusError
+				if !errors.As(err, &statusErr) || statusErr.StatusCode != tt.wantStatus {
+					t.Errorf("Fetch() error = %v, want the status %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fetch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package envgcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// defaultKMSEndpoint is the endpoint of the Cloud KMS API.
+const defaultKMSEndpoint = "https://cloudkms.googleapis.com"
+
+// KMS is an env.KMS that decrypts an encrypted data key with Google Cloud KMS, use it with env.WithKMS to
+// decrypt the enc:v1: values.
+type KMS struct {
+	// KeyName is the resource name of the key that encrypted the data key,
+	// e.g. projects/p/locations/global/keyRings/r/cryptoKeys/k.
+	KeyName string
+	// EncryptedDataKey is the base64 encoded encrypted data key.
+	EncryptedDataKey string
+	// Client is the client that sends the requests, it defaults to a client that is authenticated with
+	// the Application Default Credentials.
+	Client *http.Client
+	// Endpoint is the endpoint of the Cloud KMS API, it defaults to https://cloudkms.googleapis.com.
+	Endpoint string
+
+	client client
+}
+
+// DataKey decrypts the data key.
+func (k *KMS) DataKey(ctx context.Context) ([]byte, error) {
+	c, err := k.client.get(ctx, k.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := k.Endpoint
+	if endpoint == "" {
+		endpoint = defaultKMSEndpoint
+	}
+
+	b, err := json.Marshal(map[string]string{"ciphertext": k.EncryptedDataKey})
+	if err != nil {
+		return nil, err
+	}
+
+	u := strings.TrimSuffix(endpoint, "/") + "/v1/" + k.KeyName + ":decrypt"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	body, err := do(c, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt the data key with %s: %w", k.KeyName, err)
+	}
+	defer body.Close()
+
+	var res struct {
+		Plaintext string `json:"plaintext"`
+	}
+	if err := json.NewDecoder(body).Decode(&res); err != nil {
+		return nil, fmt.Errorf("failed to decrypt the data key with %s: %w", k.KeyName, err)
+	}
+
+	return base64.StdEncoding.DecodeString(res.Plaintext)
+}
@@ -0,0 +1,67 @@
+package envgcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestKMSDataKey(t *testing.T) {
+	key := bytes.Repeat([]byte{0xab}, 32)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			Ciphertext string `json:"ciphertext"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/projects/p/locations/global/keyRings/r/cryptoKeys/k:decrypt" || input.Ciphertext != "ZW5jcnlwdGVk" {
+			http.Error(w, "invalid ciphertext", http.StatusBadRequest)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{"plaintext": base64.StdEncoding.EncodeToString(key)})
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name             string
+		keyName          string
+		encryptedDataKey string
+		wantErr          string
+	}{
+		{name: "data key", keyName: "projects/p/locations/global/keyRings/r/cryptoKeys/k", encryptedDataKey: "ZW5jcnlwdGVk"},
+		{
+			name:             "wrong data key",
+			keyName:          "projects/p/locations/global/keyRings/r/cryptoKeys/k",
+			encryptedDataKey: "b3RoZXI=",
+			wantErr:          "failed to decrypt the data key with projects/p/locations/global/keyRings/r/cryptoKeys/k",
+		},
+		{name: "other key", keyName: "projects/p/locations/global/keyRings/r/cryptoKeys/other", encryptedDataKey: "ZW5jcnlwdGVk", wantErr: "failed to decrypt the data key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := &KMS{KeyName: tt.keyName, EncryptedDataKey: tt.encryptedDataKey, Client: srv.Client(), Endpoint: srv.URL}
+			got, err := k.DataKey(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("DataKey() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DataKey() error = %v", err)
+			}
+			if !bytes.Equal(got, key) {
+				t.Errorf("DataKey() = %x, want %x", got, key)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package envgcp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/VinukaThejana/env"
+)
+
+// defaultSecretManagerEndpoint is the endpoint of the Secret Manager API.
+const defaultSecretManagerEndpoint = "https://secretmanager.googleapis.com"
+
+// SecretManager fetches secrets from Google Secret Manager. It resolves the fields that are tagged with a
+// secret (e.g. gsm:"projects/p/secrets/name") when it is used as a resolver and fetches all the secrets of
+// the Project whose names start with the Prefix when it is used as a provider.
+type SecretManager struct {
+	// Project is the identifier of the project of the secrets, references without a project
+	// (e.g. gsm:"db-password") are resolved in it.
+	Project string
+	// Prefix selects the secrets that are fetched by the provider, it is trimmed from their names before
+	// they are converted to keys (e.g. myapp-db-host becomes DB_HOST with the myapp- prefix).
+	Prefix string
+	// Version pins the version of the secrets that are fetched by the provider, it defaults to latest.
+	// References pin their versions with a /versions/ suffix (e.g. projects/p/secrets/name/versions/3).
+	Version string
+	// Client is the client that sends the requests, it defaults to a client that is authenticated with
+	// the Application Default Credentials.
+	Client *http.Client
+	// Endpoint is the endpoint of the Secret Manager API, it defaults to https://secretmanager.googleapis.com.
+	Endpoint string
+
+	client client
+}
+
+// WithSecretManager resolves the fields that are tagged with gsm with the given Secret Manager.
+func WithSecretManager(s *SecretManager) env.Option {
+	return env.WithResolver("gsm", s)
+}
+
+// Resolve returns the value of the secret version with the given reference, which is either the resource
+// name of a secret or a secret version, or the name of a secret in the Project.
+func (s *SecretManager) Resolve(ctx context.Context, ref string) (string, error) {
+	name := ref
+	if !strings.HasPrefix(name, "projects/") {
+		if s.Project == "" {
+			return "", fmt.Errorf("the project is required to resolve the secret %s", ref)
+		}
+
+		name = "projects/" + s.Project + "/secrets/" + name
+	}
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+
+	var res struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := s.get(ctx, "/v1/"+name+":access", nil, &res); err != nil {
+		return "", fmt.Errorf("failed to access the secret %s: %w", name, err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(res.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("invalid payload of the secret %s: %w", name, err)
+	}
+
+	return string(data), nil
+}
+
+// Fetch fetches the secrets of the Project whose names start with the Prefix.
+func (s *SecretManager) Fetch(ctx context.Context) (map[string]string, error) {
+	if s.Project == "" {
+		return nil, fmt.Errorf("the project is required to fetch the secrets")
+	}
+
+	version := s.Version
+	if version == "" {
+		version = "latest"
+	}
+
+	m := make(map[string]string)
+	query := url.Values{}
+	for {
+		var res struct {
+			Secrets []struct {
+				Name string `json:"name"`
+			} `json:"secrets"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := s.get(ctx, "/v1/projects/"+s.Project+"/secrets", query, &res); err != nil {
+			return nil, fmt.Errorf("failed to list the secrets of %s: %w", s.Project, err)
+		}
+
+		for _, secret := range res.Secrets {
+			id := secret.Name[strings.LastIndex(secret.Name, "/")+1:]
+			if !strings.HasPrefix(id, s.Prefix) {
+				continue
+			}
+
+			value, err := s.Resolve(ctx, secret.Name+"/versions/"+version)
+			if err != nil {
+				return nil, err
+			}
+
+			m[strings.ToUpper(strings.ReplaceAll(strings.TrimPrefix(id, s.Prefix), "-", "_"))] = value
+		}
+
+		if res.NextPageToken == "" {
+			return m, nil
+		}
+		query.Set("pageToken", res.NextPageToken)
+	}
+}
+
+// get sends a GET request to the given path of the Secret Manager API and decodes the response into v.
+func (s *SecretManager) get(ctx context.Context, path string, query url.Values, v any) error {
+	c, err := s.client.get(ctx, s.Client)
+	if err != nil {
+		return err
+	}
+
+	endpoint := s.Endpoint
+	if endpoint == "" {
+		endpoint = defaultSecretManagerEndpoint
+	}
+
+	u := strings.TrimSuffix(endpoint, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+
+	body, err := do(c, req)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	return json.NewDecoder(body).Decode(v)
+}
@@ -0,0 +1,123 @@
+package envgcp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSecretManager(t *testing.T) {
+	// the secrets of the project p are listed in pages of one
+	secrets := []string{"projects/p/secrets/myapp-db-host", "projects/p/secrets/myapp-api-key", "projects/p/secrets/other"}
+	versions := map[string]string{
+		"projects/p/secrets/myapp-db-host/versions/latest": "db",
+		"projects/p/secrets/myapp-db-host/versions/1":      "old-db",
+		"projects/p/secrets/myapp-api-key/versions/latest": "key",
+		"projects/p/secrets/myapp-api-key/versions/1":      "old-key",
+		"projects/p/secrets/other/versions/latest":         "other",
+		"projects/q/secrets/shared/versions/latest":        "shared",
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/v1/")
+		if name, ok := strings.CutSuffix(path, ":access"); ok {
+			data, ok := versions[name]
+			if !ok {
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+
+			json.NewEncoder(w).Encode(map[string]any{"payload": map[string]string{"data": base64.StdEncoding.EncodeToString([]byte(data))}})
+			return
+		}
+		if path != "projects/p/secrets" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		page, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+		res := map[string]any{"secrets": []map[string]string{{"name": secrets[page]}}}
+		if page+1 < len(secrets) {
+			res["nextPageToken"] = strconv.Itoa(page + 1)
+		}
+		json.NewEncoder(w).Encode(res)
+	}))
+	defer srv.Close()
+
+	t.Run("resolve", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			project string
+			ref     string
+			want    string
+			wantErr string
+		}{
+			{name: "name", project: "p", ref: "other", want: "other"},
+			{name: "secret", ref: "projects/q/secrets/shared", want: "shared"},
+			{name: "version", ref: "projects/p/secrets/myapp-api-key/versions/1", want: "old-key"},
+			{name: "without project", ref: "other", wantErr: "the project is required to resolve the secret other"},
+			{name: "missing", project: "p", ref: "missing", wantErr: "failed to access the secret projects/p/secrets/missing/versions/latest"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				s := &SecretManager{Project: tt.project, Client: srv.Client(), Endpoint: srv.URL}
+				got, err := s.Resolve(context.Background(), tt.ref)
+				if tt.wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+						t.Errorf("Resolve() error = %v, want %q", err, tt.wantErr)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("Resolve() error = %v", err)
+				}
+				if got != tt.want {
+					t.Errorf("Resolve() = %q, want %q", got, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("fetch", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			project string
+			prefix  string
+			version string
+			want    map[string]string
+			wantErr string
+		}{
+			{name: "prefix", project: "p", prefix: "myapp-", want: map[string]string{"DB_HOST": "db", "API_KEY": "key"}},
+			{name: "version", project: "p", prefix: "myapp-", version: "1", want: map[string]string{"DB_HOST": "old-db", "API_KEY": "old-key"}},
+			{name: "all", project: "p", want: map[string]string{"MYAPP_DB_HOST": "db", "MYAPP_API_KEY": "key", "OTHER": "other"}},
+			{name: "without project", wantErr: "the project is required to fetch the secrets"},
+			{name: "missing project", project: "q", wantErr: "failed to list the secrets of q"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				s := &SecretManager{Project: tt.project, Prefix: tt.prefix, Version: tt.version, Client: srv.Client(), Endpoint: srv.URL}
+				got, err := s.Fetch(context.Background())
+				if tt.wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+						t.Errorf("Fetch() error = %v, want %q", err, tt.wantErr)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("Fetch() error = %v", err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Fetch() = %q, want %q", got, tt.want)
+				}
+			})
+		}
+	})
+}
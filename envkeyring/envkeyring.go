@@ -0,0 +1,39 @@
+// Package envkeyring resolves fields from the credential store of the operating system (the macOS Keychain,
+// the Windows Credential Manager or the Secret Service through libsecret on Linux), so that developer machines
+// do not need plaintext secrets in .env files. It lives in a separate package so that the bindings of the
+// credential stores are only linked into the binaries that need them.
+package envkeyring
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/VinukaThejana/env"
+	"github.com/zalando/go-keyring"
+)
+
+// Resolver resolves the references in the service/account form from the credential store.
+type Resolver struct{}
+
+// WithKeyring resolves the fields that are tagged with keyring (e.g. keyring:"myapp/db-password") from the
+// credential store when their variables are not set.
+func WithKeyring() env.Option {
+	return env.WithResolver("keyring", Resolver{})
+}
+
+// Resolve returns the secret of the account of the service in the given service/account reference, the
+// service may contain slashes and the account is the part after the last one.
+func (Resolver) Resolve(_ context.Context, ref string) (string, error) {
+	i := strings.LastIndex(ref, "/")
+	if i <= 0 || i == len(ref)-1 {
+		return "", fmt.Errorf("invalid keyring reference %s, expected service/account", ref)
+	}
+
+	secret, err := keyring.Get(ref[:i], ref[i+1:])
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s from the keyring: %w", ref, err)
+	}
+
+	return secret, nil
+}
@@ -0,0 +1,81 @@
+package envkeyring
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/VinukaThejana/env"
+	"github.com/zalando/go-keyring"
+)
+
+func TestResolve(t *testing.T) {
+	keyring.MockInit()
+	if err := keyring.Set("myapp", "db-password", "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := keyring.Set("github.com/acme/api", "token", "ghp_token"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr string
+	}{
+		{ref: "myapp/db-password", want: "s3cret"},
+		{ref: "github.com/acme/api/token", want: "ghp_token"},
+		{ref: "myapp/missing", wantErr: "failed to read myapp/missing from the keyring"},
+		{ref: "myapp", wantErr: "invalid keyring reference myapp"},
+		{ref: "/db-password", wantErr: "invalid keyring reference /db-password"},
+		{ref: "myapp/", wantErr: "invalid keyring reference myapp/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := Resolver{}.Resolve(context.Background(), tt.ref)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Resolve() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithKeyring(t *testing.T) {
+	keyring.MockInit()
+	if err := keyring.Set("myapp", "db-password", "from-keyring"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{name: "keyring", want: "from-keyring"},
+		{name: "set", src: "DB_PASSWORD=from-file", want: "from-file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg struct {
+				Password string `mapstructure:"DB_PASSWORD" keyring:"myapp/db-password"`
+			}
+			if err := env.LoadReader(strings.NewReader(tt.src), &cfg, env.WithPrecedence(env.FileOnly), WithKeyring()); err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if cfg.Password != tt.want {
+				t.Errorf("Password = %q, want %q", cfg.Password, tt.want)
+			}
+		})
+	}
+}
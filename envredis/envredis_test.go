@@ -0,0 +1,93 @@
+package envredis
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestRedisFetch(t *testing.T) {
+	tests := []struct {
+		name    string
+		hash    string
+		prefix  string
+		url     string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "hash",
+			hash: "app:config",
+			want: map[string]string{"DB_HOST": "hash", "LOG_LEVEL": "debug"},
+		},
+		{
+			name:   "prefix",
+			prefix: "app:config:",
+			want:   map[string]string{"DB_HOST": "localhost", "CACHE_TTL": "5m"},
+		},
+		{
+			name:   "hash and prefix",
+			hash:   "app:config",
+			prefix: "app:config:",
+			want:   map[string]string{"DB_HOST": "localhost", "LOG_LEVEL": "debug", "CACHE_TTL": "5m"},
+		},
+		{name: "glob prefix", prefix: "app*:", want: map[string]string{"PORT": "80"}},
+		{name: "invalid url", hash: "app:config", url: "http://localhost", wantErr: "invalid Redis URL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := miniredis.RunT(t)
+			srv.HSet("app:config", "db:host", "hash", "log-level", "debug")
+			srv.Set("app:config:db:host", "localhost")
+			srv.Set("app:config:cache.ttl", "5m")
+			srv.Set("app*:port", "80")
+			srv.Set("other:db:host", "other")
+			// the keys that are not strings have no values
+			srv.Lpush("app:config:queue", "job")
+
+			r := &Redis{URL: "redis://" + srv.Addr(), Hash: tt.hash, Prefix: tt.prefix}
+			if tt.url != "" {
+				r.URL = tt.url
+			}
+
+			got, err := r.Fetch(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Fetch() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fetch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchPrefix(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{prefix: "app:config:", want: "app:config:*"},
+		{prefix: "app*:", want: `app\*:*`},
+		{prefix: "app?:", want: `app\?:*`},
+		{prefix: "app[1]:", want: `app\[1\]:*`},
+		{prefix: `app\:`, want: `app\\:*`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			if got := matchPrefix(tt.prefix); got != tt.want {
+				t.Errorf("matchPrefix(%q) = %q, want %q", tt.prefix, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+// Package envviper provides an env.Parser backed by viper for config files in the formats that are supported
+// by viper (json, toml, yaml, hcl, ini, properties, ...), it lives in a separate package so that viper is only
+// linked into the binaries that need it.
+package envviper
+
+import (
+	"io"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/VinukaThejana/env"
+	"github.com/spf13/viper"
+)
+
+// Parser returns a parser for config files of the given type (e.g. yaml), keys of the config file are upper
+// cased and nested keys are joined with an underscore (e.g. db.host becomes DB_HOST).
+func Parser(configType string) env.Parser {
+	return func(r io.Reader) (map[string]string, error) {
+		v := viper.New()
+		v.SetConfigType(configType)
+		if err := v.ReadConfig(r); err != nil {
+			return nil, err
+		}
+
+		m := make(map[string]string)
+		for _, key := range v.AllKeys() {
+			m[strings.ToUpper(strings.ReplaceAll(key, ".", "_"))] = v.GetString(key)
+		}
+
+		return m, nil
+	}
+}
+
+// ParserFor returns a parser for the config file with the given name that is derived from its extension,
+// files in the formats that are supported by viper are read with viper and the others with env.ParseDotenv.
+func ParserFor(name string) env.Parser {
+	ext := strings.TrimPrefix(path.Ext(name), ".")
+	if ext == "env" || !slices.Contains(viper.SupportedExts, ext) {
+		return env.ParseDotenv
+	}
+
+	return Parser(ext)
+}
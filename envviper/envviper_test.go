@@ -0,0 +1,75 @@
+package envviper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParser(t *testing.T) {
+	tests := []struct {
+		name       string
+		configType string
+		src        string
+		want       map[string]string
+		wantErr    bool
+	}{
+		{
+			name:       "yaml",
+			configType: "yaml",
+			src:        "port: 8080\ndb:\n  host: localhost\n",
+			want:       map[string]string{"PORT": "8080", "DB_HOST": "localhost"},
+		},
+		{
+			name:       "json",
+			configType: "json",
+			src:        `{"Port": 8080, "db": {"host": "localhost"}}`,
+			want:       map[string]string{"PORT": "8080", "DB_HOST": "localhost"},
+		},
+		{name: "toml", configType: "toml", src: "[db]\nhost = \"localhost\"\n", want: map[string]string{"DB_HOST": "localhost"}},
+		{name: "invalid", configType: "json", src: `{"port":`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parser(tt.configType)(strings.NewReader(tt.src))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Parser() = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parser() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parser() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParserFor(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want map[string]string
+	}{
+		{name: ".env", src: "PORT=8080 # comment", want: map[string]string{"PORT": "8080"}},
+		{name: "config.env", src: "PORT=8080", want: map[string]string{"PORT": "8080"}},
+		{name: "config.yaml", src: "port: 8080", want: map[string]string{"PORT": "8080"}},
+		{name: "config.unknown", src: "port=8080", want: map[string]string{"port": "8080"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParserFor(tt.name)(strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatalf("ParserFor() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParserFor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package env
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ErrMissing is the error of a field that is tagged as required but has no value.
+var ErrMissing = errors.New("missing required environment variable")
+
+// redacted replaces the values of the fields that are tagged as secret.
+const redacted = "[REDACTED]"
+
+// FieldError describes why a single field of the struct could not be loaded.
+type FieldError struct {
+	// Key is the key of the field (e.g. DB_PORT).
+	Key string
+	// FieldName is the name of the field including the names of the structs it is nested in (e.g. DB.Port).
+	FieldName string
+	// Value is the value of the field, it is redacted if the field is tagged as secret.
+	Value string
+	// Err is the reason why the field could not be loaded.
+	Err error
+}
+
+// Error returns the error message of the field.
+func (e FieldError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the reason why the field could not be loaded.
+func (e FieldError) Unwrap() error {
+	return e.Err
+}
+
+// FieldErrors contains the errors of all the fields that could not be loaded, use errors.As to retrieve it
+// from the error that is returned by LoadE.
+type FieldErrors []FieldError
+
+// Error returns the error messages of all the fields separated by newlines.
+func (e FieldErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+// Unwrap returns the errors of all the fields.
+func (e FieldErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+
+	return errs
+}
+
+// redact returns the given value or a placeholder if the given field is a secret.
+func redact(field reflect.StructField, value string) string {
+	if isSecret(field) && value != "" {
+		return redacted
+	}
+
+	return value
+}
+
+// redactedError masks the values of the secret fields in the message of an error that can embed them (e.g. the
+// error of strconv.Atoi contains the value it failed to parse), the error is unwrapped as it is.
+type redactedError struct {
+	err    error
+	values []string
+}
+
+// Error returns the error message with the values of the secret fields replaced by a placeholder.
+func (e *redactedError) Error() string {
+	msg := e.err.Error()
+	for _, value := range e.values {
+		msg = strings.ReplaceAll(msg, value, redacted)
+	}
+
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// recordSecret records the given value of the given field if it is a secret, so that it is masked in the errors.
+func (p *parser) recordSecret(field reflect.StructField, value string) {
+	if value == "" || !isSecret(field) || slices.Contains(p.secretValues, value) {
+		return
+	}
+
+	p.secretValues = append(p.secretValues, value)
+	// the longer values are replaced first so that a value that contains another value is masked in full
+	slices.SortFunc(p.secretValues, func(a, b string) int { return len(b) - len(a) })
+}
+
+// redactErr returns the given error with the values of the secret fields masked in its message.
+func (p *parser) redactErr(err error) error {
+	if err == nil || len(p.secretValues) == 0 {
+		return err
+	}
+
+	return &redactedError{err: err, values: slices.Clone(p.secretValues)}
+}
+
+// redactErrors masks the values of the secret fields in the messages of the recorded errors and warnings, which
+// includes the errors of the Validate methods that can embed any of the values.
+func (p *parser) redactErrors() {
+	for i := range p.errs {
+		p.errs[i].Err = p.redactErr(p.errs[i].Err)
+	}
+	for i := range p.warnings {
+		p.warnings[i].Err = p.redactErr(p.warnings[i].Err)
+	}
+}
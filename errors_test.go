@@ -0,0 +1,182 @@
+package env
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadAggregatesErrors(t *testing.T) {
+	type config struct {
+		Port    int    `mapstructure:"PORT"`
+		Debug   bool   `mapstructure:"DEBUG"`
+		Host    string `mapstructure:"HOST" required:"true"`
+		Workers uint   `mapstructure:"WORKERS"`
+	}
+
+	tests := []struct {
+		name     string
+		src      string
+		wantKeys []string
+	}{
+		{name: "valid", src: "PORT=80\nDEBUG=true\nHOST=db\nWORKERS=2"},
+		{name: "one error", src: "PORT=http\nHOST=db", wantKeys: []string{"PORT"}},
+		{name: "all errors", src: "PORT=http\nDEBUG=maybe\nWORKERS=-1", wantKeys: []string{"PORT", "DEBUG", "HOST", "WORKERS"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := LoadReader(strings.NewReader(tt.src), &cfg, WithPrecedence(FileOnly))
+
+			var errs FieldErrors
+			errors.As(err, &errs)
+			var keys []string
+			for _, e := range errs {
+				keys = append(keys, e.Key)
+			}
+			if !reflect.DeepEqual(keys, tt.wantKeys) {
+				t.Errorf("the keys of the errors = %q, want %q (%v)", keys, tt.wantKeys, err)
+			}
+			if err == nil {
+				return
+			}
+			if got := len(strings.Split(err.Error(), "\n")); got != len(tt.wantKeys) {
+				t.Errorf("the error has %d lines, want one per field: %v", got, err)
+			}
+		})
+	}
+}
+
+func TestFieldErrors(t *testing.T) {
+	type database struct {
+		Port int `mapstructure:"PORT"`
+	}
+	type config struct {
+		Host string   `mapstructure:"HOST" required:"true"`
+		DB   database `prefix:"DB_"`
+	}
+
+	tests := []struct {
+		name    string
+		src     string
+		want    FieldError
+		wantIs  error
+		wantMsg string
+	}{
+		{
+			name:    "missing",
+			src:     "",
+			want:    FieldError{Key: "HOST", FieldName: "Host"},
+			wantIs:  ErrMissing,
+			wantMsg: "missing required environment variable: HOST",
+		},
+		{
+			name:    "invalid",
+			src:     "HOST=db\nDB_PORT=http",
+			want:    FieldError{Key: "DB_PORT", FieldName: "DB.Port", Value: "http"},
+			wantMsg: "failed to parse DB_PORT as int",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := LoadReader(strings.NewReader(tt.src), &cfg, WithPrecedence(FileOnly))
+
+			var fieldErr FieldError
+			if !errors.As(err, &fieldErr) {
+				t.Fatalf("LoadReader() error = %v, want a FieldError", err)
+			}
+			if fieldErr.Key != tt.want.Key || fieldErr.FieldName != tt.want.FieldName || fieldErr.Value != tt.want.Value {
+				t.Errorf("FieldError = %+v, want %+v", fieldErr, tt.want)
+			}
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.wantIs)
+			}
+			if !strings.Contains(fieldErr.Error(), tt.wantMsg) {
+				t.Errorf("Error() = %q, want %q", fieldErr.Error(), tt.wantMsg)
+			}
+		})
+	}
+}
+
+// leakyConfig returns an error from Validate that embeds its secret value.
+type leakyConfig struct {
+	Token string `mapstructure:"TOKEN" secret:"true"`
+}
+
+// Validate returns an error that contains the token.
+func (c *leakyConfig) Validate() error {
+	if strings.HasPrefix(c.Token, "bad") {
+		return errors.New("the token " + c.Token + " is revoked")
+	}
+
+	return nil
+}
+
+func TestLoadRedactsSecrets(t *testing.T) {
+	type config struct {
+		Port     int    `mapstructure:"PORT" secret:"true"`
+		Password string `mapstructure:"PASSWORD" secret:"true" oneof:"a b"`
+	}
+
+	tests := []struct {
+		name    string
+		load    func(src string) error
+		src     string
+		secret  string
+		wantErr error
+	}{
+		{name: "parse error", load: loadString[config], src: "PORT=s3cret", secret: "s3cret"},
+		{name: "constraint", load: loadString[config], src: "PASSWORD=hunter2", secret: "hunter2", wantErr: ErrInvalid},
+		{name: "validate hook", load: loadString[leakyConfig], src: "TOKEN=bad-token", secret: "bad-token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.load(tt.src)
+			if err == nil {
+				t.Fatal("LoadReader() error = nil, want an error")
+			}
+			if strings.Contains(err.Error(), tt.secret) || !strings.Contains(err.Error(), redacted) {
+				t.Errorf("LoadReader() error = %v, want the secret redacted", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("LoadReader() error = %v, want it to wrap %v", err, tt.wantErr)
+			}
+
+			var errs FieldErrors
+			if errors.As(err, &errs) && strings.Contains(errs[0].Value, tt.secret) {
+				t.Errorf("FieldError.Value = %q, want the secret redacted", errs[0].Value)
+			}
+		})
+	}
+}
+
+func TestRedactedError(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   string
+	}{
+		{name: "no values", want: "failed with pass and password"},
+		{name: "value", values: []string{"pass"}, want: "failed with " + redacted + " and " + redacted + "word"},
+		{name: "longer value first", values: []string{"password", "pass"}, want: "failed with " + redacted + " and " + redacted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newParser(nil, newOptions())
+			for _, value := range tt.values {
+				p.recordSecret(reflect.StructField{Name: "Secret", Type: reflect.TypeOf(""), Tag: `secret:"true"`}, value)
+			}
+
+			err := p.redactErr(errors.New("failed with pass and password"))
+			if err.Error() != tt.want {
+				t.Errorf("redactErr() = %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package env
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// defaultExecTimeout is the default timeout of the commands that are run to load the values.
+const defaultExecTimeout = 10 * time.Second
+
+// execProvider runs a command and provides the KEY=value pairs in its output.
+type execProvider struct {
+	o    *options
+	name string
+	args []string
+}
+
+// Fetch runs the command and parses its output as a dotenv file.
+func (p *execProvider) Fetch(ctx context.Context) (map[string]string, error) {
+	out, err := runCommand(ctx, p.o.execTimeout, nil, nil, p.name, p.args...)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := ParseDotenv(bytes.NewReader(out))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the output of %s: %w", p.name, err)
+	}
+
+	return m, nil
+}
+
+// execField runs the command in the exec tag of the given field (e.g. exec:"op read op://vault/item/field")
+// and returns its output with a trailing newline trimmed. Only the commands in the allowlist can be run.
+func (p *parser) execField(field reflect.StructField) (string, bool, error) {
+	tag, ok := field.Tag.Lookup("exec")
+	if !ok {
+		return "", false, nil
+	}
+
+	args := strings.Fields(tag)
+	if len(args) == 0 {
+		return "", false, fmt.Errorf("empty exec tag on field %s", field.Name)
+	}
+	if !slices.Contains(p.o.execAllowlist, args[0]) {
+		return "", false, fmt.Errorf("command %s of field %s is not in the exec allowlist", args[0], field.Name)
+	}
+
+	out, err := runCommand(p.o.ctx, p.o.execTimeout, nil, nil, args[0], args[1:]...)
+	if err != nil {
+		return "", false, err
+	}
+
+	return strings.TrimSuffix(strings.TrimSuffix(string(out), "\n"), "\r"), true, nil
+}
+
+// runCommand runs the given command with the given timeout and returns its output, the given variables are
+// added to the environment of the command and the given reader (if it is not nil) is its standard input.
+func runCommand(ctx context.Context, timeout time.Duration, env []string, stdin io.Reader, name string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = stdin
+	cmd.Stderr = &stderr
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+
+	out, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("failed to run %s: timed out after %s", name, timeout)
+	}
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("failed to run %s: %w", name, ctx.Err())
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("failed to run %s: %v: %s", name, err, msg)
+		}
+
+		return nil, fmt.Errorf("failed to run %s: %v", name, err)
+	}
+
+	return out, nil
+}
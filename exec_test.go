@@ -0,0 +1,85 @@
+package env
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithExec(t *testing.T) {
+	type config struct {
+		Host string `mapstructure:"HOST"`
+		Port int    `mapstructure:"PORT"`
+	}
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		want    config
+		wantErr string
+	}{
+		{
+			name: "output",
+			opts: []Option{WithExec("sh", "-c", `printf 'HOST=exec-host\nexport PORT=9090\n'`)},
+			want: config{Host: "exec-host", Port: 9090},
+		},
+		{name: "merged over the file", opts: []Option{WithExec("sh", "-c", "echo PORT=9090")}, want: config{Host: "file-host", Port: 9090}},
+		{name: "failure", opts: []Option{WithExec("sh", "-c", "echo denied >&2; exit 3")}, wantErr: "failed to run sh: exit status 3: denied"},
+		{name: "timeout", opts: []Option{WithExec("sleep", "1"), WithExecTimeout(10 * time.Millisecond)}, wantErr: "timed out after 10ms"},
+		{name: "invalid output", opts: []Option{WithExec("sh", "-c", `echo 'PORT="8080'`)}, wantErr: "failed to parse the output of sh"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := LoadReader(strings.NewReader("HOST=file-host\nPORT=8080"), &cfg, append([]Option{WithPrecedence(FileOnly)}, tt.opts...)...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadReader() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if cfg != tt.want {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecTag(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		opts    []Option
+		want    string
+		wantErr string
+	}{
+		{name: "output", opts: []Option{WithExecAllowlist("echo")}, want: "from-exec"},
+		{name: "value over the command", src: "TOKEN=from-file", opts: []Option{WithExecAllowlist("echo")}, want: "from-file"},
+		{name: "not allowed", wantErr: "command echo of field Token is not in the exec allowlist"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg struct {
+				Token string `mapstructure:"TOKEN" exec:"echo from-exec"`
+			}
+			err := LoadReader(strings.NewReader(tt.src), &cfg, append([]Option{WithPrecedence(FileOnly)}, tt.opts...)...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadReader() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if cfg.Token != tt.want {
+				t.Errorf("Token = %q, want %q", cfg.Token, tt.want)
+			}
+		})
+	}
+}
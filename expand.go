@@ -0,0 +1,89 @@
+package env
+
+import (
+	"os"
+	"strings"
+)
+
+// expand replaces the ${VAR}, ${VAR:-fallback} and ${VAR-fallback} references in the given value.
+// With :- the fallback is used when the variable is not set or empty and with - the fallback is only
+// used when the variable is not set. Fallbacks may contain references themselves.
+func expand(value string, lookup func(key string) (string, bool)) string {
+	var b strings.Builder
+
+	for {
+		start := strings.Index(value, "${")
+		if start == -1 {
+			b.WriteString(value)
+			return b.String()
+		}
+
+		end := closingBrace(value, start+2)
+		if end == -1 {
+			b.WriteString(value)
+			return b.String()
+		}
+
+		b.WriteString(value[:start])
+		b.WriteString(resolve(value[start+2:end], lookup))
+		value = value[end+1:]
+	}
+}
+
+// resolve resolves the given reference (the part between ${ and }) with the given lookup function.
+func resolve(ref string, lookup func(key string) (string, bool)) string {
+	if key, fallback, ok := strings.Cut(ref, ":-"); ok {
+		if val, ok := lookup(key); ok && val != "" {
+			return val
+		}
+
+		return expand(fallback, lookup)
+	}
+
+	if key, fallback, ok := strings.Cut(ref, "-"); ok {
+		if val, ok := lookup(key); ok {
+			return val
+		}
+
+		return expand(fallback, lookup)
+	}
+
+	val, _ := lookup(ref)
+	return val
+}
+
+// closingBrace returns the index of the brace that closes the reference that starts at the given index,
+// nested references are skipped. -1 is returned if the reference is not closed.
+func closingBrace(value string, start int) int {
+	depth := 1
+	for i := start; i < len(value); i++ {
+		switch {
+		case strings.HasPrefix(value[i:], "${"):
+			depth++
+			i++
+		case value[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// lookupEnv returns a lookup function that looks up the key in the given map and falls back to the
+// environment variables of the process. The fallback honours the options that the map was loaded with: it
+// is not used with FileOnly or a source, and only the variables with the prefix of WithPrefix are visible.
+func lookupEnv(o *options, envMap map[string]string) func(key string) (string, bool) {
+	return func(key string) (string, bool) {
+		if val, ok := envMap[key]; ok {
+			return val, true
+		}
+		if o.source != nil || o.precedence == FileOnly {
+			return "", false
+		}
+
+		return os.LookupEnv(o.envPrefix + key)
+	}
+}
@@ -0,0 +1,69 @@
+package env
+
+import "testing"
+
+func TestLookupEnv(t *testing.T) {
+	t.Setenv("LOOKUP_HOST", "env-host")
+	t.Setenv("APP_LOOKUP_PORT", "8080")
+
+	tests := []struct {
+		name   string
+		opts   []Option
+		key    string
+		want   string
+		wantOK bool
+	}{
+		{name: "loaded value", key: "LOOKUP_NAME", want: "loaded", wantOK: true},
+		{name: "environment fallback", key: "LOOKUP_HOST", want: "env-host", wantOK: true},
+		{name: "missing", key: "LOOKUP_MISSING"},
+		{name: "file only", opts: []Option{WithPrecedence(FileOnly)}, key: "LOOKUP_HOST"},
+		{name: "file only loaded value", opts: []Option{WithPrecedence(FileOnly)}, key: "LOOKUP_NAME", want: "loaded", wantOK: true},
+		{name: "source", opts: []Option{withSource(staticProvider{})}, key: "LOOKUP_HOST"},
+		{name: "prefix", opts: []Option{WithPrefix("APP_")}, key: "LOOKUP_PORT", want: "8080", wantOK: true},
+		{name: "unprefixed with prefix", opts: []Option{WithPrefix("APP_")}, key: "LOOKUP_HOST"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookup := lookupEnv(newOptions(tt.opts...), map[string]string{"LOOKUP_NAME": "loaded"})
+
+			got, ok := lookup(tt.key)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("lookup(%q) = %q, %v, want %q, %v", tt.key, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestExpand(t *testing.T) {
+	values := map[string]string{"HOST": "localhost", "PORT": "5432", "EMPTY": ""}
+	lookup := func(key string) (string, bool) {
+		value, ok := values[key]
+		return value, ok
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "no references", value: "plain", want: "plain"},
+		{name: "reference", value: "${HOST}:${PORT}", want: "localhost:5432"},
+		{name: "missing reference", value: "${MISSING}", want: ""},
+		{name: "fallback of missing", value: "${MISSING:-default}", want: "default"},
+		{name: "fallback of empty", value: "${EMPTY:-default}", want: "default"},
+		{name: "unset fallback of empty", value: "${EMPTY-default}", want: ""},
+		{name: "unset fallback of missing", value: "${MISSING-default}", want: "default"},
+		{name: "nested fallback", value: "${MISSING:-${HOST}}", want: "localhost"},
+		{name: "unclosed reference", value: "${HOST", want: "${HOST"},
+		{name: "dollar without brace", value: "$HOST", want: "$HOST"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expand(tt.value, lookup); got != tt.want {
+				t.Errorf("expand(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
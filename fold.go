@@ -0,0 +1,48 @@
+package env
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// WithCaseInsensitiveKeys matches the keys case-insensitively, so that Port=8080 in a config file fills the
+// field with the PORT key. The keys of every source (the config files, the environment variables and each
+// provider) are upper cased before they are merged, so the precedence between the sources is kept. When the
+// keys of a single source collide (e.g. Port and PORT) the upper cased key wins, otherwise the first of them
+// in lexical order wins, and the collision is logged. The keys of prefixed maps are upper cased as well.
+func WithCaseInsensitiveKeys() Option {
+	return func(o *options) {
+		o.caseInsensitive = true
+	}
+}
+
+// fold upper cases the keys of the given map if the keys are matched case-insensitively.
+func (o *options) fold(m map[string]string) map[string]string {
+	if !o.caseInsensitive || m == nil {
+		return m
+	}
+
+	// the upper cased key is the first of the keys that collide in lexical order
+	folded := make(map[string]string, len(m))
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		upper := strings.ToUpper(key)
+		if _, ok := folded[upper]; ok {
+			o.log().Warn("ignoring the key that collides with another key", "key", key, "collides", upper)
+			continue
+		}
+
+		folded[upper] = m[key]
+	}
+
+	return folded
+}
+
+// canonical returns the given key upper cased if the keys are matched case-insensitively.
+func (p *parser) canonical(key string) string {
+	if !p.o.caseInsensitive {
+		return key
+	}
+
+	return strings.ToUpper(key)
+}
@@ -0,0 +1,70 @@
+package env
+
+import (
+	"maps"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		name            string
+		caseInsensitive bool
+		m               map[string]string
+		want            map[string]string
+	}{
+		{name: "case sensitive", m: map[string]string{"Port": "1", "PORT": "2"}, want: map[string]string{"Port": "1", "PORT": "2"}},
+		{name: "upper cased", caseInsensitive: true, m: map[string]string{"Port": "1", "db_host": "db"}, want: map[string]string{"PORT": "1", "DB_HOST": "db"}},
+		{name: "upper cased key wins", caseInsensitive: true, m: map[string]string{"Port": "1", "PORT": "2", "port": "3"}, want: map[string]string{"PORT": "2"}},
+		{name: "first in lexical order wins", caseInsensitive: true, m: map[string]string{"Port": "1", "port": "2"}, want: map[string]string{"PORT": "1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newOptions()
+			o.caseInsensitive = tt.caseInsensitive
+			if got := o.fold(tt.m); !maps.Equal(got, tt.want) {
+				t.Errorf("fold() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadCaseInsensitiveKeys(t *testing.T) {
+	t.Setenv("fold_host", "env-host")
+
+	type database struct {
+		Host string `mapstructure:"HOST"`
+	}
+	type config struct {
+		Host     string            `mapstructure:"FOLD_HOST"`
+		Port     int               `mapstructure:"PORT"`
+		Database database          `prefix:"db_"`
+		Features map[string]string `prefix:"FEATURE_"`
+	}
+
+	tests := []struct {
+		name       string
+		file       string
+		precedence Precedence
+		want       config
+	}{
+		{name: "file keys", file: "Port=8080\nDB_host=db", precedence: FileOnly, want: config{Port: 8080, Database: database{Host: "db"}}},
+		{name: "env over file", file: "FOLD_HOST=file-host", precedence: EnvOverFile, want: config{Host: "env-host"}},
+		{name: "file over env", file: "Fold_Host=file-host", precedence: FileOverEnv, want: config{Host: "file-host"}},
+		{name: "prefixed map", file: "feature_Beta=on", precedence: FileOnly, want: config{Features: map[string]string{"BETA": "on"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			fsys := fstest.MapFS{".env": {Data: []byte(tt.file)}}
+			if err := LoadE(&cfg, WithFS(fsys), WithPrecedence(tt.precedence), WithCaseInsensitiveKeys()); err != nil {
+				t.Fatalf("LoadE() error = %v", err)
+			}
+			if cfg.Host != tt.want.Host || cfg.Port != tt.want.Port || cfg.Database != tt.want.Database || !maps.Equal(cfg.Features, tt.want.Features) {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package env
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+// gpgExt is the extension of the config files that are encrypted with OpenPGP.
+const gpgExt = ".gpg"
+
+// WithGPG loads the OpenPGP encrypted variant of every config file (e.g. .env.gpg instead of .env) when it exists.
+// The files are decrypted with the secret keys in the given keyring file (armored or binary, unlocked with
+// GPG_PASSPHRASE if they are protected), or by running gpg --decrypt if the path is empty so that the keys
+// and the agent of the gpg installation are used. Config files with the .gpg extension are decrypted as well
+// and fail to load without this option.
+func WithGPG(keyring string) Option {
+	return func(o *options) {
+		o.gpg = true
+		o.gpgKeyring = keyring
+	}
+}
+
+// gpgParser returns a parser that decrypts an OpenPGP encrypted config file and parses it with the given parser.
+func gpgParser(o *options, parse Parser) Parser {
+	return func(r io.Reader) (map[string]string, error) {
+		if !o.gpg {
+			return nil, errors.New("the config file is encrypted with OpenPGP, use WithGPG to decrypt it")
+		}
+
+		if o.gpgKeyring == "" {
+			out, err := runCommand(o.ctx, o.execTimeout, nil, r, "gpg", "--decrypt", "--batch", "--quiet")
+			if err != nil {
+				return nil, err
+			}
+
+			return parse(bytes.NewReader(out))
+		}
+
+		keyring, err := readKeyring(o.gpgKeyring)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		if block, err := armor.Decode(bytes.NewReader(data)); err == nil {
+			if data, err = io.ReadAll(block.Body); err != nil {
+				return nil, err
+			}
+		}
+
+		passphrase := []byte(os.Getenv("GPG_PASSPHRASE"))
+		prompted := false
+		md, err := openpgp.ReadMessage(bytes.NewReader(data), keyring, func(keys []openpgp.Key, symmetric bool) ([]byte, error) {
+			// the prompt is called again until the message is decrypted, so it gives up after the first attempt
+			if prompted || len(passphrase) == 0 {
+				return nil, errors.New("the key is protected and GPG_PASSPHRASE is missing or wrong")
+			}
+			prompted = true
+
+			if symmetric {
+				return passphrase, nil
+			}
+			for _, key := range keys {
+				if key.PrivateKey != nil && key.PrivateKey.Encrypted {
+					_ = key.PrivateKey.Decrypt(passphrase)
+				}
+			}
+
+			return nil, nil
+		}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt: %w", err)
+		}
+
+		return parse(md.UnverifiedBody)
+	}
+}
+
+// readKeyring reads the armored or binary keyring in the given path.
+func readKeyring(path string) (openpgp.EntityList, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		// the error is not wrapped, a missing keyring must not be taken for a missing config file
+		return nil, fmt.Errorf("failed to read the keyring: %v", err)
+	}
+
+	keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(b))
+	if err != nil {
+		keyring, err = openpgp.ReadKeyRing(bytes.NewReader(b))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid keyring %s: %w", path, err)
+	}
+
+	return keyring, nil
+}
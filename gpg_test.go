@@ -0,0 +1,127 @@
+package env
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+func TestWithGPG(t *testing.T) {
+	entity, err := openpgp.NewEntity("app", "", "app@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var message bytes.Buffer
+	w, err := openpgp.Encrypt(&message, []*openpgp.Entity{entity}, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("PORT=9090")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var armored bytes.Buffer
+	aw, err := armor.Encode(&armored, "PGP MESSAGE", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := aw.Write(message.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	var keyring bytes.Buffer
+	if err := entity.SerializePrivate(&keyring, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "keyring.gpg"), keyring.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	keyring.Reset()
+	if err := entity.EncryptPrivateKeys([]byte("passphrase"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.SerializePrivateWithoutSigning(&keyring, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "protected.gpg"), keyring.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// the fake gpg binary prints the decrypted config
+	if err := os.WriteFile(filepath.Join(dir, "gpg"), []byte("#!/bin/sh\ncat >/dev/null\necho PORT=7070\n"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	tests := []struct {
+		name       string
+		encrypted  []byte
+		passphrase string
+		opts       []Option
+		want       int
+		wantErr    string
+	}{
+		{name: "keyring", encrypted: message.Bytes(), opts: []Option{WithGPG(filepath.Join(dir, "keyring.gpg"))}, want: 9090},
+		{name: "armored", encrypted: armored.Bytes(), opts: []Option{WithGPG(filepath.Join(dir, "keyring.gpg"))}, want: 9090},
+		{
+			name:       "protected keyring",
+			encrypted:  message.Bytes(),
+			passphrase: "passphrase",
+			opts:       []Option{WithGPG(filepath.Join(dir, "protected.gpg"))},
+			want:       9090,
+		},
+		{name: "gpg", encrypted: message.Bytes(), opts: []Option{WithGPG("")}, want: 7070},
+		{name: "without gpg", encrypted: message.Bytes(), want: 8080},
+		{name: "gpg file without gpg", encrypted: message.Bytes(), opts: []Option{WithFile(".env.gpg")}, wantErr: "use WithGPG to decrypt it"},
+		{
+			name:       "wrong passphrase",
+			encrypted:  message.Bytes(),
+			passphrase: "wrong",
+			opts:       []Option{WithGPG(filepath.Join(dir, "protected.gpg"))},
+			wantErr:    "GPG_PASSPHRASE is missing or wrong",
+		},
+		{name: "missing keyring", encrypted: message.Bytes(), opts: []Option{WithGPG(filepath.Join(dir, "missing.gpg"))}, wantErr: "failed to read the keyring"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GPG_PASSPHRASE", tt.passphrase)
+
+			var cfg struct {
+				Port int `mapstructure:"PORT"`
+			}
+			fsys := fstest.MapFS{
+				".env":     {Data: []byte("PORT=8080")},
+				".env.gpg": {Data: tt.encrypted},
+			}
+			err := LoadE(&cfg, append([]Option{WithFS(fsys), WithPrecedence(FileOnly)}, tt.opts...)...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadE() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadE() error = %v", err)
+			}
+			if cfg.Port != tt.want {
+				t.Errorf("Port = %d, want %d", cfg.Port, tt.want)
+			}
+		})
+	}
+}
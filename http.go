@@ -0,0 +1,184 @@
+package env
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultHTTPTimeout is the default timeout of the requests to the remote config endpoints.
+const defaultHTTPTimeout = 10 * time.Second
+
+// defaultHTTPClient is the client of the providers that talk to HTTP APIs when they are not given a client.
+var defaultHTTPClient = &http.Client{Timeout: defaultHTTPTimeout}
+
+// StatusError is the error of a response of a remote source that is not successful.
+type StatusError struct {
+	// StatusCode is the status code of the response.
+	StatusCode int
+	// Status is the status of the response, e.g. 503 Service Unavailable.
+	Status string
+	// Body is the beginning of the body of the response.
+	Body string
+}
+
+// Error returns the status and the body of the response.
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return e.Status
+	}
+
+	return e.Status + ": " + e.Body
+}
+
+// newStatusError returns the error of the given response that is not successful.
+func newStatusError(res *http.Response) *StatusError {
+	b, _ := io.ReadAll(io.LimitReader(res.Body, 1<<10))
+	return &StatusError{StatusCode: res.StatusCode, Status: res.Status, Body: strings.TrimSpace(string(b))}
+}
+
+// HTTP fetches the config from an HTTP(S) endpoint that serves a dotenv or a JSON document. The same HTTP
+// value should be reused across loads, the ETag and the Last-Modified headers of the last response are sent
+// with the next request and the cached config is used when the endpoint responds with 304 Not Modified.
+type HTTP struct {
+	// URL is the URL of the endpoint.
+	URL string
+	// Header contains the headers that are sent with the requests (e.g. Authorization).
+	Header http.Header
+	// TLSConfig is the TLS configuration of the requests, it is ignored if Client is set.
+	TLSConfig *tls.Config
+	// Timeout is the timeout of the requests, it defaults to 10 seconds and it is ignored if Client is set.
+	Timeout time.Duration
+	// Client is the client that sends the requests.
+	Client *http.Client
+	// Parser parses the response, it defaults to ParseJSON for JSON responses and ParseDotenv otherwise.
+	Parser Parser
+
+	mu           sync.Mutex
+	httpClient   *http.Client
+	etag         string
+	lastModified string
+	cached       map[string]string
+}
+
+// Fetch fetches the config from the endpoint.
+func (h *HTTP) Fetch(ctx context.Context) (map[string]string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the request to %s: %w", h.URL, err)
+	}
+	for key, values := range h.Header {
+		req.Header[key] = values
+	}
+	if h.cached != nil {
+		if h.etag != "" {
+			req.Header.Set("If-None-Match", h.etag)
+		}
+		if h.lastModified != "" {
+			req.Header.Set("If-Modified-Since", h.lastModified)
+		}
+	}
+
+	res, err := h.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the config from %s: %w", h.URL, err)
+	}
+	defer res.Body.Close()
+
+	// the callers can modify the returned maps (e.g. when they are merged), so the cache is never returned
+	if res.StatusCode == http.StatusNotModified && h.cached != nil {
+		return maps.Clone(h.cached), nil
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("failed to fetch the config from %s: %w", h.URL, newStatusError(res))
+	}
+
+	parse := h.Parser
+	if parse == nil {
+		parse = ParseDotenv
+		if strings.Contains(res.Header.Get("Content-Type"), "json") {
+			parse = ParseJSON
+		}
+	}
+
+	m, err := parse(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the config from %s: %w", h.URL, err)
+	}
+
+	h.cached = m
+	h.etag = res.Header.Get("ETag")
+	h.lastModified = res.Header.Get("Last-Modified")
+
+	return maps.Clone(m), nil
+}
+
+// client returns the client that sends the requests.
+func (h *HTTP) client() *http.Client {
+	if h.Client != nil {
+		return h.Client
+	}
+	if h.httpClient != nil {
+		return h.httpClient
+	}
+
+	timeout := h.Timeout
+	if timeout == 0 {
+		timeout = defaultHTTPTimeout
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = h.TLSConfig
+
+	h.httpClient = &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+
+	return h.httpClient
+}
+
+// doJSON sends a request with the given method, headers and JSON body (if it is not nil) to the given URL and
+// decodes the JSON response into v (if it is not nil), an error is returned for the responses that are not
+// successful.
+func doJSON(ctx context.Context, c *http.Client, method, url string, header http.Header, body io.Reader, v any) error {
+	if c == nil {
+		c = defaultHTTPClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return newStatusError(res)
+	}
+	if v == nil {
+		return nil
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
@@ -0,0 +1,86 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestHTTPFetch(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		want        map[string]string
+		wantStatus  int
+	}{
+		{name: "dotenv", body: "PORT=8080\nHOST=localhost", status: http.StatusOK, want: map[string]string{"PORT": "8080", "HOST": "localhost"}},
+		{name: "json", contentType: "application/json", body: `{"port":8080,"db":{"host":"localhost"}}`, status: http.StatusOK, want: map[string]string{"port": "8080", "db_host": "localhost"}},
+		{name: "not found", body: "missing", status: http.StatusNotFound, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			got, err := (&HTTP{URL: srv.URL}).Fetch(context.Background())
+			if tt.wantStatus != 0 {
+				var statusErr *StatusError
+				if !errors.As(err, &statusErr) || statusErr.StatusCode != tt.wantStatus {
+					t.Fatalf("Fetch() error = %v, want status %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fetch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPFetchNotModified(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte("PORT=8080"))
+	}))
+	defer srv.Close()
+
+	h := &HTTP{URL: srv.URL}
+	for i := 0; i < 3; i++ {
+		got, err := h.Fetch(context.Background())
+		if err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+		if want := map[string]string{"PORT": "8080"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("Fetch() #%d = %q, want %q", i+1, got, want)
+		}
+
+		// the callers modify the returned maps, which must not change the cached config
+		got["PORT"] = "changed"
+		got["EXTRA"] = "1"
+	}
+	if requests != 3 {
+		t.Errorf("requests = %d, want 3", requests)
+	}
+}
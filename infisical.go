@@ -0,0 +1,125 @@
+package env
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// defaultInfisicalURL is the URL of Infisical Cloud.
+const defaultInfisicalURL = "https://app.infisical.com"
+
+// Infisical fetches the secrets of an environment of an Infisical project with a machine identity that
+// authenticates with Universal Auth.
+type Infisical struct {
+	// ClientID is the client ID of the machine identity, it defaults to INFISICAL_UNIVERSAL_AUTH_CLIENT_ID.
+	ClientID string
+	// ClientSecret is the client secret of the machine identity, it defaults to
+	// INFISICAL_UNIVERSAL_AUTH_CLIENT_SECRET.
+	ClientSecret string
+	// ProjectID is the ID of the project of the secrets.
+	ProjectID string
+	// Environment is the slug of the environment of the secrets (e.g. prod).
+	Environment string
+	// Path is the folder of the secrets, it defaults to the root folder.
+	Path string
+	// Recursive fetches the secrets in the subfolders of the Path as well.
+	Recursive bool
+	// FallbackToLocal fetches no secrets when the client ID or the client secret is not set, so that the
+	// values of the local .env files are used in development.
+	FallbackToLocal bool
+	// URL is the URL of the Infisical instance, it defaults to https://app.infisical.com.
+	URL string
+	// Client is the client that sends the requests, it defaults to a client with a 10 second timeout.
+	Client *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// Fetch fetches the secrets of the environment.
+func (i *Infisical) Fetch(ctx context.Context) (map[string]string, error) {
+	clientID := cmp.Or(i.ClientID, os.Getenv("INFISICAL_UNIVERSAL_AUTH_CLIENT_ID"))
+	clientSecret := cmp.Or(i.ClientSecret, os.Getenv("INFISICAL_UNIVERSAL_AUTH_CLIENT_SECRET"))
+	if clientID == "" || clientSecret == "" {
+		if i.FallbackToLocal {
+			return map[string]string{}, nil
+		}
+
+		return nil, fmt.Errorf("the client ID and the client secret of the Infisical machine identity are required")
+	}
+
+	token, err := i.login(ctx, clientID, clientSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to log in to Infisical: %w", err)
+	}
+
+	query := url.Values{
+		"workspaceId": {i.ProjectID},
+		"environment": {i.Environment},
+		"secretPath":  {cmp.Or(i.Path, "/")},
+	}
+	if i.Recursive {
+		query.Set("recursive", "true")
+	}
+
+	var res struct {
+		Secrets []struct {
+			SecretKey   string `json:"secretKey"`
+			SecretValue string `json:"secretValue"`
+		} `json:"secrets"`
+	}
+	header := http.Header{"Authorization": {"Bearer " + token}}
+	if err := doJSON(ctx, i.Client, http.MethodGet, i.url()+"/api/v3/secrets/raw?"+query.Encode(), header, nil, &res); err != nil {
+		return nil, fmt.Errorf("failed to fetch the Infisical secrets: %w", err)
+	}
+
+	m := make(map[string]string, len(res.Secrets))
+	for _, secret := range res.Secrets {
+		m[secret.SecretKey] = secret.SecretValue
+	}
+
+	return m, nil
+}
+
+// login returns the access token of the machine identity, the token is reused until it expires.
+func (i *Infisical) login(ctx context.Context, clientID, clientSecret string) (string, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.token != "" && time.Now().Before(i.expires) {
+		return i.token, nil
+	}
+
+	body, err := json.Marshal(map[string]string{"clientId": clientID, "clientSecret": clientSecret})
+	if err != nil {
+		return "", err
+	}
+
+	var res struct {
+		AccessToken string `json:"accessToken"`
+		ExpiresIn   int    `json:"expiresIn"`
+	}
+	if err := doJSON(ctx, i.Client, http.MethodPost, i.url()+"/api/v1/auth/universal-auth/login", nil, bytes.NewReader(body), &res); err != nil {
+		return "", err
+	}
+
+	i.token = res.AccessToken
+	// the token is renewed a minute before it expires
+	i.expires = time.Now().Add(time.Duration(res.ExpiresIn)*time.Second - time.Minute)
+
+	return i.token, nil
+}
+
+// url returns the URL of the Infisical instance.
+func (i *Infisical) url() string {
+	return cmp.Or(i.URL, defaultInfisicalURL)
+}
@@ -0,0 +1,119 @@
+package env
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInfisicalFetch(t *testing.T) {
+	tests := []struct {
+		name            string
+		clientSecret    string
+		path            string
+		recursive       bool
+		fallbackToLocal bool
+		expiresIn       int
+		want            map[string]string
+		wantQuery       string
+		wantLogins      int
+		wantErr         string
+	}{
+		{
+			name:         "secrets",
+			clientSecret: "secret",
+			expiresIn:    3600,
+			want:         map[string]string{"DB_HOST": "localhost", "DB_PASSWORD": "s3cret"},
+			wantQuery:    "environment=prod&secretPath=%2F&workspaceId=project",
+			wantLogins:   1,
+		},
+		{
+			name:         "recursive path",
+			clientSecret: "secret",
+			path:         "/billing",
+			recursive:    true,
+			expiresIn:    3600,
+			want:         map[string]string{"DB_HOST": "localhost", "DB_PASSWORD": "s3cret"},
+			wantQuery:    "environment=prod&recursive=true&secretPath=%2Fbilling&workspaceId=project",
+			wantLogins:   1,
+		},
+		{
+			name:         "expired token",
+			clientSecret: "secret",
+			expiresIn:    30,
+			want:         map[string]string{"DB_HOST": "localhost", "DB_PASSWORD": "s3cret"},
+			wantQuery:    "environment=prod&secretPath=%2F&workspaceId=project",
+			wantLogins:   2,
+		},
+		{name: "fallback to local", fallbackToLocal: true, want: map[string]string{}},
+		{name: "no credentials", wantErr: "the client ID and the client secret of the Infisical machine identity are required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("INFISICAL_UNIVERSAL_AUTH_CLIENT_ID", "")
+			t.Setenv("INFISICAL_UNIVERSAL_AUTH_CLIENT_SECRET", tt.clientSecret)
+
+			var logins int
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+
+				switch r.URL.Path {
+				case "/api/v1/auth/universal-auth/login":
+					var body map[string]string
+					if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body["clientId"] != "client" || body["clientSecret"] != "secret" {
+						t.Errorf("unexpected login %v: %v", body, err)
+					}
+					logins++
+					_, _ = fmt.Fprintf(w, `{"accessToken":"token-%d","expiresIn":%d}`, logins, tt.expiresIn)
+				case "/api/v3/secrets/raw":
+					if r.URL.RawQuery != tt.wantQuery || r.Header.Get("Authorization") != fmt.Sprintf("Bearer token-%d", logins) {
+						t.Errorf("unexpected request %s with Authorization %q", r.URL, r.Header.Get("Authorization"))
+					}
+					_, _ = w.Write([]byte(`{"secrets":[{"secretKey":"DB_HOST","secretValue":"localhost"},{"secretKey":"DB_PASSWORD","secretValue":"s3cret"}]}`))
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer srv.Close()
+
+			i := &Infisical{
+				ClientID:        "client",
+				ProjectID:       "project",
+				Environment:     "prod",
+				Path:            tt.path,
+				Recursive:       tt.recursive,
+				FallbackToLocal: tt.fallbackToLocal,
+				URL:             srv.URL,
+			}
+			if tt.clientSecret == "" {
+				i.ClientID = ""
+			}
+
+			// the token of the first fetch is reused by the second one until it expires
+			for range 2 {
+				got, err := i.Fetch(context.Background())
+				if tt.wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+						t.Fatalf("Fetch() error = %v, want %q", err, tt.wantErr)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("Fetch() error = %v", err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Fetch() = %q, want %q", got, tt.want)
+				}
+			}
+			if logins != tt.wantLogins {
+				t.Errorf("logins = %d, want %d", logins, tt.wantLogins)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package env
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ParseJSON parses the contents of a JSON config file, which must contain an object. Keys of nested objects
+// are joined with an underscore (e.g. {"DB": {"HOST": "x"}} becomes DB_HOST), arrays are joined with commas
+// and the other values are converted to their string representations.
+func ParseJSON(r io.Reader) (map[string]string, error) {
+	var v map[string]any
+
+	d := json.NewDecoder(r)
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]string)
+	flatten(m, "", v)
+
+	return m, nil
+}
+
+// flatten adds the values of the given object to the given map with their keys prefixed with the given prefix.
+func flatten(m map[string]string, prefix string, v map[string]any) {
+	for key, value := range v {
+		switch value := value.(type) {
+		case map[string]any:
+			flatten(m, prefix+key+"_", value)
+		case []any:
+			items := make([]string, len(value))
+			for i, item := range value {
+				items[i] = stringify(item)
+			}
+
+			m[prefix+key] = strings.Join(items, ",")
+		default:
+			m[prefix+key] = stringify(value)
+		}
+	}
+}
+
+// stringify returns the string representation of the given JSON value.
+func stringify(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]any, []any:
+		b, _ := json.Marshal(v)
+		return string(b)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,44 @@
+package env
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "empty", src: `{}`, want: map[string]string{}},
+		{name: "scalars", src: `{"HOST":"db","PORT":5432,"DEBUG":true,"TOKEN":null}`, want: map[string]string{"HOST": "db", "PORT": "5432", "DEBUG": "true", "TOKEN": ""}},
+		{name: "large number", src: `{"ID":12345678901234567890}`, want: map[string]string{"ID": "12345678901234567890"}},
+		{name: "nested objects", src: `{"DB":{"HOST":"db","POOL":{"SIZE":10}}}`, want: map[string]string{"DB_HOST": "db", "DB_POOL_SIZE": "10"}},
+		{name: "arrays", src: `{"HOSTS":["a","b"],"PORTS":[80,443]}`, want: map[string]string{"HOSTS": "a,b", "PORTS": "80,443"}},
+		{name: "objects in arrays", src: `{"ITEMS":[{"a":1}]}`, want: map[string]string{"ITEMS": `{"a":1}`}},
+		{name: "case is kept", src: `{"db_host":"db"}`, want: map[string]string{"db_host": "db"}},
+		{name: "not an object", src: `["a"]`, wantErr: true},
+		{name: "invalid", src: `{"HOST":`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseJSON(strings.NewReader(tt.src))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseJSON() = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseJSON() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseJSON() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
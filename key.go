@@ -0,0 +1,186 @@
+package env
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// defaultTagNames are the names of the tags that contain the keys of the fields by default.
+var defaultTagNames = []string{"mapstructure"}
+
+// WithTagName sets the names of the tags that contain the keys of the fields (e.g. env), the first of the tags
+// that a field has is used so that structs that are tagged for other libraries work unchanged. It defaults to
+// mapstructure.
+func WithTagName(names ...string) Option {
+	return func(o *options) {
+		o.tagNames = names
+	}
+}
+
+// WithSkipUntagged skips the fields without a key tag (mapstructure by default) instead of loading them, so that computed or
+// runtime-only fields can live in the same struct as the config. Nested structs and prefixed maps are still
+// loaded through their prefix tags.
+func WithSkipUntagged() Option {
+	return func(o *options) {
+		o.skipUntagged = true
+	}
+}
+
+// WithInferredKeys derives the keys of the fields without a key tag from their names in SCREAMING_SNAKE_CASE
+// (e.g. HTTPPort becomes HTTP_PORT and UserID becomes USER_ID), so that conventional structs need no tags.
+// Nested structs without a prefix tag add their key to the prefix (e.g. Database.Host becomes DATABASE_HOST),
+// embedded structs do not.
+func WithInferredKeys() Option {
+	return withKeyInference(inferSnake)
+}
+
+// keyInference is how the keys of the fields without a key tag are derived from their names.
+type keyInference int
+
+const (
+	// inferNone does not derive the keys, the fields without a key tag have empty keys.
+	inferNone keyInference = iota
+	// inferEnvconfig derives the keys like kelseyhightower/envconfig, the names are upper cased and split into
+	// words only if the field is tagged with split_words:"true".
+	inferEnvconfig
+	// inferSnake derives the keys in SCREAMING_SNAKE_CASE (e.g. HTTPPort becomes HTTP_PORT).
+	inferSnake
+)
+
+// withKeyInference derives the keys of the fields without a key tag from their names.
+func withKeyInference(inference keyInference) Option {
+	return func(o *options) {
+		o.keyInference = inference
+	}
+}
+
+// keyTag returns the value of the first key tag of the given field.
+func (p *parser) keyTag(field reflect.StructField) (string, bool) {
+	for _, name := range p.o.tagNames {
+		if tag, ok := field.Tag.Lookup(name); ok {
+			return tag, true
+		}
+	}
+
+	return "", false
+}
+
+// skipped reports whether the given field is excluded from loading with a "-" key tag (e.g. mapstructure:"-")
+// or the ignored:"true" tag.
+func (p *parser) skipped(field reflect.StructField) bool {
+	if ignored, _ := boolTag(field, "ignored"); ignored {
+		return true
+	}
+
+	tag, _ := p.keyTag(field)
+	return tag == "-"
+}
+
+// fieldKey returns the key of the given field without the prefix, false is returned if the field is not
+// loaded because it has no key and the untagged fields are skipped. The key is empty for the untagged fields
+// if the keys are not inferred, such fields are only filled by their resolvers or defaults. The options that
+// follow the key in the tag (e.g. env:"PORT,required") are not part of the key.
+func (p *parser) fieldKey(field reflect.StructField) (string, bool) {
+	tag, ok := p.keyTag(field)
+	if !ok && p.o.skipUntagged {
+		return "", false
+	}
+
+	key, _, _ := strings.Cut(tag, ",")
+	switch p.o.keyInference {
+	case inferEnvconfig:
+		if !ok {
+			key = field.Name
+			if split, _ := boolTag(field, "split_words"); split {
+				key = strings.Join(splitWords(field.Name), "_")
+			}
+		}
+
+		key = strings.ToUpper(key)
+	case inferSnake:
+		if !ok {
+			key = strings.ToUpper(strings.Join(splitWords(field.Name), "_"))
+		}
+	}
+
+	return p.canonical(key), true
+}
+
+// nestedPrefix returns the prefix that the given nested struct field adds to the keys of its fields, which is
+// its prefix tag or, if the keys are inferred from the names of the fields, its key followed by an underscore.
+// Embedded structs do not add a prefix.
+func (p *parser) nestedPrefix(field reflect.StructField) string {
+	if prefix := structPrefix(field); prefix != "" || p.o.keyInference == inferNone || field.Anonymous {
+		return prefix
+	}
+
+	key, _ := p.fieldKey(field)
+	return key + "_"
+}
+
+// flag reports whether the given field is tagged with the given boolean tag (e.g. required:"true") or has it as
+// an option of its key tag (e.g. env:"PORT,required").
+func (p *parser) flag(field reflect.StructField, name string) (bool, error) {
+	tag, _ := p.keyTag(field)
+	if _, options, ok := strings.Cut(tag, ","); ok && slices.Contains(strings.Split(options, ","), name) {
+		return true, nil
+	}
+
+	return boolTag(field, name)
+}
+
+// defaultValue returns the value of the default (or envDefault) tag of the given field.
+func defaultValue(field reflect.StructField) (string, bool) {
+	if value, ok := field.Tag.Lookup("default"); ok {
+		return value, true
+	}
+
+	return field.Tag.Lookup("envDefault")
+}
+
+// structPrefix returns the value of the prefix (or envPrefix) tag of the given nested struct field.
+func structPrefix(field reflect.StructField) string {
+	if prefix, ok := field.Tag.Lookup("prefix"); ok {
+		return prefix
+	}
+
+	return field.Tag.Get("envPrefix")
+}
+
+// splitWords splits the given name of a field into its words, keeping acronyms and digits together with the
+// previous letters (e.g. HTTPPort becomes HTTP and Port, UserID becomes User and ID).
+func splitWords(name string) []string {
+	runes := []rune(name)
+
+	var words []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+
+		boundary := false
+		switch {
+		case cur == '_':
+			words = append(words, string(runes[start:i]))
+			start = i + 1
+			continue
+		case unicode.IsUpper(cur) && (unicode.IsLower(prev) || unicode.IsDigit(prev)):
+			// the start of a word after a lower case word (e.g. the P of userPort)
+			boundary = true
+		case unicode.IsUpper(cur) && unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+			// the start of a word after an acronym (e.g. the P of HTTPPort)
+			boundary = true
+		}
+
+		if boundary && i > start {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+
+	return words
+}
@@ -0,0 +1,195 @@
+package env
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSkippedFields(t *testing.T) {
+	type config struct {
+		Host     string `mapstructure:"HOST"`
+		Excluded string `mapstructure:"-"`
+		Ignored  string `mapstructure:"IGNORED" ignored:"true"`
+		Done     chan struct{}
+		Mu       *sync.Mutex `mapstructure:"-"`
+		Computed string      `default:"computed"`
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		want config
+	}{
+		{name: "dash and ignored tags", want: config{Host: "a", Computed: "computed"}},
+		{name: "skip untagged", opts: []Option{WithSkipUntagged()}, want: config{Host: "a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			src := "HOST=a\nEXCLUDED=b\nIGNORED=c"
+			if err := LoadReader(strings.NewReader(src), &cfg, append([]Option{WithPrecedence(FileOnly)}, tt.opts...)...); err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if cfg.Host != tt.want.Host || cfg.Excluded != tt.want.Excluded || cfg.Ignored != tt.want.Ignored || cfg.Computed != tt.want.Computed {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithTagName(t *testing.T) {
+	type config struct {
+		Host    string `env:"HOST" mapstructure:"MS_HOST"`
+		Port    int    `envconfig:"PORT"`
+		Name    string `mapstructure:"NAME"`
+		Skipped string `env:"-" mapstructure:"SKIPPED"`
+	}
+
+	tests := []struct {
+		name  string
+		names []string
+		want  config
+	}{
+		{name: "default", want: config{Host: "ms", Name: "name", Skipped: "skipped"}},
+		{name: "env", names: []string{"env"}, want: config{Host: "env"}},
+		{name: "fallback order", names: []string{"env", "envconfig", "mapstructure"}, want: config{Host: "env", Port: 80, Name: "name"}},
+		{name: "mapstructure first", names: []string{"mapstructure", "env"}, want: config{Host: "ms", Name: "name", Skipped: "skipped"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []Option{WithPrecedence(FileOnly), WithSkipUntagged()}
+			if tt.names != nil {
+				opts = append(opts, WithTagName(tt.names...))
+			}
+
+			var cfg config
+			src := "HOST=env\nMS_HOST=ms\nPORT=80\nNAME=name\nSKIPPED=skipped"
+			if err := LoadReader(strings.NewReader(src), &cfg, opts...); err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if cfg != tt.want {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvTagOptions(t *testing.T) {
+	dir := t.TempDir()
+	cert := filepath.Join(dir, "cert.pem")
+	if err := os.WriteFile(cert, []byte("cert"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	type database struct {
+		Host string `env:"HOST" envDefault:"localhost"`
+	}
+	type config struct {
+		Name     string   `env:"NAME,required"`
+		Token    string   `env:"TOKEN,notEmpty"`
+		URL      string   `env:"URL,expand"`
+		Cert     string   `env:"CERT,file"`
+		Port     int      `env:"PORT" envDefault:"8080"`
+		Database database `envPrefix:"DB_"`
+	}
+
+	tests := []struct {
+		name    string
+		src     string
+		want    config
+		wantErr string
+	}{
+		{
+			name: "options",
+			src:  "NAME=api\nTOKEN=t\nHOST=example.com\nURL=https://${HOST}\nCERT=" + cert + "\nDB_HOST=db",
+			want: config{Name: "api", Token: "t", URL: "https://example.com", Cert: "cert", Port: 8080, Database: database{Host: "db"}},
+		},
+		{name: "defaults", src: "NAME=api\nTOKEN=t", want: config{Name: "api", Token: "t", Port: 8080, Database: database{Host: "localhost"}}},
+		{name: "required", src: "TOKEN=t", wantErr: "NAME"},
+		{name: "not empty", src: "NAME=api\nTOKEN=", wantErr: "TOKEN is empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := LoadReader(strings.NewReader(tt.src), &cfg, WithPrecedence(FileOnly), WithTagName("env"))
+			if tt.wantErr != "" {
+				if err == nil || !errors.Is(err, ErrMissing) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadReader() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if cfg != tt.want {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{name: "Port", want: []string{"Port"}},
+		{name: "HTTPPort", want: []string{"HTTP", "Port"}},
+		{name: "UserID", want: []string{"User", "ID"}},
+		{name: "userPort", want: []string{"user", "Port"}},
+		{name: "S3Bucket", want: []string{"S3", "Bucket"}},
+		{name: "Max_Conns", want: []string{"Max", "Conns"}},
+		{name: "URL", want: []string{"URL"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitWords(tt.name); !slices.Equal(got, tt.want) {
+				t.Errorf("splitWords(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithInferredKeys(t *testing.T) {
+	type database struct {
+		Host string
+	}
+	type Embedded struct {
+		LogLevel string
+	}
+	type config struct {
+		Embedded
+		HTTPPort int
+		UserID   string
+		Name     string `mapstructure:"SERVICE"`
+		Database database
+		Cache    database `prefix:"REDIS_"`
+	}
+
+	var cfg config
+	src := "HTTP_PORT=80\nUSER_ID=u\nSERVICE=api\nDATABASE_HOST=db\nREDIS_HOST=redis\nLOG_LEVEL=debug"
+	if err := LoadReader(strings.NewReader(src), &cfg, WithPrecedence(FileOnly), WithInferredKeys()); err != nil {
+		t.Fatalf("LoadReader() error = %v", err)
+	}
+
+	want := config{
+		Embedded: Embedded{LogLevel: "debug"},
+		HTTPPort: 80,
+		UserID:   "u",
+		Name:     "api",
+		Database: database{Host: "db"},
+		Cache:    database{Host: "redis"},
+	}
+	if cfg != want {
+		t.Errorf("config = %+v, want %+v", cfg, want)
+	}
+}
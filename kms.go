@@ -0,0 +1,140 @@
+package env
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// encPrefix is the prefix of the values that are encrypted with a data key.
+const encPrefix = "enc:v1:"
+
+// KMS provides the AES-256 data key that the inline encrypted values are decrypted with, typically by
+// decrypting an encrypted data key with a key management service (see the envaws and the envgcp packages).
+type KMS interface {
+	DataKey(ctx context.Context) ([]byte, error)
+}
+
+// KeyFile is a KMS that reads the data key from a local file, which contains the 32 bytes of the key either
+// raw, hex encoded or base64 encoded.
+type KeyFile struct {
+	// Path is the path of the key file.
+	Path string
+}
+
+// DataKey reads the data key from the key file.
+func (k KeyFile) DataKey(_ context.Context) ([]byte, error) {
+	b, err := os.ReadFile(k.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the key file: %w", err)
+	}
+	if len(b) == 32 {
+		return b, nil
+	}
+
+	s := strings.TrimSpace(string(b))
+	if key, err := hex.DecodeString(s); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(s); err == nil && len(key) == 32 {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("invalid key file %s, expected a 32 byte key", k.Path)
+}
+
+// WithKMS decrypts the values of the enc:v1:<nonce>:<ciphertext> form, which can be mixed with plain values in
+// the config files and the environment variables, with AES-256-GCM and the data key of the given KMS. The data
+// key is fetched once per load, and only if there are encrypted values.
+func WithKMS(k KMS) Option {
+	return func(o *options) {
+		o.references = append(o.references, tagResolver{tag: encPrefix, r: &kmsResolver{kms: k}})
+	}
+}
+
+// EncryptValue encrypts the given value with AES-256-GCM and the given data key and returns it in the
+// enc:v1:<nonce>:<ciphertext> form that WithKMS decrypts.
+func EncryptValue(key []byte, value string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	ciphertext := gcm.Seal(nil, nonce, []byte(value), nil)
+
+	return encPrefix + base64.StdEncoding.EncodeToString(nonce) + ":" + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// kmsResolver decrypts the encrypted values with the data key of a KMS.
+type kmsResolver struct {
+	kms  KMS
+	once sync.Once
+	gcm  cipher.AEAD
+	err  error
+}
+
+// Resolve decrypts the given encrypted value.
+func (r *kmsResolver) Resolve(ctx context.Context, value string) (string, error) {
+	r.once.Do(func() {
+		var key []byte
+		key, r.err = r.kms.DataKey(ctx)
+		if r.err != nil {
+			r.err = fmt.Errorf("failed to fetch the data key: %w", r.err)
+			return
+		}
+
+		r.gcm, r.err = newGCM(key)
+	})
+	if r.err != nil {
+		return "", r.err
+	}
+
+	nonce, ciphertext, ok := strings.Cut(strings.TrimPrefix(value, encPrefix), ":")
+	if !ok {
+		return "", errors.New("invalid encrypted value, expected enc:v1:<nonce>:<ciphertext>")
+	}
+
+	n, err := base64.StdEncoding.DecodeString(nonce)
+	if err != nil || len(n) != r.gcm.NonceSize() {
+		return "", errors.New("invalid nonce of the encrypted value")
+	}
+
+	c, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", errors.New("invalid ciphertext of the encrypted value")
+	}
+
+	plaintext, err := r.gcm.Open(nil, n, c, nil)
+	if err != nil {
+		return "", errors.New("failed to decrypt the value, it was tampered with or encrypted with another key")
+	}
+
+	return string(plaintext), nil
+}
+
+// newGCM returns the AES-256-GCM cipher of the given key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid data key, expected 32 bytes but got %d", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
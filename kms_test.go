@@ -0,0 +1,142 @@
+package env
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// staticKMS is a KMS that returns a fixed data key and counts the fetches of it.
+type staticKMS struct {
+	key     []byte
+	err     error
+	fetches int
+}
+
+func (k *staticKMS) DataKey(context.Context) ([]byte, error) {
+	k.fetches++
+	return k.key, k.err
+}
+
+func TestKeyFile(t *testing.T) {
+	key := bytes.Repeat([]byte{0xab}, 32)
+	dir := t.TempDir()
+	files := map[string]string{
+		"raw":     string(key),
+		"hex":     hex.EncodeToString(key) + "\n",
+		"base64":  base64.StdEncoding.EncodeToString(key) + "\n",
+		"invalid": "short",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		wantErr string
+	}{
+		{name: "raw"},
+		{name: "hex"},
+		{name: "base64"},
+		{name: "invalid", wantErr: "expected a 32 byte key"},
+		{name: "missing", wantErr: "failed to read the key file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := KeyFile{Path: filepath.Join(dir, tt.name)}.DataKey(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("DataKey() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DataKey() error = %v", err)
+			}
+			if !bytes.Equal(got, key) {
+				t.Errorf("DataKey() = %x, want %x", got, key)
+			}
+		})
+	}
+}
+
+func TestWithKMS(t *testing.T) {
+	key := bytes.Repeat([]byte{0xab}, 32)
+	password, err := EncryptValue(key, "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := EncryptValue(key, "t0ken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := EncryptValue(bytes.Repeat([]byte{0xcd}, 32), "other")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type config struct {
+		Host     string `mapstructure:"DB_HOST"`
+		Password string `mapstructure:"DB_PASSWORD"`
+		Token    string `mapstructure:"API_TOKEN"`
+	}
+
+	tests := []struct {
+		name        string
+		src         string
+		kms         *staticKMS
+		want        config
+		wantFetches int
+		wantErr     string
+	}{
+		{
+			name:        "encrypted values",
+			src:         "DB_HOST=localhost\nDB_PASSWORD=" + password + "\nAPI_TOKEN=" + token,
+			kms:         &staticKMS{key: key},
+			want:        config{Host: "localhost", Password: "s3cret", Token: "t0ken"},
+			wantFetches: 1,
+		},
+		{
+			name: "plain values",
+			src:  "DB_HOST=localhost\nDB_PASSWORD=plain",
+			kms:  &staticKMS{key: key},
+			want: config{Host: "localhost", Password: "plain"},
+		},
+		{name: "other key", src: "DB_PASSWORD=" + other, kms: &staticKMS{key: key}, wantErr: "encrypted with another key"},
+		{name: "invalid value", src: "DB_PASSWORD=enc:v1:abc", kms: &staticKMS{key: key}, wantErr: "expected enc:v1:<nonce>:<ciphertext>"},
+		{name: "invalid nonce", src: "DB_PASSWORD=enc:v1:abc:def", kms: &staticKMS{key: key}, wantErr: "invalid nonce"},
+		{name: "invalid data key", src: "DB_PASSWORD=" + password, kms: &staticKMS{key: key[:16]}, wantErr: "expected 32 bytes but got 16"},
+		{name: "data key error", src: "DB_PASSWORD=" + password, kms: &staticKMS{err: errors.New("access denied")}, wantErr: "failed to fetch the data key: access denied"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := LoadReader(strings.NewReader(tt.src), &cfg, WithPrecedence(FileOnly), WithKMS(tt.kms))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadReader() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if cfg != tt.want {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+			if tt.kms.fetches != tt.wantFetches {
+				t.Errorf("data key fetches = %d, want %d", tt.kms.fetches, tt.wantFetches)
+			}
+		})
+	}
+}
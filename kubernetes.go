@@ -0,0 +1,173 @@
+package env
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// serviceAccountDir is the directory that the service account of a pod is mounted at.
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// Kubernetes fetches the data of ConfigMaps and Secrets through the Kubernetes API, for the operators and the
+// jobs that can not rely on the variables that are injected when a pod is created. It authenticates with the
+// service account of the pod by default. The keys of the data are upper cased with the dashes and the dots
+// replaced with underscores (e.g. db.host becomes DB_HOST) and the later objects override the earlier ones,
+// with the Secrets applied after the ConfigMaps.
+type Kubernetes struct {
+	// Namespace is the namespace of the objects, it defaults to the namespace of the service account.
+	Namespace string
+	// ConfigMaps are the names of the ConfigMaps to fetch.
+	ConfigMaps []string
+	// Secrets are the names of the Secrets to fetch.
+	Secrets []string
+	// Host is the URL of the API server, it defaults to the in-cluster URL.
+	Host string
+	// Token is the bearer token of the requests, it defaults to the token of the service account which is
+	// read on every fetch as it is rotated.
+	Token string
+	// Client is the client that sends the requests, it defaults to a client that trusts the CA of the
+	// service account.
+	Client *http.Client
+
+	mu     sync.Mutex
+	client *http.Client
+}
+
+// Fetch fetches the data of the ConfigMaps and the Secrets.
+func (k *Kubernetes) Fetch(ctx context.Context) (map[string]string, error) {
+	client, err := k.httpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := k.Namespace
+	if namespace == "" {
+		b, err := os.ReadFile(serviceAccountDir + "/namespace")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the namespace of the service account: %w", err)
+		}
+		namespace = strings.TrimSpace(string(b))
+	}
+
+	host := k.Host
+	if host == "" {
+		h, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if h == "" || port == "" {
+			return nil, fmt.Errorf("the host of the API server is required outside of a cluster")
+		}
+		host = "https://" + net.JoinHostPort(h, port)
+	}
+
+	token := k.Token
+	if token == "" {
+		b, err := os.ReadFile(serviceAccountDir + "/token")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the token of the service account: %w", err)
+		}
+		token = strings.TrimSpace(string(b))
+	}
+	header := http.Header{"Authorization": {"Bearer " + token}}
+
+	m := make(map[string]string)
+	for _, name := range k.ConfigMaps {
+		var res struct {
+			Data       map[string]string `json:"data"`
+			BinaryData map[string]string `json:"binaryData"`
+		}
+		if err := doJSON(ctx, client, http.MethodGet, fmt.Sprintf("%s/api/v1/namespaces/%s/configmaps/%s", strings.TrimSuffix(host, "/"), namespace, name), header, nil, &res); err != nil {
+			return nil, fmt.Errorf("failed to fetch the ConfigMap %s/%s: %w", namespace, name, err)
+		}
+
+		for key, value := range res.Data {
+			m[dataKey(key)] = value
+		}
+		if err := decodeData(m, res.BinaryData); err != nil {
+			return nil, fmt.Errorf("invalid binary data in the ConfigMap %s/%s: %w", namespace, name, err)
+		}
+	}
+
+	for _, name := range k.Secrets {
+		var res struct {
+			Data map[string]string `json:"data"`
+		}
+		if err := doJSON(ctx, client, http.MethodGet, fmt.Sprintf("%s/api/v1/namespaces/%s/secrets/%s", strings.TrimSuffix(host, "/"), namespace, name), header, nil, &res); err != nil {
+			return nil, fmt.Errorf("failed to fetch the Secret %s/%s: %w", namespace, name, err)
+		}
+
+		if err := decodeData(m, res.Data); err != nil {
+			return nil, fmt.Errorf("invalid data in the Secret %s/%s: %w", namespace, name, err)
+		}
+	}
+
+	return m, nil
+}
+
+// httpClient returns the client of the requests, it is created on the first fetch that succeeds so that a CA
+// which is mounted after a failed fetch is picked up.
+func (k *Kubernetes) httpClient() (*http.Client, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.client == nil {
+		c, err := k.newClient()
+		if err != nil {
+			return nil, err
+		}
+		k.client = c
+	}
+
+	return k.client, nil
+}
+
+// newClient returns the Client if it is set, otherwise a client that trusts the CA of the service account.
+func (k *Kubernetes) newClient() (*http.Client, error) {
+	if k.Client != nil {
+		return k.Client, nil
+	}
+
+	ca, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if os.IsNotExist(err) {
+		return defaultHTTPClient, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the CA of the service account: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("invalid CA of the service account")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+
+	return &http.Client{Timeout: defaultHTTPTimeout, Transport: transport}, nil
+}
+
+// decodeData adds the given base64 encoded data to the given map.
+func decodeData(m map[string]string, data map[string]string) error {
+	for key, value := range data {
+		b, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+
+		m[dataKey(key)] = string(b)
+	}
+
+	return nil
+}
+
+// dataKey returns the env key of the given key of the data of a ConfigMap or a Secret, or of the given name
+// of a secret file.
+func dataKey(key string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(key))
+}
@@ -0,0 +1,113 @@
+package env
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKubernetesFetch(t *testing.T) {
+	encode := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+	objects := map[string]string{
+		"/api/v1/namespaces/app/configmaps/config": `{"data":{"db.host":"localhost","log-level":"debug"},"binaryData":{"cert.pem":"` + encode("cert") + `"}}`,
+		"/api/v1/namespaces/app/configmaps/extra":  `{"data":{"log-level":"info"}}`,
+		"/api/v1/namespaces/app/secrets/db":        `{"data":{"db.password":"` + encode("s3cret") + `","db.host":"` + encode("secret-host") + `"}}`,
+		"/api/v1/namespaces/app/secrets/invalid":   `{"data":{"token":"!"}}`,
+	}
+
+	tests := []struct {
+		name       string
+		configMaps []string
+		secrets    []string
+		want       map[string]string
+		wantStatus int
+		wantErr    string
+	}{
+		{
+			name:       "config maps",
+			configMaps: []string{"config", "extra"},
+			want:       map[string]string{"DB_HOST": "localhost", "LOG_LEVEL": "info", "CERT_PEM": "cert"},
+		},
+		{
+			name:       "secrets override config maps",
+			configMaps: []string{"config"},
+			secrets:    []string{"db"},
+			want:       map[string]string{"DB_HOST": "secret-host", "DB_PASSWORD": "s3cret", "LOG_LEVEL": "debug", "CERT_PEM": "cert"},
+		},
+		{name: "not found", configMaps: []string{"missing"}, wantStatus: http.StatusNotFound, wantErr: "failed to fetch the ConfigMap app/missing"},
+		{name: "invalid data", secrets: []string{"invalid"}, wantErr: "invalid data in the Secret app/invalid: token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer token" {
+					t.Errorf("unexpected Authorization %q", r.Header.Get("Authorization"))
+				}
+
+				body, ok := objects[r.URL.Path]
+				if !ok {
+					http.NotFound(w, r)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(body))
+			}))
+			defer srv.Close()
+
+			k := &Kubernetes{Namespace: "app", ConfigMaps: tt.configMaps, Secrets: tt.secrets, Host: srv.URL, Token: "token", Client: srv.Client()}
+			got, err := k.Fetch(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Fetch() error = %v, want %q", err, tt.wantErr)
+				}
+				var statusErr *StatusError
+				if tt.wantStatus != 0 && (!errors.As(err, &statusErr) || statusErr.StatusCode != tt.wantStatus) {
+					t.Errorf("Fetch() error = %v, want status %d", err, tt.wantStatus)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fetch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKubernetesFetchOutsideCluster(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	t.Setenv("KUBERNETES_SERVICE_PORT", "")
+
+	_, err := (&Kubernetes{Namespace: "app", Token: "token", Client: http.DefaultClient}).Fetch(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "the host of the API server is required") {
+		t.Errorf("Fetch() error = %v, want the host to be required", err)
+	}
+}
+
+func TestDataKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "DB_HOST", want: "DB_HOST"},
+		{key: "db.host", want: "DB_HOST"},
+		{key: "log-level", want: "LOG_LEVEL"},
+		{key: "tls.cert-file", want: "TLS_CERT_FILE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := dataKey(tt.key); got != tt.want {
+				t.Errorf("dataKey(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package env
+
+import (
+	"context"
+	"log/slog"
+)
+
+// discardHandler is a slog.Handler that discards all the records, it is used when no logger is provided.
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
@@ -0,0 +1,53 @@
+package env
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestWithLogger(t *testing.T) {
+	type config struct {
+		Port int `mapstructure:"PORT"`
+	}
+
+	tests := []struct {
+		name  string
+		fsys  fstest.MapFS
+		level slog.Level
+		want  []string
+	}{
+		{name: "loaded file", fsys: fstest.MapFS{".env": {Data: []byte("PORT=80")}}, level: slog.LevelDebug, want: []string{"loaded the config file"}},
+		{name: "missing file", fsys: fstest.MapFS{}, level: slog.LevelDebug, want: []string{"skipping the config file that does not exist", "no config file found"}},
+		{name: "level", fsys: fstest.MapFS{}, level: slog.LevelInfo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: tt.level}))
+
+			var cfg config
+			if err := LoadE(&cfg, WithFS(tt.fsys), WithLogger(logger)); err != nil {
+				t.Fatalf("LoadE() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("the log does not contain %q:\n%s", want, buf.String())
+				}
+			}
+			if len(tt.want) == 0 && buf.Len() > 0 {
+				t.Errorf("the log = %q, want it to be empty", buf.String())
+			}
+		})
+	}
+
+	t.Run("discarded", func(t *testing.T) {
+		if newOptions().log().Enabled(context.Background(), slog.LevelError) {
+			t.Error("the default logger is enabled, want the diagnostics to be discarded")
+		}
+	})
+}
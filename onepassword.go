@@ -0,0 +1,140 @@
+package env
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// OnePassword resolves the 1Password secret references (op://vault/item/field or
+// op://vault/item/section/field) through 1Password Connect, or through the op CLI with a service account
+// token when no Connect server is configured.
+type OnePassword struct {
+	// ConnectHost is the URL of the Connect server, it defaults to OP_CONNECT_HOST.
+	ConnectHost string
+	// ConnectToken is the access token of the Connect server, it defaults to OP_CONNECT_TOKEN.
+	ConnectToken string
+	// ServiceAccountToken is the token of the service account that the op CLI uses, it defaults to
+	// OP_SERVICE_ACCOUNT_TOKEN which the op CLI reads itself.
+	ServiceAccountToken string
+	// Client is the client that sends the requests to Connect, it defaults to a client with a 10 second
+	// timeout.
+	Client *http.Client
+}
+
+// WithOnePassword resolves the values that are op:// references and the fields that are tagged with op
+// (e.g. op:"op://vault/item/field") with the given 1Password resolver.
+func WithOnePassword(op *OnePassword) Option {
+	return func(o *options) {
+		WithResolver("op", op)(o)
+		WithReferences("op", op)(o)
+	}
+}
+
+// Resolve returns the value of the field that the given secret reference points to.
+func (op *OnePassword) Resolve(ctx context.Context, ref string) (string, error) {
+	host := cmp.Or(op.ConnectHost, os.Getenv("OP_CONNECT_HOST"))
+	token := cmp.Or(op.ConnectToken, os.Getenv("OP_CONNECT_TOKEN"))
+	if host == "" || token == "" {
+		return op.read(ctx, ref)
+	}
+
+	u, err := url.Parse(ref)
+	if err != nil || u.Scheme != "op" {
+		return "", fmt.Errorf("invalid 1Password reference %s, expected op://vault/item/field", ref)
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return "", fmt.Errorf("invalid 1Password reference %s, expected op://vault/item/field", ref)
+	}
+
+	vault, item, field, section := u.Host, parts[0], parts[len(parts)-1], ""
+	if len(parts) == 3 {
+		section = parts[1]
+	}
+
+	c := &connect{host: strings.TrimSuffix(host, "/"), header: http.Header{"Authorization": {"Bearer " + token}}, client: op.Client}
+
+	vaultID, err := c.find(ctx, "/v1/vaults", "name", vault)
+	if err != nil {
+		return "", fmt.Errorf("failed to find the vault %s: %w", vault, err)
+	}
+
+	itemID, err := c.find(ctx, "/v1/vaults/"+vaultID+"/items", "title", item)
+	if err != nil {
+		return "", fmt.Errorf("failed to find the item %s: %w", item, err)
+	}
+
+	var res struct {
+		Fields []struct {
+			ID      string `json:"id"`
+			Label   string `json:"label"`
+			Value   string `json:"value"`
+			Section *struct {
+				ID    string `json:"id"`
+				Label string `json:"label"`
+			} `json:"section"`
+		} `json:"fields"`
+	}
+	if err := doJSON(ctx, c.client, http.MethodGet, c.host+"/v1/vaults/"+vaultID+"/items/"+itemID, c.header, nil, &res); err != nil {
+		return "", fmt.Errorf("failed to fetch the item %s: %w", item, err)
+	}
+
+	for _, f := range res.Fields {
+		if f.Label != field && f.ID != field {
+			continue
+		}
+		if section != "" && (f.Section == nil || (f.Section.Label != section && f.Section.ID != section)) {
+			continue
+		}
+
+		return f.Value, nil
+	}
+
+	return "", fmt.Errorf("field %s not found in the item %s", field, item)
+}
+
+// read reads the given secret reference with the op CLI.
+func (op *OnePassword) read(ctx context.Context, ref string) (string, error) {
+	var env []string
+	if op.ServiceAccountToken != "" {
+		env = append(env, "OP_SERVICE_ACCOUNT_TOKEN="+op.ServiceAccountToken)
+	}
+
+	out, err := runCommand(ctx, defaultExecTimeout, env, nil, "op", "read", "--no-newline", ref)
+	if err != nil {
+		return "", err
+	}
+
+	return string(out), nil
+}
+
+// connect sends the requests to a 1Password Connect server.
+type connect struct {
+	host   string
+	header http.Header
+	client *http.Client
+}
+
+// find returns the ID of the object in the given collection whose attribute equals the given value, the
+// value is used as the ID if no object matches it.
+func (c *connect) find(ctx context.Context, path, attribute, value string) (string, error) {
+	var res []struct {
+		ID string `json:"id"`
+	}
+	filter := url.Values{"filter": {fmt.Sprintf("%s eq %q", attribute, value)}}
+	if err := doJSON(ctx, c.client, http.MethodGet, c.host+path+"?"+filter.Encode(), c.header, nil, &res); err != nil {
+		return "", err
+	}
+
+	if len(res) == 0 {
+		return value, nil
+	}
+
+	return res[0].ID, nil
+}
@@ -0,0 +1,138 @@
+package env
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// connectServer returns a 1Password Connect server with the app item of the prod vault.
+func connectServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer connect-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/vaults":
+			if r.URL.Query().Get("filter") == `name eq "prod"` {
+				_, _ = w.Write([]byte(`[{"id":"vault-id"}]`))
+				return
+			}
+			_, _ = w.Write([]byte(`[]`))
+		case "/v1/vaults/vault-id/items":
+			if r.URL.Query().Get("filter") == `title eq "app"` {
+				_, _ = w.Write([]byte(`[{"id":"item-id"}]`))
+				return
+			}
+			_, _ = w.Write([]byte(`[]`))
+		case "/v1/vaults/vault-id/items/item-id":
+			_, _ = w.Write([]byte(`{"fields":[
+				{"id":"password","label":"password","value":"s3cret"},
+				{"id":"f1","label":"host","value":"db.internal","section":{"id":"s1","label":"database"}},
+				{"id":"f2","label":"host","value":"cache.internal","section":{"id":"s2","label":"cache"}}
+			]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestOnePasswordConnect(t *testing.T) {
+	srv := connectServer(t)
+
+	tests := []struct {
+		name    string
+		ref     string
+		want    string
+		wantErr string
+	}{
+		{name: "field", ref: "op://prod/app/password", want: "s3cret"},
+		{name: "section field", ref: "op://prod/app/cache/host", want: "cache.internal"},
+		{name: "section id", ref: "op://prod/app/s1/host", want: "db.internal"},
+		{name: "ids", ref: "op://vault-id/item-id/password", want: "s3cret"},
+		{name: "missing field", ref: "op://prod/app/username", wantErr: "field username not found in the item app"},
+		{name: "missing item", ref: "op://prod/other/password", wantErr: "failed to fetch the item other"},
+		{name: "invalid reference", ref: "op://prod/app", wantErr: "invalid 1Password reference op://prod/app"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := &OnePassword{ConnectHost: srv.URL, ConnectToken: "connect-token"}
+			got, err := op.Resolve(context.Background(), tt.ref)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Resolve() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOnePasswordCLI(t *testing.T) {
+	// the fake op CLI prints the service account token and the reference that it reads
+	dir := t.TempDir()
+	script := "#!/bin/sh\nprintf '%s %s' \"$OP_SERVICE_ACCOUNT_TOKEN\" \"$3\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "op"), []byte(script), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("OP_CONNECT_HOST", "")
+	t.Setenv("OP_CONNECT_TOKEN", "")
+	t.Setenv("OP_SERVICE_ACCOUNT_TOKEN", "env-token")
+
+	tests := []struct {
+		name  string
+		token string
+		want  string
+	}{
+		{name: "service account token", token: "ops_token", want: "ops_token op://prod/app/password"},
+		{name: "environment token", want: "env-token op://prod/app/password"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := (&OnePassword{ServiceAccountToken: tt.token}).Resolve(context.Background(), "op://prod/app/password")
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithOnePassword(t *testing.T) {
+	srv := connectServer(t)
+
+	var cfg struct {
+		Password string `mapstructure:"DB_PASSWORD"`
+		Host     string `mapstructure:"DB_HOST" op:"op://prod/app/database/host"`
+	}
+	src := "DB_PASSWORD=op://prod/app/password"
+	if err := LoadReader(strings.NewReader(src), &cfg, WithPrecedence(FileOnly), WithOnePassword(&OnePassword{ConnectHost: srv.URL, ConnectToken: "connect-token"})); err != nil {
+		t.Fatalf("LoadReader() error = %v", err)
+	}
+	if cfg.Password != "s3cret" || cfg.Host != "db.internal" {
+		t.Errorf("config = %+v, want the resolved references", cfg)
+	}
+}
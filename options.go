@@ -0,0 +1,346 @@
+package env
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Precedence defines how the values in the config files and the environment variables are merged.
+type Precedence int
+
+const (
+	// FileOrEnv loads the config files if any of them exist and the environment variables otherwise.
+	FileOrEnv Precedence = iota
+	// EnvOverFile loads the config files and overrides their values with the environment variables.
+	EnvOverFile
+	// FileOverEnv loads the environment variables and overrides their values with the config files.
+	FileOverEnv
+	// FileOnly loads only the config files.
+	FileOnly
+	// EnvOnly loads only the environment variables.
+	EnvOnly
+)
+
+// Option configures how the environment variables are loaded.
+type Option func(*options)
+
+// options contains the configuration that is used while loading the environment variables.
+type options struct {
+	ctx        context.Context
+	path       string
+	file       string
+	files      []string
+	layers     bool
+	profile    string
+	precedence Precedence
+	expand     bool
+	strict     bool
+	sops       bool
+	report     *Report
+	logger     *slog.Logger
+	parser     Parser
+	providers  []Provider
+	resolvers  []tagResolver
+	references []tagResolver
+	fsys       fs.FS
+	reader     io.Reader
+	source     Provider
+	retry      *Retry
+	verify     verifier
+
+	execTimeout   time.Duration
+	execAllowlist []string
+
+	age             bool
+	ageIdentityFile string
+
+	gpg        bool
+	gpgKeyring string
+
+	keyPrefix       string
+	envPrefix       string
+	tagNames        []string
+	skipUntagged    bool
+	keyInference    keyInference
+	caseInsensitive bool
+
+	provenance *Provenance
+	audit      *Audit
+	origins    map[string]Origin
+
+	pollInterval   time.Duration
+	reloadSignals  []os.Signal
+	fieldCallbacks []fieldCallback
+	subscribers    []chan<- ChangeSet
+	reloadError    func(error)
+}
+
+// newOptions returns the options with the defaults applied and the given options on top of them.
+func newOptions(opts ...Option) *options {
+	o := &options{
+		ctx:    context.Background(),
+		path:   ".",
+		file:   ".env",
+		parser: ParseDotenv,
+
+		tagNames: defaultTagNames,
+
+		execTimeout: defaultExecTimeout,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// log returns the logger that diagnostics are written to, diagnostics are discarded if no logger is provided.
+func (o *options) log() *slog.Logger {
+	if o.logger == nil {
+		return slog.New(discardHandler{})
+	}
+
+	return o.logger
+}
+
+// configFiles returns the paths to the config files in the order that they should be loaded.
+func (o *options) configFiles() []string {
+	files := []string{o.file}
+	if len(o.files) > 0 {
+		files = o.files
+	}
+	if o.layers {
+		files = layers(o.file, environment())
+	}
+	if o.profile != "" {
+		files = layers(o.file, o.profile)
+	}
+
+	paths := make([]string, len(files))
+	for i, file := range files {
+		if o.fsys != nil {
+			paths[i] = path.Join(o.path, file)
+			continue
+		}
+
+		paths[i] = filepath.Join(o.path, file)
+	}
+
+	return paths
+}
+
+// fileParser returns the parser of the config file in the given path, the signature of the file is verified
+// before it is decrypted when verification is enabled.
+func (o *options) fileParser(file string) Parser {
+	parse := o.parser
+	switch {
+	case strings.HasSuffix(file, ageExt):
+		parse = ageParser(o, o.parser)
+	case strings.HasSuffix(file, gpgExt):
+		parse = gpgParser(o, o.parser)
+	case o.sops:
+		parse = sopsParser(o, file, o.parser)
+	}
+	if o.verify != nil {
+		parse = verifiedParser(o, file, parse)
+	}
+
+	return parse
+}
+
+// layers returns the chain of config files for the given environment derived from the given file,
+// following the dotenv convention (e.g. .env, .env.local, .env.production, .env.production.local).
+func layers(file, environment string) []string {
+	files := []string{file, file + ".local"}
+	if environment != "" {
+		files = append(files, file+"."+environment, file+"."+environment+".local")
+	}
+
+	return files
+}
+
+// environment returns the name of the environment that the application is running in from the APP_ENV
+// or the GO_ENV environment variables.
+func environment() string {
+	if env := os.Getenv("APP_ENV"); env != "" {
+		return env
+	}
+
+	return os.Getenv("GO_ENV")
+}
+
+// WithPath sets the directory that contains the config file, defaults to the current directory.
+func WithPath(path string) Option {
+	return func(o *options) {
+		o.path = path
+	}
+}
+
+// WithFile sets the name of the config file, defaults to .env.
+func WithFile(file string) Option {
+	return func(o *options) {
+		o.file = file
+	}
+}
+
+// WithFS reads the config files from the given file system (e.g. an embed.FS) instead of the disk.
+func WithFS(fsys fs.FS) Option {
+	return func(o *options) {
+		o.fsys = fsys
+	}
+}
+
+// withReader reads the config from the given reader instead of the config files.
+func withReader(r io.Reader) Option {
+	return func(o *options) {
+		o.reader = r
+	}
+}
+
+// withContext sets the context of the fetches of the providers, the resolvers and the commands.
+func withContext(ctx context.Context) Option {
+	return func(o *options) {
+		o.ctx = ctx
+	}
+}
+
+// withSource loads the values of the given provider instead of the config files and the environment variables.
+func withSource(p Provider) Option {
+	return func(o *options) {
+		o.source = p
+	}
+}
+
+// WithFiles sets an ordered chain of config files, values in the later files override the values in the
+// earlier files and the files that do not exist are skipped.
+func WithFiles(files ...string) Option {
+	return func(o *options) {
+		o.files = files
+	}
+}
+
+// WithEnvironmentFiles derives the chain of config files from the config file and the APP_ENV or GO_ENV
+// environment variables (e.g. .env, .env.local, .env.staging, .env.staging.local), it overrides WithFiles.
+func WithEnvironmentFiles() Option {
+	return func(o *options) {
+		o.layers = true
+	}
+}
+
+// WithProfile selects the named profile (e.g. dev, staging or prod), the chain of config files is derived
+// from the config file and the profile (e.g. .env, .env.local, .env.staging, .env.staging.local) and keys
+// prefixed with the upper cased profile name (e.g. STAGING_DATABASE_URL) override their unprefixed keys.
+// It overrides WithFiles and WithEnvironmentFiles.
+func WithProfile(profile string) Option {
+	return func(o *options) {
+		o.profile = profile
+	}
+}
+
+// WithPrefix only reads the environment variables that start with the given prefix (e.g. MYAPP_), which is
+// trimmed from their keys (e.g. MYAPP_PORT is loaded into the field with the PORT key), so that the applications
+// that run on the same host can not clobber each other's variables. The keys of the config files can start with
+// the prefix as well, it is trimmed from them too.
+func WithPrefix(prefix string) Option {
+	return func(o *options) {
+		o.envPrefix = prefix
+	}
+}
+
+// WithPrecedence sets how the values in the config files and the environment variables are merged,
+// defaults to FileOrEnv.
+func WithPrecedence(precedence Precedence) Option {
+	return func(o *options) {
+		o.precedence = precedence
+	}
+}
+
+// WithExpand expands ${VAR} and ${VAR:-fallback} references in all the values, use the expand tag
+// to only expand the values of specific fields.
+func WithExpand() Option {
+	return func(o *options) {
+		o.expand = true
+	}
+}
+
+// WithStrict makes loading fail with an error listing the keys in the config files that do not map to any
+// of the fields of the struct, which catches typos such as DATABSE_URL.
+func WithStrict() Option {
+	return func(o *options) {
+		o.strict = true
+	}
+}
+
+// WithReport fills the given report with a description of how the fields of the struct were filled.
+func WithReport(report *Report) Option {
+	return func(o *options) {
+		o.report = report
+	}
+}
+
+// WithLogger sets the logger that diagnostics are written to, diagnostics are discarded by default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithParser sets the parser that is used to read the config files, defaults to ParseDotenv.
+// Use the envviper package for config files in the other formats that are supported by viper.
+func WithParser(parser Parser) Option {
+	return func(o *options) {
+		o.parser = parser
+	}
+}
+
+// WithExec runs the given command (e.g. doppler secrets download --format env --no-file) and merges the
+// KEY=value pairs in its output, which is parsed as a dotenv file, on top of the loaded values.
+func WithExec(name string, args ...string) Option {
+	return func(o *options) {
+		o.providers = append(o.providers, &execProvider{o: o, name: name, args: args})
+	}
+}
+
+// WithExecAllowlist sets the commands that fields tagged with exec are allowed to run, fields can not
+// run any commands by default.
+func WithExecAllowlist(commands ...string) Option {
+	return func(o *options) {
+		o.execAllowlist = commands
+	}
+}
+
+// WithExecTimeout sets the timeout of the commands that are run to load the values, defaults to 10 seconds.
+func WithExecTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.execTimeout = timeout
+	}
+}
+
+// WithPollInterval makes Watch poll the config files and the providers at the given interval, the config is
+// reloaded when their values change. It is meant for the sources that can not be watched natively (e.g. HTTP,
+// S3 or Secrets Manager).
+func WithPollInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.pollInterval = interval
+	}
+}
+
+// OnReloadError registers a callback that is called with the error of a reload that failed, the current config
+// is kept in that case. The error of values that already failed to load is only reported once.
+func OnReloadError(fn func(error)) Option {
+	return func(o *options) {
+		o.reloadError = fn
+	}
+}
+
+// WithHTTP fetches the config from the given HTTP(S) endpoint and merges it on top of the loaded values.
+func WithHTTP(h *HTTP) Option {
+	return WithProvider(h)
+}
@@ -0,0 +1,240 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestConfigFiles(t *testing.T) {
+	t.Setenv("APP_ENV", "staging")
+
+	tests := []struct {
+		name string
+		opts []Option
+		want []string
+	}{
+		{name: "defaults", want: []string{".env"}},
+		{name: "path", opts: []Option{WithPath("/etc/app")}, want: []string{"/etc/app/.env"}},
+		{name: "file", opts: []Option{WithFile("config.env")}, want: []string{"config.env"}},
+		{
+			name: "path and file",
+			opts: []Option{WithPath("/etc/app"), WithFile("config.env")},
+			want: []string{"/etc/app/config.env"},
+		},
+		{name: "files", opts: []Option{WithFiles(".env", ".env.local")}, want: []string{".env", ".env.local"}},
+		{
+			name: "environment files",
+			opts: []Option{WithFiles("other.env"), WithEnvironmentFiles()},
+			want: []string{".env", ".env.local", ".env.staging", ".env.staging.local"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newOptions(tt.opts...).configFiles(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("configFiles() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadOptions(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("OPTIONS_NAME=default"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "custom.env"), []byte("OPTIONS_NAME=custom"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{name: "path", opts: []Option{WithPath(dir)}, want: "default"},
+		{name: "path and file", opts: []Option{WithPath(dir), WithFile("custom.env")}, want: "custom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg struct {
+				Name string `mapstructure:"OPTIONS_NAME"`
+			}
+			if err := LoadE(&cfg, tt.opts...); err != nil {
+				t.Fatalf("LoadE() error = %v", err)
+			}
+			if cfg.Name != tt.want {
+				t.Errorf("Name = %q, want %q", cfg.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadLayeredFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		".env":                  {Data: []byte("HOST=base\nPORT=8080\nDEBUG=false")},
+		".env.local":            {Data: []byte("HOST=local")},
+		".env.production":       {Data: []byte("DEBUG=true\nPORT=80")},
+		".env.production.local": {Data: []byte("PORT=443")},
+	}
+
+	type config struct {
+		Host  string `mapstructure:"HOST"`
+		Port  int    `mapstructure:"PORT"`
+		Debug bool   `mapstructure:"DEBUG"`
+	}
+
+	tests := []struct {
+		name        string
+		environment string
+		opts        []Option
+		want        config
+	}{
+		{name: "files", opts: []Option{WithFiles(".env", ".env.production", ".env.missing")}, want: config{Host: "base", Port: 80, Debug: true}},
+		{name: "environment files", environment: "production", opts: []Option{WithEnvironmentFiles()}, want: config{Host: "local", Port: 443, Debug: true}},
+		{name: "without environment", opts: []Option{WithEnvironmentFiles()}, want: config{Host: "local", Port: 8080}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("APP_ENV", tt.environment)
+			t.Setenv("GO_ENV", "")
+
+			var cfg config
+			if err := LoadE(&cfg, append([]Option{WithFS(fsys), WithPrecedence(FileOnly)}, tt.opts...)...); err != nil {
+				t.Fatalf("LoadE() error = %v", err)
+			}
+			if cfg != tt.want {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	fsys := fstest.MapFS{
+		".env":         {Data: []byte("HOST=base\nPORT=8080\nSTAGING_HOST=staging-db")},
+		".env.staging": {Data: []byte("PORT=9090\nPROD_PORT=80")},
+	}
+
+	type config struct {
+		Host string `mapstructure:"HOST"`
+		Port int    `mapstructure:"PORT"`
+	}
+
+	tests := []struct {
+		name    string
+		profile string
+		opts    []Option
+		want    config
+		wantErr bool
+	}{
+		{name: "without profile", want: config{Host: "base", Port: 8080}},
+		{name: "profile", profile: "staging", want: config{Host: "staging-db", Port: 9090}},
+		{name: "strict", profile: "staging", opts: []Option{WithStrict()}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []Option{WithFS(fsys), WithPrecedence(FileOnly)}
+			if tt.profile != "" {
+				opts = append(opts, WithProfile(tt.profile))
+			}
+
+			var cfg config
+			err := LoadE(&cfg, append(opts, tt.opts...)...)
+			if tt.wantErr {
+				// the keys of the other profiles are unknown
+				if err == nil || !strings.Contains(err.Error(), "PROD_PORT") || strings.Contains(err.Error(), "STAGING_HOST") {
+					t.Errorf("LoadE() error = %v, want an error for PROD_PORT only", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadE() error = %v", err)
+			}
+			if cfg != tt.want {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	t.Setenv("PRECEDENCE_HOST", "env-host")
+	t.Setenv("PRECEDENCE_DEBUG", "true")
+
+	type config struct {
+		Host  string `mapstructure:"PRECEDENCE_HOST"`
+		Port  int    `mapstructure:"PRECEDENCE_PORT"`
+		Debug bool   `mapstructure:"PRECEDENCE_DEBUG"`
+	}
+
+	fsys := fstest.MapFS{".env": {Data: []byte("PRECEDENCE_HOST=file-host\nPRECEDENCE_PORT=8080")}}
+	tests := []struct {
+		name       string
+		fsys       fstest.MapFS
+		precedence Precedence
+		want       config
+	}{
+		{name: "file or env", fsys: fsys, precedence: FileOrEnv, want: config{Host: "file-host", Port: 8080}},
+		{name: "file or env without file", fsys: fstest.MapFS{}, precedence: FileOrEnv, want: config{Host: "env-host", Debug: true}},
+		{name: "env over file", fsys: fsys, precedence: EnvOverFile, want: config{Host: "env-host", Port: 8080, Debug: true}},
+		{name: "file over env", fsys: fsys, precedence: FileOverEnv, want: config{Host: "file-host", Port: 8080, Debug: true}},
+		{name: "file only", fsys: fsys, precedence: FileOnly, want: config{Host: "file-host", Port: 8080}},
+		{name: "env only", fsys: fsys, precedence: EnvOnly, want: config{Host: "env-host", Debug: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			if err := LoadE(&cfg, WithFS(tt.fsys), WithPrecedence(tt.precedence)); err != nil {
+				t.Fatalf("LoadE() error = %v", err)
+			}
+			if cfg != tt.want {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPrefix(t *testing.T) {
+	t.Setenv("MYAPP_PREFIX_HOST", "prefixed-host")
+	t.Setenv("PREFIX_HOST", "other-host")
+	t.Setenv("PREFIX_PORT", "9090")
+
+	type config struct {
+		Host string `mapstructure:"PREFIX_HOST"`
+		Port int    `mapstructure:"PREFIX_PORT"`
+	}
+
+	tests := []struct {
+		name       string
+		file       string
+		precedence Precedence
+		want       config
+	}{
+		{name: "env only", precedence: EnvOnly, want: config{Host: "prefixed-host"}},
+		{name: "env over file", file: "PREFIX_PORT=8080", precedence: EnvOverFile, want: config{Host: "prefixed-host", Port: 8080}},
+		{name: "prefixed file keys", file: "MYAPP_PREFIX_PORT=8080", precedence: FileOnly, want: config{Port: 8080}},
+		{name: "prefixed file key over unprefixed", file: "MYAPP_PREFIX_PORT=8080\nPREFIX_PORT=80", precedence: FileOnly, want: config{Port: 8080}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			fsys := fstest.MapFS{".env": {Data: []byte(tt.file)}}
+			if err := LoadE(&cfg, WithFS(fsys), WithPrecedence(tt.precedence), WithPrefix("MYAPP_")); err != nil {
+				t.Fatalf("LoadE() error = %v", err)
+			}
+			if cfg != tt.want {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,900 @@
+package env
+
+import (
+	"cmp"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	urlType      = reflect.TypeOf(url.URL{})
+	ipNetType    = reflect.TypeOf(net.IPNet{})
+
+	textUnmarshalerType   = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	binaryUnmarshalerType = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
+)
+
+// parser unmarshals the environment variables in a map into a struct.
+type parser struct {
+	o              *options
+	envMap         map[string]string
+	keys           map[string]bool
+	secrets        map[string]bool
+	secretValues   []string
+	provenance     Provenance
+	accesses       Audit
+	fields         map[string]parsedField
+	prefixes       []string
+	structPrefixes []string
+	filled         []string
+	defaulted      []string
+	preset         []string
+	zero           []string
+	errs           FieldErrors
+	warnings       FieldErrors
+}
+
+// newParser returns a parser for the environment variables in the given map.
+func newParser(envMap map[string]string, o *options) *parser {
+	return &parser{
+		o:          o,
+		envMap:     envMap,
+		keys:       make(map[string]bool),
+		secrets:    make(map[string]bool),
+		fields:     make(map[string]parsedField),
+		provenance: make(Provenance),
+	}
+}
+
+// parse parses the environment variables and unmarshals them into the struct that the given pointer points to.
+// Fields that are not present in the map are set to the value of their default tag if it is present,
+// otherwise an ErrMissing error is recorded for the fields that are tagged as required.
+// The errors of all the fields are returned as FieldErrors.
+func (p *parser) parse(e any) error {
+	v := reflect.ValueOf(e).Elem()
+	p.migrateDeprecated(v.Type(), p.o.keyPrefix, "")
+	p.parseStruct(v, p.o.keyPrefix, "")
+
+	if len(p.errs) > 0 {
+		return p.errs
+	}
+
+	return nil
+}
+
+// unknown returns the sorted keys of the given map that do not map to any of the fields of the parsed struct.
+func (p *parser) unknown(m map[string]string) []string {
+	var keys []string
+	for key := range m {
+		if p.known(key) {
+			continue
+		}
+		if p.o.profile != "" {
+			prefix := strings.ToUpper(p.o.profile) + "_"
+			if strings.HasPrefix(key, prefix) && p.known(strings.TrimPrefix(key, prefix)) {
+				continue
+			}
+		}
+
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// known reports whether the given key maps to a field of the parsed struct.
+func (p *parser) known(key string) bool {
+	if p.keys[key] || p.keys[strings.TrimSuffix(key, "_FILE")] {
+		return true
+	}
+
+	for _, prefix := range p.prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseStruct parses the fields of the given struct, the given prefix is prepended to the key of every field
+// and the given path is prepended to the name of every field.
+// Fields of a struct type are parsed recursively with the value of their prefix tag appended to the prefix.
+// Errors are collected for every field instead of stopping at the first one.
+func (p *parser) parseStruct(objValue reflect.Value, prefix, path string) {
+	objType := objValue.Type()
+	prefix = p.canonical(prefix)
+
+	for i := 0; i < objType.NumField(); i++ {
+		field := objType.Field(i)
+		fieldValue := objValue.Field(i)
+		fieldName := path + field.Name
+		if p.skipped(field) {
+			continue
+		}
+
+		isJSON, err := boolTag(field, "envJSON")
+		if err != nil {
+			p.fail(field, fieldName, "", "", err)
+			continue
+		}
+
+		if isNested(field.Type) && !isJSON {
+			structPrefix := p.nestedPrefix(field)
+			if structPrefix != "" {
+				p.structPrefixes = append(p.structPrefixes, prefix+structPrefix)
+			}
+
+			p.parseStruct(fieldValue, prefix+structPrefix, fieldName+".")
+			continue
+		}
+
+		if mapPrefix, ok := field.Tag.Lookup("prefix"); ok && field.Type.Kind() == reflect.Map {
+			p.parsePrefixedMap(fieldValue, field, fieldName, p.canonical(prefix+mapPrefix))
+			continue
+		}
+
+		key, ok := p.fieldKey(field)
+		if !ok {
+			continue
+		}
+
+		// a field without a key (e.g. an untagged field that is only filled by a resolver or its default) is
+		// not looked up and its key is not recorded
+		var envKey, envValue string
+		ok = false
+		if key != "" {
+			envKey = prefix + key
+			p.keys[envKey] = true
+			if isSecret(field) {
+				p.secrets[envKey] = true
+			}
+
+			envValue, ok, err = p.lookup(envKey)
+			if err != nil {
+				p.fail(field, fieldName, envKey, "", err)
+				continue
+			}
+		}
+		p.fields[fieldName] = parsedField{key: envKey, field: field}
+		aliases := p.aliasKeys(field, prefix)
+		origin := p.origin(envKey)
+		if !ok {
+			var aliasKey string
+			aliasKey, envValue, ok, err = p.lookupAlias(aliases)
+			if err != nil {
+				p.fail(field, fieldName, envKey, "", err)
+				continue
+			}
+			origin = p.origin(aliasKey)
+		}
+		fromEnv := ok
+		if !ok {
+			envValue, ok, err = p.execField(field)
+			if err != nil {
+				p.fail(field, fieldName, envKey, "", err)
+				continue
+			}
+			origin = Origin{Source: "exec", Name: field.Tag.Get("exec")}
+		}
+		if !ok {
+			envValue, ok, err = p.resolveField(field)
+			if err != nil {
+				p.fail(field, fieldName, envKey, "", err)
+				continue
+			}
+			origin = Origin{Source: "resolver", Name: p.resolverTag(field)}
+		}
+		if ok && envValue == "" {
+			notEmpty, err := p.flag(field, "notEmpty")
+			if err != nil {
+				p.fail(field, fieldName, envKey, "", err)
+				continue
+			}
+			if notEmpty {
+				p.fail(field, fieldName, envKey, "", fmt.Errorf("%w: %s is empty", ErrMissing, cmp.Or(envKey, fieldName)))
+				continue
+			}
+		}
+		if !ok {
+			envValue, ok = defaultValue(field)
+			if !ok {
+				required, err := p.flag(field, "required")
+				if err != nil {
+					p.fail(field, fieldName, envKey, "", err)
+					continue
+				}
+				notEmpty, err := p.flag(field, "notEmpty")
+				if err != nil {
+					p.fail(field, fieldName, envKey, "", err)
+					continue
+				}
+				if required || notEmpty {
+					p.fail(field, fieldName, envKey, "", fmt.Errorf("%w: %s", ErrMissing, cmp.Or(envKey, fieldName)))
+				}
+				if fieldValue.IsZero() {
+					p.zero = appendKey(p.zero, envKey)
+				} else {
+					p.preset = appendKey(p.preset, envKey)
+					p.setOrigin(fieldName, Origin{Source: "preset"}, "")
+				}
+
+				continue
+			}
+			if !fieldValue.IsZero() {
+				// the value that the struct was pre-populated with is kept over the default
+				p.preset = appendKey(p.preset, envKey)
+				p.setOrigin(fieldName, Origin{Source: "preset"}, "")
+				continue
+			}
+
+			p.defaulted = appendKey(p.defaulted, envKey)
+			origin = Origin{Source: "default"}
+		} else {
+			p.filled = appendKey(p.filled, envKey)
+		}
+
+		if !fieldValue.CanSet() {
+			p.fail(field, fieldName, envKey, envValue, fmt.Errorf("field %s is not settable", field.Name))
+			continue
+		}
+
+		shouldExpand, err := p.flag(field, "expand")
+		if err != nil {
+			p.fail(field, fieldName, envKey, envValue, err)
+			continue
+		}
+		if p.o.expand || shouldExpand {
+			lookup := lookupEnv(p.o, p.envMap)
+			envValue = expand(envValue, func(key string) (string, bool) {
+				value, ok := lookup(key)
+				p.access(key, ok)
+				return value, ok
+			})
+		}
+
+		reference := envValue
+		envValue, err = p.resolveReference(envValue)
+		if err != nil {
+			p.fail(field, fieldName, envKey, envValue, err)
+			continue
+		}
+		p.setOrigin(fieldName, origin, reference)
+
+		isFile, err := p.flag(field, "file")
+		if err != nil {
+			p.fail(field, fieldName, envKey, envValue, err)
+			continue
+		}
+		if isFile {
+			b, err := readFile(field, envKey, envValue)
+			if err != nil {
+				p.fail(field, fieldName, envKey, envValue, err)
+				continue
+			}
+
+			if fieldValue.Kind() == reflect.Slice && fieldValue.Type().Elem().Kind() == reflect.Uint8 {
+				fieldValue.SetBytes(b)
+				continue
+			}
+
+			envValue = string(b)
+		}
+
+		p.recordSecret(field, envValue)
+		if err := p.setValue(fieldValue, field, envKey, envValue); err != nil {
+			p.fail(field, fieldName, envKey, envValue, err)
+			continue
+		}
+
+		if err := p.check(field, envKey, fieldValue, envValue); err != nil {
+			p.violate(field, fieldName, envKey, envValue, err)
+		}
+
+		unset, err := p.flag(field, "unset")
+		if err != nil {
+			p.fail(field, fieldName, envKey, "", err)
+			continue
+		}
+		if unset && fromEnv {
+			p.unsetenv(field, prefix, envKey)
+		}
+	}
+}
+
+// appendKey appends the given key to the given keys of the report, the fields without a key are not reported.
+func appendKey(keys []string, key string) []string {
+	if key == "" {
+		return keys
+	}
+
+	return append(keys, key)
+}
+
+// fail records the error of the given field.
+func (p *parser) fail(field reflect.StructField, fieldName, envKey, envValue string, err error) {
+	p.recordSecret(field, envValue)
+	p.errs = append(p.errs, FieldError{
+		Key:       envKey,
+		FieldName: fieldName,
+		Value:     redact(field, envValue),
+		Err:       err,
+	})
+}
+
+// violate records the violation of a constraint by the given field as an error, or as a warning that is logged
+// and reported without failing the loading if the field is tagged with severity:"warn".
+func (p *parser) violate(field reflect.StructField, fieldName, envKey, envValue string, err error) {
+	if field.Tag.Get("severity") != "warn" {
+		p.fail(field, fieldName, envKey, envValue, err)
+		return
+	}
+
+	p.recordSecret(field, envValue)
+	p.warnings = append(p.warnings, FieldError{
+		Key:       envKey,
+		FieldName: fieldName,
+		Value:     redact(field, envValue),
+		Err:       err,
+	})
+	p.o.log().Warn("the environment variable violates a constraint", "key", envKey, "field", fieldName, "error", p.redactErr(err))
+}
+
+// aliasKeys returns the keys in the alias tag of the given field (e.g. alias:"REDISCLOUD_URL,REDISTOGO_URL")
+// with the prefix of the field prepended to them. The keys are recorded even if the field is filled from its
+// own key, so that the aliases that are still set are not reported as unknown.
+func (p *parser) aliasKeys(field reflect.StructField, prefix string) []string {
+	tag, ok := field.Tag.Lookup("alias")
+	if !ok {
+		return nil
+	}
+
+	var keys []string
+	for _, alias := range strings.Split(tag, ",") {
+		key := p.canonical(prefix + strings.TrimSpace(alias))
+		p.keys[key] = true
+		if isSecret(field) {
+			p.secrets[key] = true
+		}
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// lookupAlias returns the key and the value of the first of the given alias keys that is present.
+func (p *parser) lookupAlias(keys []string) (string, string, bool, error) {
+	for _, key := range keys {
+		envValue, ok, err := p.lookup(key)
+		if err != nil || ok {
+			return key, envValue, ok, err
+		}
+	}
+
+	return "", "", false, nil
+}
+
+// lookup returns the value of the given key, if the key is not present but the key with a _FILE suffix
+// is present (e.g. DB_PASSWORD_FILE) the contents of the file it points to are returned instead.
+func (p *parser) lookup(envKey string) (string, bool, error) {
+	if envValue, ok := p.envMap[envKey]; ok {
+		p.access(envKey, true)
+		return envValue, true, nil
+	}
+
+	path, ok := p.envMap[envKey+"_FILE"]
+	p.access(envKey, ok)
+	if !ok {
+		return "", false, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read %s from %s_FILE: %v", envKey, envKey, err)
+	}
+
+	envValue := strings.TrimSuffix(string(b), "\n")
+	return strings.TrimSuffix(envValue, "\r"), true, nil
+}
+
+// unsetenv removes the environment variables that the value of the given field can be loaded from (its key, its
+// aliases and their _FILE variants) from the environment of the process, so that child processes and
+// /proc/self/environ no longer expose the value.
+func (p *parser) unsetenv(field reflect.StructField, prefix, envKey string) {
+	var keys []string
+	if envKey != "" {
+		keys = append(keys, envKey)
+	}
+	if tag, ok := field.Tag.Lookup("alias"); ok {
+		for _, alias := range strings.Split(tag, ",") {
+			keys = append(keys, p.canonical(prefix+strings.TrimSpace(alias)))
+		}
+	}
+
+	for name := range environ() {
+		key, ok := strings.CutPrefix(name, p.o.envPrefix)
+		if !ok {
+			continue
+		}
+
+		key = p.canonical(key)
+		for _, k := range keys {
+			if key == k || key == k+"_FILE" {
+				if err := os.Unsetenv(name); err != nil {
+					p.o.log().Warn("failed to unset the environment variable", "key", name, "error", err)
+					break
+				}
+
+				p.o.log().Debug("unset the environment variable", "key", name)
+				break
+			}
+		}
+	}
+}
+
+// defaultMaxFileSize is the maximum size of a file that is read for a field tagged with file.
+const defaultMaxFileSize = 1 << 20
+
+// readFile reads the file in the given path for the given field, the size of the file is limited to
+// defaultMaxFileSize unless a different limit is set with the maxSize tag (e.g. maxSize:"10MiB").
+func readFile(field reflect.StructField, envKey, path string) ([]byte, error) {
+	maxSize := uint64(defaultMaxFileSize)
+	if tag, ok := field.Tag.Lookup("maxSize"); ok {
+		val, err := parseSize(tag)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maxSize tag on field %s: %v", field.Name, err)
+		}
+
+		maxSize = val
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the file for %s: %v", envKey, err)
+	}
+	defer f.Close()
+
+	b, err := io.ReadAll(io.LimitReader(f, int64(maxSize)+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the file for %s: %v", envKey, err)
+	}
+	if uint64(len(b)) > maxSize {
+		return nil, fmt.Errorf("failed to read the file for %s: %s is larger than %d bytes", envKey, path, maxSize)
+	}
+
+	return b, nil
+}
+
+// setValue parses the given value according to the type of the given field and sets it.
+func (p *parser) setValue(fieldValue reflect.Value, field reflect.StructField, envKey, envValue string) error {
+	isJSON, err := boolTag(field, "envJSON")
+	if err != nil {
+		return err
+	}
+	if isJSON {
+		// the value is decoded into a new value instead of the current one, whose maps and pointers can be
+		// shared with a config that is being reloaded
+		v := reflect.New(fieldValue.Type())
+		if err := json.Unmarshal([]byte(envValue), v.Interface()); err != nil {
+			return fmt.Errorf("failed to parse %s as json: %v", envKey, err)
+		}
+
+		fieldValue.Set(v.Elem())
+		return nil
+	}
+
+	if fn, ok := decoderFor(fieldValue.Type()); ok {
+		return decode(fieldValue, fn, envKey, envValue)
+	}
+
+	if inner := unwrapSecret(fieldValue); inner != fieldValue {
+		return p.setValue(inner, field, envKey, envValue)
+	}
+
+	if fieldValue.Kind() == reflect.Pointer {
+		ptr := reflect.New(fieldValue.Type().Elem())
+		if err := p.setValue(ptr.Elem(), field, envKey, envValue); err != nil {
+			return err
+		}
+
+		fieldValue.Set(ptr)
+		return nil
+	}
+
+	switch fieldValue.Type() {
+	case durationType:
+		val, err := time.ParseDuration(envValue)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s as time.Duration: %v", envKey, err)
+		}
+
+		fieldValue.SetInt(int64(val))
+		return nil
+	case timeType:
+		val, err := parseTime(field.Tag.Get("format"), envValue)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s as time.Time: %v", envKey, err)
+		}
+
+		fieldValue.Set(reflect.ValueOf(val))
+		return nil
+	case urlType:
+		val, err := url.Parse(envValue)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s as url.URL: %v", envKey, err)
+		}
+		if err := checkScheme(field, envKey, val); err != nil {
+			return err
+		}
+
+		fieldValue.Set(reflect.ValueOf(*val))
+		return nil
+	case ipNetType:
+		_, val, err := net.ParseCIDR(envValue)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s as net.IPNet: %v", envKey, err)
+		}
+
+		fieldValue.Set(reflect.ValueOf(*val))
+		return nil
+	}
+
+	if fieldValue.CanAddr() {
+		switch u := fieldValue.Addr().Interface().(type) {
+		case encoding.TextUnmarshaler:
+			if err := u.UnmarshalText([]byte(envValue)); err != nil {
+				return fmt.Errorf("failed to parse %s as %s: %v", envKey, fieldValue.Type(), err)
+			}
+
+			return nil
+		case encoding.BinaryUnmarshaler:
+			if err := u.UnmarshalBinary([]byte(envValue)); err != nil {
+				return fmt.Errorf("failed to parse %s as %s: %v", envKey, fieldValue.Type(), err)
+			}
+
+			return nil
+		}
+	}
+
+	switch fieldValue.Kind() {
+	case reflect.String:
+		fieldValue.SetString(envValue)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if isSize(field) {
+			return setSize(fieldValue, envKey, envValue)
+		}
+
+		val, err := strconv.ParseInt(envValue, 10, fieldValue.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("failed to parse %s as int: %v", envKey, err)
+		}
+
+		fieldValue.SetInt(val)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if isSize(field) {
+			return setSize(fieldValue, envKey, envValue)
+		}
+
+		val, err := strconv.ParseUint(envValue, 10, fieldValue.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("failed to parse %s as uint: %v", envKey, err)
+		}
+
+		fieldValue.SetUint(val)
+	case reflect.Float32, reflect.Float64:
+		val, err := strconv.ParseFloat(envValue, fieldValue.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("failed to parse %s as float: %v", envKey, err)
+		}
+
+		fieldValue.SetFloat(val)
+	case reflect.Bool:
+		val, err := strconv.ParseBool(envValue)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s as bool: %v", envKey, err)
+		}
+
+		fieldValue.SetBool(val)
+	case reflect.Slice:
+		if fieldValue.Type().Elem().Kind() == reflect.Uint8 {
+			return setBytes(fieldValue, field, envKey, envValue)
+		}
+
+		return p.setSlice(fieldValue, field, envKey, envValue)
+	case reflect.Map:
+		return p.setMap(fieldValue, field, envKey, envValue)
+	default:
+		return fmt.Errorf("unsupported type for field %s", field.Name)
+	}
+
+	return nil
+}
+
+// setSlice splits the given value with the separator of the given field and sets each of the items.
+// The separator defaults to a comma and can be overridden with the envSeparator tag.
+func (p *parser) setSlice(fieldValue reflect.Value, field reflect.StructField, envKey, envValue string) error {
+	separator, ok := field.Tag.Lookup("envSeparator")
+	if !ok {
+		separator = ","
+	}
+
+	var items []string
+	if envValue != "" {
+		items = strings.Split(envValue, separator)
+	}
+
+	slice := reflect.MakeSlice(fieldValue.Type(), len(items), len(items))
+	for i, item := range items {
+		if err := p.setValue(slice.Index(i), field, fmt.Sprintf("%s[%d]", envKey, i), strings.TrimSpace(item)); err != nil {
+			return err
+		}
+	}
+
+	fieldValue.Set(slice)
+	return nil
+}
+
+// setMap parses the given value as a list of key value pairs (e.g. key=val,key2=val2) and sets them.
+// The separator between the pairs can be overridden with the envSeparator tag and the separator
+// between a key and its value can be overridden with the envKeyValSeparator tag.
+func (p *parser) setMap(fieldValue reflect.Value, field reflect.StructField, envKey, envValue string) error {
+	separator, ok := field.Tag.Lookup("envSeparator")
+	if !ok {
+		separator = ","
+	}
+	keyValSeparator, ok := field.Tag.Lookup("envKeyValSeparator")
+	if !ok {
+		keyValSeparator = "="
+	}
+
+	m := reflect.MakeMap(fieldValue.Type())
+	if envValue != "" {
+		for _, pair := range strings.Split(envValue, separator) {
+			key, value, ok := strings.Cut(pair, keyValSeparator)
+			if !ok {
+				return fmt.Errorf("failed to parse %s as map: %q is not a key value pair", envKey, pair)
+			}
+
+			if err := p.setMapIndex(m, field, envKey, strings.TrimSpace(key), strings.TrimSpace(value)); err != nil {
+				return err
+			}
+		}
+	}
+
+	fieldValue.Set(m)
+	return nil
+}
+
+// parsePrefixedMap collects all the environment variables that start with the given prefix into the given map,
+// the prefix is trimmed from the environment variable to get the key in the map (e.g. FEATURE_X becomes X).
+func (p *parser) parsePrefixedMap(fieldValue reflect.Value, field reflect.StructField, fieldName, prefix string) {
+	p.prefixes = append(p.prefixes, prefix)
+	p.fields[fieldName] = parsedField{key: prefix + "*", field: field}
+
+	keys := make([]string, 0)
+	for key := range p.envMap {
+		if strings.HasPrefix(key, prefix) && len(key) > len(prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	p.access(prefix+"*", len(keys) > 0)
+
+	if len(keys) == 0 {
+		required, err := p.flag(field, "required")
+		if err != nil {
+			p.fail(field, fieldName, prefix+"*", "", err)
+			return
+		}
+		if required {
+			p.fail(field, fieldName, prefix+"*", "", fmt.Errorf("%w: %s", ErrMissing, prefix+"*"))
+		}
+		if fieldValue.IsZero() {
+			p.zero = append(p.zero, prefix+"*")
+		}
+
+		return
+	}
+	p.filled = append(p.filled, prefix+"*")
+
+	if !fieldValue.CanSet() {
+		p.fail(field, fieldName, prefix+"*", "", fmt.Errorf("field %s is not settable", field.Name))
+		return
+	}
+
+	// the entries of a pre-populated map are kept unless they are overridden, the map is copied as it can
+	// be shared with other values
+	m := reflect.MakeMap(fieldValue.Type())
+	for iter := fieldValue.MapRange(); iter.Next(); {
+		m.SetMapIndex(iter.Key(), iter.Value())
+	}
+	for _, key := range keys {
+		if err := p.setMapIndex(m, field, key, strings.TrimPrefix(key, prefix), p.envMap[key]); err != nil {
+			p.fail(field, fieldName, key, p.envMap[key], err)
+			return
+		}
+		p.setOrigin(fmt.Sprintf("%s[%s]", fieldName, strings.TrimPrefix(key, prefix)), p.origin(key), p.envMap[key])
+	}
+
+	fieldValue.Set(m)
+}
+
+// setMapIndex parses the given key and value according to the key and element types of the given map and sets them.
+func (p *parser) setMapIndex(m reflect.Value, field reflect.StructField, envKey, key, value string) error {
+	k := reflect.New(m.Type().Key()).Elem()
+	if err := p.setValue(k, field, envKey, key); err != nil {
+		return err
+	}
+
+	v := reflect.New(m.Type().Elem()).Elem()
+	if err := p.setValue(v, field, envKey, value); err != nil {
+		return err
+	}
+
+	m.SetMapIndex(k, v)
+	return nil
+}
+
+// encodings maps the values of the base64 tag to their encodings.
+var encodings = map[string]*base64.Encoding{
+	"":       base64.StdEncoding,
+	"std":    base64.StdEncoding,
+	"url":    base64.URLEncoding,
+	"rawstd": base64.RawStdEncoding,
+	"rawurl": base64.RawURLEncoding,
+}
+
+// setBytes decodes the given base64 value and sets it to the given byte slice field.
+// The encoding defaults to the standard encoding and can be changed with the base64 tag.
+func setBytes(fieldValue reflect.Value, field reflect.StructField, envKey, envValue string) error {
+	tag := field.Tag.Get("base64")
+	encoding, ok := encodings[strings.ToLower(tag)]
+	if !ok {
+		return fmt.Errorf("invalid base64 tag %q on field %s, expected one of std, url, rawstd or rawurl", tag, field.Name)
+	}
+
+	val, err := encoding.DecodeString(envValue)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s as base64: %v", envKey, err)
+	}
+
+	fieldValue.SetBytes(val)
+	return nil
+}
+
+// layouts maps the names that can be used in the format tag to their time layouts.
+var layouts = map[string]string{
+	"rfc3339":     time.RFC3339,
+	"rfc3339nano": time.RFC3339Nano,
+	"rfc1123":     time.RFC1123,
+	"rfc1123z":    time.RFC1123Z,
+	"rfc822":      time.RFC822,
+	"rfc822z":     time.RFC822Z,
+	"datetime":    time.DateTime,
+	"dateonly":    time.DateOnly,
+	"timeonly":    time.TimeOnly,
+	"kitchen":     time.Kitchen,
+}
+
+// parseTime parses the given value with the given format, the format is either a time layout, one of the
+// named layouts or one of unix, unixmilli, unixmicro and unixnano for epoch timestamps. Defaults to RFC3339.
+func parseTime(format, value string) (time.Time, error) {
+	switch strings.ToLower(format) {
+	case "unix", "unixmilli", "unixmicro", "unixnano":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		switch strings.ToLower(format) {
+		case "unix":
+			return time.Unix(n, 0), nil
+		case "unixmilli":
+			return time.UnixMilli(n), nil
+		case "unixmicro":
+			return time.UnixMicro(n), nil
+		default:
+			return time.Unix(0, n), nil
+		}
+	case "":
+		return time.Parse(time.RFC3339, value)
+	}
+
+	if layout, ok := layouts[strings.ToLower(format)]; ok {
+		return time.Parse(layout, value)
+	}
+
+	return time.Parse(format, value)
+}
+
+// setSize parses the given value as a human readable byte size and sets it to the given integer field.
+func setSize(fieldValue reflect.Value, envKey, envValue string) error {
+	val, err := parseSize(envValue)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s as bytes: %v", envKey, err)
+	}
+
+	if fieldValue.CanInt() {
+		if val > math.MaxInt64 || fieldValue.OverflowInt(int64(val)) {
+			return fmt.Errorf("failed to parse %s as bytes: %s overflows %s", envKey, envValue, fieldValue.Type())
+		}
+
+		fieldValue.SetInt(int64(val))
+		return nil
+	}
+
+	if fieldValue.OverflowUint(val) {
+		return fmt.Errorf("failed to parse %s as bytes: %s overflows %s", envKey, envValue, fieldValue.Type())
+	}
+
+	fieldValue.SetUint(val)
+	return nil
+}
+
+// isSize reports whether the given field is tagged to be parsed as a human readable byte size.
+func isSize(field reflect.StructField) bool {
+	return field.Tag.Get("unit") == "bytes"
+}
+
+// checkScheme checks whether the scheme of the given url is one of the schemes in the schemes tag of the given field.
+func checkScheme(field reflect.StructField, envKey string, u *url.URL) error {
+	tag, ok := field.Tag.Lookup("schemes")
+	if !ok {
+		return nil
+	}
+
+	schemes := strings.Split(tag, ",")
+	for i, scheme := range schemes {
+		schemes[i] = strings.TrimSpace(scheme)
+		if strings.EqualFold(schemes[i], u.Scheme) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid scheme %q for %s, expected one of %s", u.Scheme, envKey, strings.Join(schemes, ", "))
+}
+
+// isNested reports whether the given type is a struct whose fields should be parsed recursively.
+// Structs that are parsed from a single value such as time.Time, url.URL and net.IPNet, the ones with a registered decoder
+// or the ones that implement encoding.TextUnmarshaler or encoding.BinaryUnmarshaler are not nested.
+func isNested(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t == timeType || t == urlType || t == ipNetType {
+		return false
+	}
+	if _, ok := decoderFor(t); ok {
+		return false
+	}
+
+	pt := reflect.PointerTo(t)
+	return !pt.Implements(textUnmarshalerType) && !pt.Implements(binaryUnmarshalerType) && !pt.Implements(secretType)
+}
+
+// boolTag parses the tag with the given name of the given field as a bool, false is returned if the tag is not present.
+func boolTag(field reflect.StructField, name string) (bool, error) {
+	tag, ok := field.Tag.Lookup(name)
+	if !ok {
+		return false, nil
+	}
+
+	val, err := strconv.ParseBool(tag)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s tag on field %s: %v", name, field.Name, err)
+	}
+
+	return val, nil
+}
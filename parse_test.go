@@ -0,0 +1,1000 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadKeepsPresetValues(t *testing.T) {
+	type config struct {
+		Host   string            `mapstructure:"HOST"`
+		Port   int               `mapstructure:"PORT" default:"8080"`
+		Debug  bool              `mapstructure:"DEBUG"`
+		Labels map[string]string `prefix:"LABEL_"`
+	}
+
+	preset := func() config {
+		return config{Host: "0.0.0.0", Port: 3000, Labels: map[string]string{"team": "core", "tier": "web"}}
+	}
+
+	tests := []struct {
+		name       string
+		src        string
+		want       config
+		wantPreset []string
+	}{
+		{
+			name:       "absent keys",
+			src:        "",
+			want:       preset(),
+			wantPreset: []string{"HOST", "PORT"},
+		},
+		{
+			name:       "present keys",
+			src:        "HOST=localhost\nPORT=9000\nDEBUG=true",
+			want:       config{Host: "localhost", Port: 9000, Debug: true, Labels: map[string]string{"team": "core", "tier": "web"}},
+			wantPreset: nil,
+		},
+		{
+			name:       "empty value",
+			src:        "HOST=",
+			want:       config{Host: "", Port: 3000, Labels: map[string]string{"team": "core", "tier": "web"}},
+			wantPreset: []string{"PORT"},
+		},
+		{
+			name:       "overridden map entries",
+			src:        "LABEL_tier=db\nLABEL_zone=a",
+			want:       config{Host: "0.0.0.0", Port: 3000, Labels: map[string]string{"team": "core", "tier": "db", "zone": "a"}},
+			wantPreset: []string{"HOST", "PORT"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := preset()
+			var report Report
+			if err := LoadReader(strings.NewReader(tt.src), &cfg, WithPrecedence(FileOnly), WithReport(&report)); err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if !reflect.DeepEqual(cfg, tt.want) {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+			if !reflect.DeepEqual(report.Preset, tt.wantPreset) {
+				t.Errorf("Report.Preset = %q, want %q", report.Preset, tt.wantPreset)
+			}
+		})
+	}
+
+	t.Run("shared map", func(t *testing.T) {
+		labels := map[string]string{"team": "core"}
+		cfg := config{Labels: labels}
+		if err := LoadReader(strings.NewReader("LABEL_team=infra"), &cfg, WithPrecedence(FileOnly)); err != nil {
+			t.Fatalf("LoadReader() error = %v", err)
+		}
+		if labels["team"] != "core" {
+			t.Errorf("the pre-populated map was modified: %q", labels)
+		}
+	})
+}
+
+func TestLoadDefaults(t *testing.T) {
+	type config struct {
+		Host    string        `mapstructure:"HOST" default:"localhost"`
+		Port    int           `mapstructure:"PORT" default:"8080"`
+		Debug   bool          `mapstructure:"DEBUG" default:"true"`
+		Timeout time.Duration `mapstructure:"TIMEOUT" default:"5s"`
+	}
+
+	tests := []struct {
+		name string
+		src  string
+		want config
+	}{
+		{name: "absent keys", src: "", want: config{Host: "localhost", Port: 8080, Debug: true, Timeout: 5 * time.Second}},
+		{name: "present keys", src: "HOST=db\nPORT=5432\nDEBUG=false\nTIMEOUT=1m", want: config{Host: "db", Port: 5432, Timeout: time.Minute}},
+		{name: "empty value", src: "HOST=", want: config{Port: 8080, Debug: true, Timeout: 5 * time.Second}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			if err := LoadReader(strings.NewReader(tt.src), &cfg, WithPrecedence(FileOnly)); err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if cfg != tt.want {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+
+	t.Run("invalid default", func(t *testing.T) {
+		var cfg struct {
+			Port int `mapstructure:"PORT" default:"http"`
+		}
+		err := LoadReader(strings.NewReader(""), &cfg, WithPrecedence(FileOnly))
+		if err == nil || !strings.Contains(err.Error(), "PORT") {
+			t.Errorf("LoadReader() error = %v, want an error for PORT", err)
+		}
+	})
+}
+
+func TestLoadRequired(t *testing.T) {
+	type config struct {
+		Host string `mapstructure:"HOST" required:"true"`
+		Port int    `mapstructure:"PORT" required:"true" default:"8080"`
+		Name string `mapstructure:"NAME" required:"false"`
+	}
+
+	tests := []struct {
+		name    string
+		src     string
+		want    config
+		wantErr bool
+	}{
+		{name: "present", src: "HOST=db", want: config{Host: "db", Port: 8080}},
+		{name: "empty value", src: "HOST=", want: config{Port: 8080}},
+		{name: "missing", src: "NAME=app", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := LoadReader(strings.NewReader(tt.src), &cfg, WithPrecedence(FileOnly))
+			if tt.wantErr {
+				if !errors.Is(err, ErrMissing) || !strings.Contains(err.Error(), "HOST") {
+					t.Errorf("LoadReader() error = %v, want ErrMissing for HOST", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if cfg != tt.want {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+
+	t.Run("invalid tag", func(t *testing.T) {
+		var cfg struct {
+			Host string `mapstructure:"HOST" required:"yes"`
+		}
+		if err := LoadReader(strings.NewReader(""), &cfg, WithPrecedence(FileOnly)); err == nil {
+			t.Error("LoadReader() error = nil, want an error for the invalid required tag")
+		}
+	})
+}
+
+func TestLoadNestedStructs(t *testing.T) {
+	type database struct {
+		Host string `mapstructure:"HOST"`
+		Port int    `mapstructure:"PORT" default:"5432"`
+	}
+	type config struct {
+		Name     string   `mapstructure:"NAME"`
+		Primary  database `prefix:"DB_"`
+		Replica  database `prefix:"REPLICA_"`
+		Embedded struct {
+			Debug bool `mapstructure:"DEBUG"`
+		}
+	}
+
+	tests := []struct {
+		name string
+		src  string
+		want config
+	}{
+		{
+			name: "prefixed keys",
+			src:  "NAME=app\nDB_HOST=primary\nDB_PORT=6432\nREPLICA_HOST=replica\nDEBUG=true",
+			want: config{
+				Name:    "app",
+				Primary: database{Host: "primary", Port: 6432},
+				Replica: database{Host: "replica", Port: 5432},
+				Embedded: struct {
+					Debug bool `mapstructure:"DEBUG"`
+				}{Debug: true},
+			},
+		},
+		{
+			name: "unprefixed keys",
+			src:  "HOST=other",
+			want: config{Primary: database{Port: 5432}, Replica: database{Port: 5432}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			if err := LoadReader(strings.NewReader(tt.src), &cfg, WithPrecedence(FileOnly)); err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if cfg != tt.want {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+
+	t.Run("field name", func(t *testing.T) {
+		var cfg struct {
+			DB database `prefix:"DB_"`
+		}
+		err := LoadReader(strings.NewReader("DB_PORT=port"), &cfg, WithPrecedence(FileOnly))
+
+		var errs FieldErrors
+		if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Key != "DB_PORT" || errs[0].FieldName != "DB.Port" {
+			t.Errorf("LoadReader() error = %#v, want the error of DB_PORT for DB.Port", err)
+		}
+	})
+}
+
+func TestLoadSlices(t *testing.T) {
+	type config struct {
+		Hosts   []string        `mapstructure:"HOSTS"`
+		Ports   []int           `mapstructure:"PORTS" envSeparator:";"`
+		Timeout []time.Duration `mapstructure:"TIMEOUTS"`
+	}
+
+	tests := []struct {
+		name    string
+		src     string
+		want    config
+		wantErr string
+	}{
+		{name: "comma separated", src: "HOSTS=a, b ,c", want: config{Hosts: []string{"a", "b", "c"}}},
+		{name: "custom separator", src: "PORTS=80;443", want: config{Ports: []int{80, 443}}},
+		{name: "durations", src: "TIMEOUTS=1s,2m", want: config{Timeout: []time.Duration{time.Second, 2 * time.Minute}}},
+		{name: "empty value", src: "HOSTS=", want: config{Hosts: []string{}}},
+		{name: "invalid item", src: "PORTS=80;http", wantErr: "PORTS[1]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := LoadReader(strings.NewReader(tt.src), &cfg, WithPrecedence(FileOnly))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadReader() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if !reflect.DeepEqual(cfg, tt.want) {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMaps(t *testing.T) {
+	type config struct {
+		Features map[string]bool   `prefix:"FEATURE_"`
+		Limits   map[string]int    `mapstructure:"LIMITS"`
+		Headers  map[string]string `mapstructure:"HEADERS" envSeparator:";" envKeyValSeparator:":"`
+	}
+
+	tests := []struct {
+		name    string
+		src     string
+		want    config
+		wantErr string
+	}{
+		{
+			name: "prefixed keys",
+			src:  "FEATURE_SEARCH=true\nFEATURE_BETA=false\nFEATURE_=true",
+			want: config{Features: map[string]bool{"SEARCH": true, "BETA": false}},
+		},
+		{name: "pairs", src: "LIMITS=read=10, write=5", want: config{Limits: map[string]int{"read": 10, "write": 5}}},
+		{
+			name: "custom separators",
+			src:  "HEADERS=Accept:json;X-Team:core",
+			want: config{Headers: map[string]string{"Accept": "json", "X-Team": "core"}},
+		},
+		{name: "invalid pair", src: "LIMITS=read", wantErr: "not a key value pair"},
+		{name: "invalid value", src: "FEATURE_SEARCH=maybe", wantErr: "FEATURE_SEARCH"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := LoadReader(strings.NewReader(tt.src), &cfg, WithPrecedence(FileOnly))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadReader() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if !reflect.DeepEqual(cfg, tt.want) {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+
+	t.Run("required prefix", func(t *testing.T) {
+		var cfg struct {
+			Features map[string]bool `prefix:"FEATURE_" required:"true"`
+		}
+		err := LoadReader(strings.NewReader(""), &cfg, WithPrecedence(FileOnly))
+		if !errors.Is(err, ErrMissing) || !strings.Contains(err.Error(), "FEATURE_*") {
+			t.Errorf("LoadReader() error = %v, want ErrMissing for FEATURE_*", err)
+		}
+	})
+}
+
+func TestLoadDurations(t *testing.T) {
+	type config struct {
+		Timeout time.Duration  `mapstructure:"TIMEOUT"`
+		Backoff *time.Duration `mapstructure:"BACKOFF"`
+	}
+
+	backoff := 1500 * time.Millisecond
+	tests := []struct {
+		name    string
+		src     string
+		want    config
+		wantErr bool
+	}{
+		{name: "seconds", src: "TIMEOUT=30s", want: config{Timeout: 30 * time.Second}},
+		{name: "compound", src: "TIMEOUT=1h30m", want: config{Timeout: 90 * time.Minute}},
+		{name: "pointer", src: "BACKOFF=1.5s", want: config{Backoff: &backoff}},
+		{name: "without unit", src: "TIMEOUT=30", wantErr: true},
+		{name: "invalid", src: "TIMEOUT=soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := LoadReader(strings.NewReader(tt.src), &cfg, WithPrecedence(FileOnly))
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "time.Duration") {
+					t.Errorf("LoadReader() error = %v, want a time.Duration error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if !reflect.DeepEqual(cfg, tt.want) {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadUnsignedIntegers(t *testing.T) {
+	type config struct {
+		Workers uint   `mapstructure:"WORKERS"`
+		Level   uint8  `mapstructure:"LEVEL"`
+		Port    uint16 `mapstructure:"PORT"`
+		Limit   uint64 `mapstructure:"LIMIT"`
+	}
+
+	tests := []struct {
+		name    string
+		src     string
+		want    config
+		wantErr string
+	}{
+		{
+			name: "valid",
+			src:  "WORKERS=8\nLEVEL=255\nPORT=65535\nLIMIT=18446744073709551615",
+			want: config{Workers: 8, Level: 255, Port: 65535, Limit: 18446744073709551615},
+		},
+		{name: "negative", src: "WORKERS=-1", wantErr: "WORKERS"},
+		{name: "overflow", src: "LEVEL=256", wantErr: "LEVEL"},
+		{name: "port overflow", src: "PORT=65536", wantErr: "PORT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := LoadReader(strings.NewReader(tt.src), &cfg, WithPrecedence(FileOnly))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadReader() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if cfg != tt.want {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
+
+// version is a struct that is parsed with encoding.BinaryUnmarshaler.
+type version struct {
+	Major, Minor int
+}
+
+// UnmarshalBinary parses the version from MAJOR.MINOR.
+func (v *version) UnmarshalBinary(b []byte) error {
+	_, err := fmt.Sscanf(string(b), "%d.%d", &v.Major, &v.Minor)
+	return err
+}
+
+func TestLoadUnmarshalers(t *testing.T) {
+	type config struct {
+		Level   slog.Level   `mapstructure:"LEVEL"`
+		Levels  []slog.Level `mapstructure:"LEVELS"`
+		Version version      `mapstructure:"VERSION"`
+	}
+
+	tests := []struct {
+		name    string
+		src     string
+		want    config
+		wantErr string
+	}{
+		{name: "text", src: "LEVEL=warn", want: config{Level: slog.LevelWarn}},
+		{name: "text items", src: "LEVELS=debug,error", want: config{Levels: []slog.Level{slog.LevelDebug, slog.LevelError}}},
+		{name: "binary", src: "VERSION=1.22", want: config{Version: version{Major: 1, Minor: 22}}},
+		{name: "invalid text", src: "LEVEL=loud", wantErr: "failed to parse LEVEL as slog.Level"},
+		{name: "invalid binary", src: "VERSION=latest", wantErr: "failed to parse VERSION as env.version"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := LoadReader(strings.NewReader(tt.src), &cfg, WithPrecedence(FileOnly))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadReader() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if !reflect.DeepEqual(cfg, tt.want) {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPointers(t *testing.T) {
+	type config struct {
+		Port    *int    `mapstructure:"PORT"`
+		Host    *string `mapstructure:"HOST" default:"localhost"`
+		Debug   *bool   `mapstructure:"DEBUG"`
+		Timeout **int   `mapstructure:"TIMEOUT"`
+	}
+
+	port, host, debug := 8080, "localhost", false
+	timeout := 30
+	timeoutPtr := &timeout
+
+	tests := []struct {
+		name    string
+		src     string
+		want    config
+		wantErr bool
+	}{
+		{name: "absent keys", src: "", want: config{Host: &host}},
+		{name: "present keys", src: "PORT=8080\nDEBUG=false", want: config{Port: &port, Host: &host, Debug: &debug}},
+		{name: "pointer to pointer", src: "TIMEOUT=30", want: config{Host: &host, Timeout: &timeoutPtr}},
+		{name: "invalid", src: "PORT=http", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := LoadReader(strings.NewReader(tt.src), &cfg, WithPrecedence(FileOnly))
+			if tt.wantErr {
+				if err == nil {
+					t.Error("LoadReader() error = nil, want an error")
+				}
+				if cfg.Port != nil {
+					t.Errorf("Port = %v, want nil", *cfg.Port)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if !reflect.DeepEqual(cfg, tt.want) {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadURLs(t *testing.T) {
+	type config struct {
+		Endpoint url.URL  `mapstructure:"ENDPOINT"`
+		Database *url.URL `mapstructure:"DATABASE" schemes:"postgres, postgresql"`
+	}
+
+	tests := []struct {
+		name    string
+		src     string
+		want    string
+		wantErr string
+	}{
+		{name: "url", src: "ENDPOINT=https://api.example.com/v1?debug=true", want: "https://api.example.com/v1?debug=true"},
+		{name: "allowed scheme", src: "DATABASE=postgres://db:5432/app", want: "postgres://db:5432/app"},
+		{name: "allowed scheme in another case", src: "DATABASE=PostgreSQL://db/app", want: "postgresql://db/app"},
+		{name: "disallowed scheme", src: "DATABASE=mysql://db/app", wantErr: `invalid scheme "mysql" for DATABASE, expected one of postgres, postgresql`},
+		{name: "invalid", src: "ENDPOINT=http://[::1", wantErr: "failed to parse ENDPOINT as url.URL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := LoadReader(strings.NewReader(tt.src), &cfg, WithPrecedence(FileOnly))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadReader() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+
+			got := cfg.Endpoint.String()
+			if cfg.Database != nil {
+				got = cfg.Database.String()
+			}
+			if got != tt.want {
+				t.Errorf("URL = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadIPs(t *testing.T) {
+	type config struct {
+		Bind    net.IP         `mapstructure:"BIND"`
+		Addr    netip.Addr     `mapstructure:"ADDR"`
+		Listen  netip.AddrPort `mapstructure:"LISTEN"`
+		Network net.IPNet      `mapstructure:"NETWORK"`
+		Prefix  netip.Prefix   `mapstructure:"PREFIX"`
+		Allowed []netip.Prefix `mapstructure:"ALLOWED"`
+	}
+
+	tests := []struct {
+		name    string
+		src     string
+		want    config
+		wantErr string
+	}{
+		{name: "ipv4", src: "BIND=0.0.0.0", want: config{Bind: net.ParseIP("0.0.0.0")}},
+		{name: "ipv6", src: "ADDR=::1", want: config{Addr: netip.IPv6Loopback()}},
+		{name: "address and port", src: "LISTEN=127.0.0.1:8080", want: config{Listen: netip.MustParseAddrPort("127.0.0.1:8080")}},
+		{
+			name: "cidr",
+			src:  "NETWORK=10.1.2.3/8\nPREFIX=192.168.0.0/16",
+			want: config{
+				Network: net.IPNet{IP: net.IPv4(10, 0, 0, 0).To4(), Mask: net.CIDRMask(8, 32)},
+				Prefix:  netip.MustParsePrefix("192.168.0.0/16"),
+			},
+		},
+		{
+			name: "cidrs",
+			src:  "ALLOWED=10.0.0.0/8,fd00::/8",
+			want: config{Allowed: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("fd00::/8")}},
+		},
+		{name: "invalid ip", src: "ADDR=localhost", wantErr: "failed to parse ADDR"},
+		{name: "invalid cidr", src: "NETWORK=10.0.0.0", wantErr: "failed to parse NETWORK as net.IPNet"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := LoadReader(strings.NewReader(tt.src), &cfg, WithPrecedence(FileOnly))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadReader() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if !reflect.DeepEqual(cfg, tt.want) {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		format  string
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{format: "", value: "2024-03-01T12:30:00Z", want: time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)},
+		{format: "RFC3339", value: "2024-03-01T12:30:00Z", want: time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)},
+		{format: "dateonly", value: "2024-03-01", want: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{format: "datetime", value: "2024-03-01 12:30:00", want: time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)},
+		{format: "02/01/2006", value: "01/03/2024", want: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{format: "unix", value: "1709296200", want: time.Unix(1709296200, 0)},
+		{format: "unixmilli", value: "1709296200123", want: time.UnixMilli(1709296200123)},
+		{format: "unixmicro", value: "1709296200123456", want: time.UnixMicro(1709296200123456)},
+		{format: "unixnano", value: "1709296200123456789", want: time.Unix(0, 1709296200123456789)},
+		{format: "unix", value: "yesterday", wantErr: true},
+		{format: "dateonly", value: "2024-03-01T12:30:00Z", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format+" "+tt.value, func(t *testing.T) {
+			got, err := parseTime(tt.format, tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseTime() = %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseTime() error = %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("format tag", func(t *testing.T) {
+		var cfg struct {
+			Launch time.Time `mapstructure:"LAUNCH" format:"dateonly"`
+		}
+		if err := LoadReader(strings.NewReader("LAUNCH=2024-03-01"), &cfg, WithPrecedence(FileOnly)); err != nil {
+			t.Fatalf("LoadReader() error = %v", err)
+		}
+		if want := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC); !cfg.Launch.Equal(want) {
+			t.Errorf("Launch = %v, want %v", cfg.Launch, want)
+		}
+	})
+}
+
+func TestLoadBase64(t *testing.T) {
+	type config struct {
+		Key    []byte `mapstructure:"KEY"`
+		URLKey []byte `mapstructure:"URL_KEY" base64:"url"`
+		RawKey []byte `mapstructure:"RAW_KEY" base64:"RawURL"`
+		Hex    []byte `mapstructure:"HEX" base64:"hex"`
+	}
+
+	tests := []struct {
+		name    string
+		src     string
+		want    config
+		wantErr string
+	}{
+		{name: "standard", src: "KEY=aGVsbG8/Pz8=", want: config{Key: []byte("hello???")}},
+		{name: "url", src: "URL_KEY=aGVsbG8_Pz8=", want: config{URLKey: []byte("hello???")}},
+		{name: "raw url", src: "RAW_KEY=aGVsbG8_Pz8", want: config{RawKey: []byte("hello???")}},
+		{name: "invalid value", src: "KEY=not base64", wantErr: "failed to parse KEY as base64"},
+		{name: "invalid tag", src: "HEX=00ff", wantErr: `invalid base64 tag "hex"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := LoadReader(strings.NewReader(tt.src), &cfg, WithPrecedence(FileOnly))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadReader() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if !reflect.DeepEqual(cfg, tt.want) {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadJSON(t *testing.T) {
+	type options struct {
+		Retries int      `json:"retries"`
+		Hosts   []string `json:"hosts"`
+	}
+	type config struct {
+		Options options           `mapstructure:"OPTIONS" envJSON:"true"`
+		Labels  map[string]string `mapstructure:"LABELS" envJSON:"true"`
+		Limits  *options          `mapstructure:"LIMITS" envJSON:"true"`
+	}
+
+	tests := []struct {
+		name    string
+		src     string
+		want    config
+		wantErr string
+	}{
+		{
+			name: "struct",
+			src:  `OPTIONS={"retries":3,"hosts":["a","b"]}`,
+			want: config{Options: options{Retries: 3, Hosts: []string{"a", "b"}}},
+		},
+		{name: "map", src: `LABELS={"team":"core"}`, want: config{Labels: map[string]string{"team": "core"}}},
+		{name: "pointer", src: `LIMITS={"retries":1}`, want: config{Limits: &options{Retries: 1}}},
+		{name: "invalid json", src: `OPTIONS={"retries":`, wantErr: "failed to parse OPTIONS as json"},
+		{name: "wrong type", src: `OPTIONS={"retries":"3"}`, wantErr: "failed to parse OPTIONS as json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := LoadReader(strings.NewReader(tt.src), &cfg, WithPrecedence(FileOnly))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadReader() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if !reflect.DeepEqual(cfg, tt.want) {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+
+	t.Run("invalid tag", func(t *testing.T) {
+		var cfg struct {
+			Options options `mapstructure:"OPTIONS" envJSON:"yes"`
+		}
+		err := LoadReader(strings.NewReader(`OPTIONS={}`), &cfg, WithPrecedence(FileOnly))
+		if err == nil || !strings.Contains(err.Error(), "invalid envJSON tag") {
+			t.Errorf("LoadReader() error = %v, want an invalid envJSON tag error", err)
+		}
+	})
+}
+
+func TestLoadFileIndirection(t *testing.T) {
+	dir := t.TempDir()
+	password := filepath.Join(dir, "password")
+	if err := os.WriteFile(password, []byte("s3cret\r\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	type config struct {
+		Password string `mapstructure:"DB_PASSWORD"`
+		Token    string `mapstructure:"TOKEN" alias:"API_TOKEN"`
+	}
+
+	tests := []struct {
+		name    string
+		src     string
+		opts    []Option
+		want    config
+		wantErr string
+	}{
+		{name: "file", src: "DB_PASSWORD_FILE=" + password, want: config{Password: "s3cret"}},
+		{name: "value over file", src: "DB_PASSWORD=plain\nDB_PASSWORD_FILE=" + password, want: config{Password: "plain"}},
+		{name: "alias", src: "API_TOKEN_FILE=" + password, want: config{Token: "s3cret"}},
+		{name: "strict", src: "DB_PASSWORD_FILE=" + password, opts: []Option{WithStrict()}, want: config{Password: "s3cret"}},
+		{name: "missing file", src: "DB_PASSWORD_FILE=" + filepath.Join(dir, "missing"), wantErr: "failed to read DB_PASSWORD from DB_PASSWORD_FILE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := LoadReader(strings.NewReader(tt.src), &cfg, append([]Option{WithPrecedence(FileOnly)}, tt.opts...)...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadReader() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if cfg != tt.want {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFileTag(t *testing.T) {
+	dir := t.TempDir()
+	cert := filepath.Join(dir, "cert.pem")
+	if err := os.WriteFile(cert, []byte("-----BEGIN CERTIFICATE-----\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	port := filepath.Join(dir, "port")
+	if err := os.WriteFile(port, []byte("8080"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	type config struct {
+		Cert      string `mapstructure:"CERT" file:"true"`
+		CertBytes []byte `mapstructure:"CERT_BYTES" file:"true"`
+		Port      int    `mapstructure:"PORT" file:"true"`
+		Small     string `mapstructure:"SMALL" file:"true" maxSize:"4B"`
+		Path      string `mapstructure:"CERT_PATH"`
+	}
+
+	tests := []struct {
+		name    string
+		src     string
+		want    config
+		wantErr string
+	}{
+		{name: "string", src: "CERT=" + cert, want: config{Cert: "-----BEGIN CERTIFICATE-----\n"}},
+		{name: "bytes", src: "CERT_BYTES=" + cert, want: config{CertBytes: []byte("-----BEGIN CERTIFICATE-----\n")}},
+		{name: "parsed", src: "PORT=" + port, want: config{Port: 8080}},
+		{name: "without tag", src: "CERT_PATH=" + cert, want: config{Path: cert}},
+		{name: "missing file", src: "CERT=" + filepath.Join(dir, "missing"), wantErr: "failed to read the file for CERT"},
+		{name: "too large", src: "SMALL=" + cert, wantErr: "is larger than 4 bytes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := LoadReader(strings.NewReader(tt.src), &cfg, WithPrecedence(FileOnly))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadReader() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if !reflect.DeepEqual(cfg, tt.want) {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadAlias(t *testing.T) {
+	type cache struct {
+		URL string `mapstructure:"URL" alias:"ADDR"`
+	}
+	type config struct {
+		RedisURL string `mapstructure:"REDIS_URL" alias:"REDISCLOUD_URL, REDISTOGO_URL" default:"redis://localhost" required:"true"`
+		Cache    cache  `prefix:"CACHE_"`
+	}
+
+	tests := []struct {
+		name       string
+		src        string
+		want       config
+		wantUnused []string
+	}{
+		{name: "key over aliases", src: "REDIS_URL=a\nREDISCLOUD_URL=b", want: config{RedisURL: "a"}},
+		{name: "first alias", src: "REDISCLOUD_URL=b\nREDISTOGO_URL=c", want: config{RedisURL: "b"}},
+		{name: "second alias", src: "REDISTOGO_URL=c", want: config{RedisURL: "c"}},
+		{name: "default", src: "", want: config{RedisURL: "redis://localhost"}},
+		{name: "prefixed alias", src: "CACHE_ADDR=d\nCACHE_OTHER=e", want: config{RedisURL: "redis://localhost", Cache: cache{URL: "d"}}, wantUnused: []string{"CACHE_OTHER"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			var report Report
+			if err := LoadReader(strings.NewReader(tt.src), &cfg, WithPrecedence(FileOnly), WithReport(&report)); err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if cfg != tt.want {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+			if !slices.Equal(report.Unused, tt.wantUnused) {
+				t.Errorf("Unused = %q, want %q", report.Unused, tt.wantUnused)
+			}
+		})
+	}
+}
+
+func TestAliasKeysOfSecrets(t *testing.T) {
+	type config struct {
+		Token string `mapstructure:"TOKEN" alias:"API_TOKEN" secret:"true"`
+	}
+
+	var cfg config
+	p := newParser(map[string]string{"TOKEN": "a", "API_TOKEN": "b"}, newOptions())
+	if err := p.parse(&cfg); err != nil {
+		t.Fatalf("parse() error = %v", err)
+	}
+	if !p.keys["API_TOKEN"] || !p.secrets["API_TOKEN"] {
+		t.Error("the alias of the secret field is not recorded as a secret key")
+	}
+}
+
+func TestLoadUnset(t *testing.T) {
+	dir := t.TempDir()
+	password := filepath.Join(dir, "password")
+	if err := os.WriteFile(password, []byte("s3cret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	type config struct {
+		Password string `mapstructure:"UNSET_PASSWORD" unset:"true"`
+		Token    string `mapstructure:"UNSET_TOKEN" alias:"UNSET_API_TOKEN" unset:"true"`
+		Host     string `mapstructure:"UNSET_HOST"`
+	}
+
+	tests := []struct {
+		name      string
+		env       map[string]string
+		opts      []Option
+		want      config
+		wantUnset []string
+		wantKept  []string
+	}{
+		{
+			name:      "key",
+			env:       map[string]string{"UNSET_PASSWORD": "s3cret", "UNSET_HOST": "localhost"},
+			want:      config{Password: "s3cret", Host: "localhost"},
+			wantUnset: []string{"UNSET_PASSWORD"},
+			wantKept:  []string{"UNSET_HOST"},
+		},
+		{
+			name:      "file variant",
+			env:       map[string]string{"UNSET_PASSWORD_FILE": password},
+			want:      config{Password: "s3cret"},
+			wantUnset: []string{"UNSET_PASSWORD_FILE"},
+		},
+		{
+			name:      "alias",
+			env:       map[string]string{"UNSET_API_TOKEN": "token"},
+			want:      config{Token: "token"},
+			wantUnset: []string{"UNSET_API_TOKEN"},
+		},
+		{
+			name:      "prefix",
+			env:       map[string]string{"APP_UNSET_PASSWORD": "s3cret", "UNSET_PASSWORD": "other"},
+			opts:      []Option{WithPrefix("APP_")},
+			want:      config{Password: "s3cret"},
+			wantUnset: []string{"APP_UNSET_PASSWORD"},
+			wantKept:  []string{"UNSET_PASSWORD"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			var cfg config
+			if err := LoadE(&cfg, append([]Option{WithPrecedence(EnvOnly)}, tt.opts...)...); err != nil {
+				t.Fatalf("LoadE() error = %v", err)
+			}
+			if cfg != tt.want {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+			for _, key := range tt.wantUnset {
+				if _, ok := os.LookupEnv(key); ok {
+					t.Errorf("%s is still set", key)
+				}
+			}
+			for _, key := range tt.wantKept {
+				if _, ok := os.LookupEnv(key); !ok {
+					t.Errorf("%s is unset", key)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Provenance maps the names of the fields (e.g. DB.Port) to the origins of their values, the entries of the
+// prefixed maps are named after their keys (e.g. Features[BETA]). Fields that were not set are not included.
+type Provenance map[string]Origin
+
+// Origin describes where the value of a field came from.
+type Origin struct {
+	// Key is the key that supplied the value (e.g. DB_PORT, an alias or DB_PASSWORD_FILE), it is empty for the
+	// values that were not read from a key.
+	Key string
+	// Source is the kind of the source of the value: environment, file, reader, credentials, provider, exec,
+	// resolver, default or preset.
+	Source string
+	// Name identifies the source within its kind, which is the path of a file, the type of a provider, the
+	// command of an exec tag or the tag of a resolver.
+	Name string
+	// Reference is the scheme of the reference that the value was resolved from (e.g. vault://), if any.
+	Reference string
+}
+
+// String describes the origin (e.g. DB_PORT from file .env).
+func (o Origin) String() string {
+	s := strings.TrimSpace(o.Source + " " + o.Name)
+	if o.Key != "" {
+		s = o.Key + " from " + s
+	}
+	if o.Reference != "" {
+		s += " via " + o.Reference
+	}
+
+	return s
+}
+
+// WithProvenance fills the given provenance with the origin of the value of every field that was set (e.g. the
+// environment, a config file, a provider or the default tag), which answers "where did this value come from?".
+func WithProvenance(provenance *Provenance) Option {
+	return func(o *options) {
+		o.provenance = provenance
+	}
+}
+
+// track records the given source as the origin of the keys of the given map if the provenance is tracked, the
+// keys override the origins of the keys that were tracked before.
+func (o *options) track(source, name string, m map[string]string) {
+	if o.provenance == nil {
+		return
+	}
+	if o.origins == nil {
+		o.origins = make(map[string]Origin)
+	}
+
+	for key := range m {
+		o.origins[key] = Origin{Key: key, Source: source, Name: name}
+	}
+}
+
+// trackFile records the config file in the given path as the origin of the keys of the given map, the keys are
+// normalized as the keys of the config files are (see WithPrefix and WithCaseInsensitiveKeys).
+func (o *options) trackFile(source, path string, m map[string]string) {
+	if o.provenance == nil {
+		return
+	}
+
+	normalized := make(map[string]string, len(m))
+	for key, value := range trimPrefix(m, o.envPrefix) {
+		if o.caseInsensitive {
+			key = strings.ToUpper(key)
+		}
+
+		normalized[key] = value
+	}
+
+	o.track(source, path, normalized)
+}
+
+// trackSource records the origins of the keys of the given source, the keys of a Chain are attributed to the
+// providers that supplied them.
+func (o *options) trackSource(m map[string]string) {
+	c, ok := o.source.(*ChainProvider)
+	if !ok {
+		o.track("provider", fmt.Sprintf("%T", o.source), m)
+		return
+	}
+
+	for key, value := range m {
+		name := fmt.Sprintf("%T", o.source)
+		if p, ok := c.Source(key); ok {
+			name = fmt.Sprintf("%T", p)
+		}
+
+		o.track("provider", name, map[string]string{key: value})
+	}
+}
+
+// trackProfile records the origins of the keys that are overridden by the keys of the given profile.
+func (o *options) trackProfile(envMap map[string]string, profile string) {
+	if o.provenance == nil {
+		return
+	}
+
+	prefix := strings.ToUpper(profile) + "_"
+	for key := range envMap {
+		if trimmed, ok := strings.CutPrefix(key, prefix); ok && trimmed != "" {
+			if origin, ok := o.origins[key]; ok {
+				o.origins[trimmed] = origin
+			}
+		}
+	}
+}
+
+// origin returns the origin of the given key that was found in the loaded values, which can be its _FILE variant.
+func (p *parser) origin(key string) Origin {
+	if _, ok := p.envMap[key]; !ok {
+		key += "_FILE"
+	}
+	if origin, ok := p.o.origins[key]; ok {
+		return origin
+	}
+
+	return Origin{Key: key}
+}
+
+// setOrigin records the origin of the value of the field with the given name, the scheme of the reference is
+// recorded if the value was resolved from one.
+func (p *parser) setOrigin(fieldName string, origin Origin, value string) {
+	if p.o.provenance == nil {
+		return
+	}
+
+	for _, r := range p.o.references {
+		if strings.HasPrefix(value, r.tag) {
+			origin.Reference = r.tag
+			break
+		}
+	}
+
+	p.provenance[fieldName] = origin
+}
+
+// resolverTag returns the tag of the resolver that resolves the value of the given field.
+func (p *parser) resolverTag(field reflect.StructField) string {
+	for _, r := range p.o.resolvers {
+		if _, ok := field.Tag.Lookup(r.tag); ok {
+			return r.tag
+		}
+	}
+
+	return ""
+}
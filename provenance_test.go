@@ -0,0 +1,115 @@
+package env
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestProvenance(t *testing.T) {
+	t.Setenv("PROVENANCE_HOST", "env-host")
+
+	dir := t.TempDir()
+	password := filepath.Join(dir, "password")
+	if err := os.WriteFile(password, []byte("s3cret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	type config struct {
+		Host     string            `mapstructure:"PROVENANCE_HOST"`
+		Port     int               `mapstructure:"PORT" default:"8080"`
+		Name     string            `mapstructure:"NAME"`
+		Password string            `mapstructure:"PASSWORD"`
+		Features map[string]string `prefix:"FEATURE_"`
+		Unset    string            `mapstructure:"UNSET"`
+	}
+
+	tests := []struct {
+		name string
+		load func(cfg *config, provenance *Provenance) error
+		want Provenance
+	}{
+		{
+			name: "file and environment",
+			load: func(cfg *config, provenance *Provenance) error {
+				fsys := fstest.MapFS{".env": {Data: []byte("NAME=api\nPASSWORD_FILE=" + password + "\nFEATURE_BETA=on")}}
+				return LoadE(cfg, WithFS(fsys), WithPrecedence(EnvOverFile), WithProvenance(provenance))
+			},
+			want: Provenance{
+				"Host":           {Key: "PROVENANCE_HOST", Source: "environment"},
+				"Port":           {Source: "default"},
+				"Name":           {Key: "NAME", Source: "file", Name: ".env"},
+				"Password":       {Key: "PASSWORD_FILE", Source: "file", Name: ".env"},
+				"Features[BETA]": {Key: "FEATURE_BETA", Source: "file", Name: ".env"},
+			},
+		},
+		{
+			name: "reader",
+			load: func(cfg *config, provenance *Provenance) error {
+				return LoadReader(strings.NewReader("NAME=api"), cfg, WithPrecedence(FileOnly), WithProvenance(provenance))
+			},
+			want: Provenance{"Port": {Source: "default"}, "Name": {Key: "NAME", Source: "reader"}},
+		},
+		{
+			name: "preset",
+			load: func(cfg *config, provenance *Provenance) error {
+				cfg.Port = 3000
+				return LoadReader(strings.NewReader("NAME=api"), cfg, WithPrecedence(FileOnly), WithProvenance(provenance))
+			},
+			want: Provenance{"Port": {Source: "preset"}, "Name": {Key: "NAME", Source: "reader"}},
+		},
+		{
+			name: "provider",
+			load: func(cfg *config, provenance *Provenance) error {
+				return LoadProvider(staticProvider{"NAME": "api"}, cfg, WithProvenance(provenance))
+			},
+			want: Provenance{"Port": {Source: "default"}, "Name": {Key: "NAME", Source: "provider", Name: "env.staticProvider"}},
+		},
+		{
+			name: "chain",
+			load: func(cfg *config, provenance *Provenance) error {
+				chain := Chain(staticProvider{"NAME": "api"}, &mutableProvider{values: map[string]string{"PORT": "80", "NAME": "other"}})
+				return LoadProvider(chain, cfg, WithProvenance(provenance))
+			},
+			want: Provenance{
+				"Port": {Key: "PORT", Source: "provider", Name: "*env.mutableProvider"},
+				"Name": {Key: "NAME", Source: "provider", Name: "env.staticProvider"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			var provenance Provenance
+			if err := tt.load(&cfg, &provenance); err != nil {
+				t.Fatalf("load error = %v", err)
+			}
+			if !maps.Equal(provenance, tt.want) {
+				t.Errorf("Provenance = %v, want %v", provenance, tt.want)
+			}
+		})
+	}
+}
+
+func TestOriginString(t *testing.T) {
+	tests := []struct {
+		origin Origin
+		want   string
+	}{
+		{origin: Origin{Key: "DB_PORT", Source: "file", Name: ".env"}, want: "DB_PORT from file .env"},
+		{origin: Origin{Source: "default"}, want: "default"},
+		{origin: Origin{Key: "PASSWORD", Source: "environment", Reference: "vault://"}, want: "PASSWORD from environment via vault://"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.origin.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// Provider is a source of keys and values, the values of the providers are merged on top of the values
+// of the config files and the environment variables before unmarshaling, in the order that the providers
+// are added with WithProvider.
+type Provider interface {
+	Fetch(ctx context.Context) (map[string]string, error)
+}
+
+// Watcher is a provider that can watch its source for changes. Watch blocks until the context is done and
+// calls fn with all the keys and values whenever they change, errors are passed to fn and the watch goes on.
+type Watcher interface {
+	Provider
+	Watch(ctx context.Context, fn func(map[string]string, error)) error
+}
+
+// File is a provider that reads a config file, it is the provider of the config files that are loaded with
+// WithFile and WithFiles so that they can be combined with other providers in any order.
+type File struct {
+	// Path is the path of the config file.
+	Path string
+	// FS is the file system that the config file is read from, it defaults to the disk.
+	FS fs.FS
+	// Parser parses the config file, it defaults to ParseDotenv.
+	Parser Parser
+	// Optional makes a config file that does not exist provide no values instead of an error.
+	Optional bool
+}
+
+// Fetch reads the config file.
+func (f File) Fetch(_ context.Context) (map[string]string, error) {
+	parse := f.Parser
+	if parse == nil {
+		parse = ParseDotenv
+	}
+
+	m, err := readConfigFile(f.FS, f.Path, parse)
+	if errors.Is(err, os.ErrNotExist) && f.Optional {
+		return map[string]string{}, nil
+	}
+
+	return m, err
+}
+
+// Environment is a provider that reads the environment variables of the process, it is the provider of the
+// environment variables that are loaded by default.
+type Environment struct {
+	// Prefix selects the environment variables that start with it, it is trimmed from their keys
+	// (e.g. MYAPP_PORT becomes PORT with the MYAPP_ prefix).
+	Prefix string
+}
+
+// Fetch reads the environment variables.
+func (e Environment) Fetch(_ context.Context) (map[string]string, error) {
+	m := environ()
+	if e.Prefix == "" {
+		return m, nil
+	}
+
+	filtered := make(map[string]string)
+	for key, value := range m {
+		if trimmed, ok := strings.CutPrefix(key, e.Prefix); ok && trimmed != "" {
+			filtered[trimmed] = value
+		}
+	}
+
+	return filtered, nil
+}
+
+// WithProvider merges the keys and values of the given provider on top of the loaded values.
+func WithProvider(p Provider) Option {
+	return func(o *options) {
+		o.providers = append(o.providers, p)
+	}
+}
+
+// Resolver resolves the values of the fields that are tagged with its tag from the references in the tags
+// (e.g. gsm:"projects/p/secrets/name"), the fields are only resolved when their variables are not set.
+type Resolver interface {
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// ResolverFunc is an adapter to use ordinary functions as resolvers.
+type ResolverFunc func(ctx context.Context, ref string) (string, error)
+
+// Resolve calls f(ctx, ref).
+func (f ResolverFunc) Resolve(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+// tagResolver is a resolver with the tag of the fields or the prefix of the references that it resolves.
+type tagResolver struct {
+	tag string
+	r   Resolver
+}
+
+// WithResolver resolves the fields that are tagged with the given tag with the given resolver.
+func WithResolver(tag string, r Resolver) Option {
+	return func(o *options) {
+		o.resolvers = append(o.resolvers, tagResolver{tag: tag, r: r})
+	}
+}
+
+// resolveField resolves the given field with the first resolver whose tag it has.
+func (p *parser) resolveField(field reflect.StructField) (string, bool, error) {
+	for _, r := range p.o.resolvers {
+		ref, ok := field.Tag.Lookup(r.tag)
+		if !ok {
+			continue
+		}
+
+		value, err := r.r.Resolve(p.o.ctx, ref)
+		p.access(ref, err == nil)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to resolve %s of field %s: %w", ref, field.Name, err)
+		}
+
+		return value, true, nil
+	}
+
+	return "", false, nil
+}
+
+// WithReferences resolves the values that are references with the given scheme (e.g. op://vault/item/field
+// with the op scheme) with the given resolver, so that the references in the config files and in the
+// environment variables are resolved transparently.
+func WithReferences(scheme string, r Resolver) Option {
+	return func(o *options) {
+		o.references = append(o.references, tagResolver{tag: scheme + "://", r: r})
+	}
+}
+
+// resolveReference resolves the given value with the resolver of its scheme if it is a reference,
+// other values are returned as they are.
+func (p *parser) resolveReference(value string) (string, error) {
+	for _, r := range p.o.references {
+		if !strings.HasPrefix(value, r.tag) {
+			continue
+		}
+
+		resolved, err := r.r.Resolve(p.o.ctx, value)
+		p.access(value, err == nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve %s: %w", value, err)
+		}
+
+		return resolved, nil
+	}
+
+	return value, nil
+}
+
+// fetchProviders fetches the keys and values of the providers and merges them into the given map.
+func fetchProviders(ctx context.Context, o *options, envMap map[string]string) (map[string]string, error) {
+	for _, p := range o.providers {
+		m, err := o.retry.fetch(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+
+		m = o.fold(m)
+		o.track("provider", fmt.Sprintf("%T", p), m)
+		envMap = merge(envMap, m)
+	}
+
+	return envMap, nil
+}
@@ -0,0 +1,156 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFileFetch(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.env":  {Data: []byte("PORT=8080")},
+		"app.json": {Data: []byte(`{"PORT":8080}`)},
+	}
+
+	tests := []struct {
+		name    string
+		file    File
+		want    map[string]string
+		wantErr error
+	}{
+		{name: "dotenv", file: File{Path: "app.env", FS: fsys}, want: map[string]string{"PORT": "8080"}},
+		{name: "parser", file: File{Path: "app.json", FS: fsys, Parser: ParseJSON}, want: map[string]string{"PORT": "8080"}},
+		{name: "optional", file: File{Path: "missing.env", FS: fsys, Optional: true}, want: map[string]string{}},
+		{name: "missing", file: File{Path: "missing.env", FS: fsys}, wantErr: fs.ErrNotExist},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.file.Fetch(context.Background())
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Fetch() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fetch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvironmentFetch(t *testing.T) {
+	t.Setenv("PROVIDER_PORT", "8080")
+	t.Setenv("PROVIDER_", "empty")
+
+	tests := []struct {
+		name   string
+		prefix string
+		key    string
+		want   string
+		ok     bool
+	}{
+		{name: "all", key: "PROVIDER_PORT", want: "8080", ok: true},
+		{name: "prefix", prefix: "PROVIDER_", key: "PORT", want: "8080", ok: true},
+		{name: "prefix only", prefix: "PROVIDER_", key: ""},
+		{name: "other prefix", prefix: "OTHER_", key: "PORT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Environment{Prefix: tt.prefix}.Fetch(context.Background())
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if got, ok := m[tt.key]; got != tt.want || ok != tt.ok {
+				t.Errorf("Fetch()[%q] = %q, %v, want %q, %v", tt.key, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestWithProvider(t *testing.T) {
+	type config struct {
+		Host string `mapstructure:"HOST"`
+		Port int    `mapstructure:"PORT"`
+	}
+
+	tests := []struct {
+		name      string
+		providers []Provider
+		want      config
+	}{
+		{name: "no providers", want: config{Host: "file-host", Port: 8080}},
+		{name: "provider", providers: []Provider{staticProvider{"PORT": "9090"}}, want: config{Host: "file-host", Port: 9090}},
+		{
+			name:      "later providers override",
+			providers: []Provider{staticProvider{"HOST": "first", "PORT": "9090"}, staticProvider{"HOST": "second"}},
+			want:      config{Host: "second", Port: 9090},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []Option{WithPrecedence(FileOnly)}
+			for _, p := range tt.providers {
+				opts = append(opts, WithProvider(p))
+			}
+
+			var cfg config
+			if err := LoadReader(strings.NewReader("HOST=file-host\nPORT=8080"), &cfg, opts...); err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if cfg != tt.want {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithResolver(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		err     error
+		want    string
+		wantErr string
+	}{
+		{name: "resolved", want: "resolved-db-password"},
+		{name: "set", src: "DB_PASSWORD=from-file", want: "from-file"},
+		{name: "error", err: errors.New("not found"), wantErr: "failed to resolve db-password of field Password: not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := ResolverFunc(func(_ context.Context, ref string) (string, error) {
+				return "resolved-" + ref, tt.err
+			})
+
+			var cfg struct {
+				Password string `mapstructure:"DB_PASSWORD" secretstore:"db-password"`
+				Host     string `mapstructure:"DB_HOST"`
+			}
+			err := LoadReader(strings.NewReader(tt.src), &cfg, WithPrecedence(FileOnly), WithResolver("secretstore", resolver))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadReader() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if cfg.Password != tt.want || cfg.Host != "" {
+				t.Errorf("config = %+v, want the password %q", cfg, tt.want)
+			}
+		})
+	}
+}
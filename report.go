@@ -0,0 +1,64 @@
+package env
+
+import (
+	"slices"
+	"strings"
+)
+
+// Report describes how the fields of the struct were filled while loading, it can be used to audit
+// the hygiene of the configuration. Fields are identified by their keys and prefixed maps by their
+// prefix followed by an asterisk (e.g. FEATURE_*).
+type Report struct {
+	// Filled contains the keys of the fields that were set from the config files or the environment.
+	Filled []string
+	// Defaulted contains the keys of the fields that were set from their default tag.
+	Defaulted []string
+	// Preset contains the keys of the fields that were not set because they were absent, the values that
+	// the struct was pre-populated with before loading were kept.
+	Preset []string
+	// Zero contains the keys of the fields that were not set and hold the zero value of their type.
+	Zero []string
+	// Unused contains the keys in the config files and the environment variables that start with the
+	// prefix of a nested struct or the prefix provided with WithPrefix which do not map to any of the fields
+	// of the struct.
+	Unused []string
+	// Warnings contains the violations of the constraints of the fields that are tagged with severity:"warn",
+	// which do not fail the loading.
+	Warnings FieldErrors
+}
+
+// report returns the report of the parsed struct, the given map contains the values of the config files.
+func (p *parser) report(fileMap map[string]string) Report {
+	candidates := make(map[string]string)
+	for key, value := range fileMap {
+		candidates[key] = value
+	}
+	for key, value := range p.envMap {
+		for _, prefix := range p.structPrefixes {
+			if strings.HasPrefix(key, prefix) {
+				candidates[key] = value
+				break
+			}
+		}
+	}
+
+	unused := p.unknown(candidates)
+	if p.o.envPrefix != "" {
+		for key := range environ() {
+			if trimmed, ok := strings.CutPrefix(key, p.o.envPrefix); ok && !p.known(trimmed) {
+				unused = append(unused, key)
+			}
+		}
+		slices.Sort(unused)
+		unused = slices.Compact(unused)
+	}
+
+	return Report{
+		Filled:    p.filled,
+		Defaulted: p.defaulted,
+		Preset:    p.preset,
+		Zero:      p.zero,
+		Unused:    unused,
+		Warnings:  p.warnings,
+	}
+}
@@ -0,0 +1,98 @@
+package env
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	type database struct {
+		Host string `mapstructure:"HOST"`
+	}
+	type config struct {
+		Port     int    `mapstructure:"PORT"`
+		Name     string `mapstructure:"NAME" default:"app"`
+		Debug    bool   `mapstructure:"DEBUG"`
+		Untagged string `default:"x"`
+		Computed int
+		DB       database `prefix:"DB_"`
+	}
+
+	var cfg config
+	var report Report
+	err := LoadProvider(staticProvider{"PORT": "8080", "DB_HOST": "localhost", "DB_USER": "admin"}, &cfg, WithReport(&report))
+	if err != nil {
+		t.Fatalf("LoadProvider() error = %v", err)
+	}
+
+	want := Report{
+		Filled:    []string{"PORT", "DB_HOST"},
+		Defaulted: []string{"NAME"},
+		Zero:      []string{"DEBUG"},
+		Unused:    []string{"DB_USER"},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("Report = %+v, want %+v", report, want)
+	}
+	if cfg.Untagged != "x" {
+		t.Errorf("Untagged = %q, want the default x", cfg.Untagged)
+	}
+}
+
+func TestUntaggedFieldKeysAreNotRecorded(t *testing.T) {
+	type config struct {
+		Port     int `mapstructure:"PORT"`
+		Computed int
+	}
+
+	var cfg config
+	p := newParser(map[string]string{"PORT": "1"}, newOptions())
+	if err := p.parse(&cfg); err != nil {
+		t.Fatalf("parse() error = %v", err)
+	}
+	if p.keys[""] {
+		t.Error("the empty key of the untagged field is recorded")
+	}
+	if got := p.unknown(map[string]string{"PORT": "1", "OTHER": "2"}); !reflect.DeepEqual(got, []string{"OTHER"}) {
+		t.Errorf("unknown() = %q, want [OTHER]", got)
+	}
+}
+
+func TestReportUnused(t *testing.T) {
+	t.Setenv("REPORT_PORT", "8080")
+	t.Setenv("REPORT_TYPO", "1")
+	t.Setenv("DB_PASWORD", "secret")
+
+	type config struct {
+		Port int `mapstructure:"PORT"`
+		DB   struct {
+			Host string `mapstructure:"HOST"`
+		} `prefix:"DB_"`
+		Features map[string]bool `prefix:"FEATURE_"`
+	}
+
+	tests := []struct {
+		name string
+		src  string
+		opts []Option
+		want []string
+	}{
+		{name: "file keys", src: "PORT=1\nPROT=2\nFEATURE_X=true", want: []string{"PROT"}},
+		{name: "nested prefix", src: "PORT=1", opts: []Option{WithPrecedence(EnvOverFile)}, want: []string{"DB_PASWORD"}},
+		{name: "env prefix", src: "", opts: []Option{WithPrecedence(EnvOverFile), WithPrefix("REPORT_")}, want: []string{"REPORT_TYPO"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			var report Report
+			if err := LoadReader(strings.NewReader(tt.src), &cfg, append(tt.opts, WithReport(&report))...); err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if !reflect.DeepEqual(report.Unused, tt.want) {
+				t.Errorf("Report.Unused = %q, want %q", report.Unused, tt.want)
+			}
+		})
+	}
+}
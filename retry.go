@@ -0,0 +1,118 @@
+package env
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	// defaultRetryAttempts is the default maximum number of attempts of a fetch.
+	defaultRetryAttempts = 3
+	// defaultRetryBackoff is the default backoff before the first retry of a fetch.
+	defaultRetryBackoff = 200 * time.Millisecond
+	// defaultRetryMaxBackoff is the default maximum backoff between the retries of a fetch.
+	defaultRetryMaxBackoff = 5 * time.Second
+)
+
+// Retry is the policy of retrying the fetches of the providers that fail, the backoff between the attempts
+// grows exponentially and is randomized with full jitter.
+type Retry struct {
+	// MaxAttempts is the maximum number of attempts of a fetch including the first one, it defaults to 3.
+	MaxAttempts int
+	// Backoff is the backoff before the first retry, it is doubled for every retry and defaults to 200ms.
+	Backoff time.Duration
+	// MaxBackoff caps the backoff between the retries, it defaults to 5 seconds.
+	MaxBackoff time.Duration
+	// Retryable reports whether a fetch that failed with the given error should be retried, it defaults to
+	// Retryable.
+	Retryable func(error) bool
+}
+
+// WithRetry retries the fetches of the providers that fail with the given policy, so that transient
+// failures of the remote sources do not fail the loading.
+func WithRetry(r Retry) Option {
+	return func(o *options) {
+		o.retry = &r
+	}
+}
+
+// Retryable reports whether the given error of a fetch is transient: the network errors (except for the
+// failures to verify a certificate), the timeouts, the connections that were closed mid-response and the
+// responses with a 5xx status, 408 Request Timeout or 429 Too Many Requests. The other errors (e.g. a config
+// that fails to parse, a missing file or a denied permission) and the cancellation of the context are
+// permanent.
+func Retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		code := statusErr.StatusCode
+		return code >= 500 || code == http.StatusRequestTimeout || code == http.StatusTooManyRequests
+	}
+
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	if errors.As(err, &certErr) || errors.As(err, &unknownAuthorityErr) || errors.As(err, &hostnameErr) {
+		return false
+	}
+
+	var timeoutErr interface{ Timeout() bool }
+	if errors.As(err, &timeoutErr) && timeoutErr.Timeout() {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// fetch fetches the values of the given provider, retrying the fetches that fail with the policy.
+func (r *Retry) fetch(ctx context.Context, p Provider) (map[string]string, error) {
+	if r == nil {
+		return p.Fetch(ctx)
+	}
+
+	attempts := r.MaxAttempts
+	if attempts <= 0 {
+		attempts = defaultRetryAttempts
+	}
+	backoff := r.Backoff
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+	maxBackoff := r.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultRetryMaxBackoff
+	}
+	backoff = min(backoff, maxBackoff)
+	retryable := r.Retryable
+	if retryable == nil {
+		retryable = Retryable
+	}
+
+	for attempt := 1; ; attempt++ {
+		m, err := p.Fetch(ctx)
+		if err == nil || attempt >= attempts || !retryable(err) {
+			return m, err
+		}
+
+		timer := time.NewTimer(rand.N(backoff) + 1)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, errors.Join(err, ctx.Err())
+		}
+
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
@@ -0,0 +1,171 @@
+package env
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// flakyProvider fails with the given errors before it returns its values.
+type flakyProvider struct {
+	errs   []error
+	values map[string]string
+	calls  int
+}
+
+// Fetch returns the next error, or the values once all the errors were returned.
+func (p *flakyProvider) Fetch(_ context.Context) (map[string]string, error) {
+	p.calls++
+	if p.calls <= len(p.errs) {
+		return nil, p.errs[p.calls-1]
+	}
+
+	return p.values, nil
+}
+
+// timeoutError is an error that reports a timeout without being a net.Error.
+type timeoutError struct{}
+
+// Error returns the message of the timeout.
+func (timeoutError) Error() string { return "timeout" }
+
+// Timeout reports that the error is a timeout.
+func (timeoutError) Timeout() bool { return true }
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "connection refused", err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, want: true},
+		{name: "http client error", err: &url.Error{Op: "Get", URL: "https://example.com", Err: &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}}, want: true},
+		{name: "dns error", err: fmt.Errorf("fetch: %w", &net.DNSError{Err: "no such host", Name: "example.com"}), want: true},
+		{name: "timeout", err: timeoutError{}, want: true},
+		{name: "unexpected eof", err: fmt.Errorf("read: %w", io.ErrUnexpectedEOF), want: true},
+		{name: "certificate", err: &url.Error{Op: "Get", URL: "https://example.com", Err: &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}}, want: false},
+		{name: "plain error", err: errors.New("invalid config"), want: false},
+		{name: "missing file", err: fmt.Errorf("read: %w", os.ErrNotExist), want: false},
+		{name: "permission", err: &fs.PathError{Op: "open", Path: ".env", Err: fs.ErrPermission}, want: false},
+		{name: "canceled", err: context.Canceled, want: false},
+		{name: "deadline", err: fmt.Errorf("fetch: %w", context.DeadlineExceeded), want: false},
+		{name: "server error", err: &StatusError{StatusCode: http.StatusServiceUnavailable}, want: true},
+		{name: "request timeout", err: &StatusError{StatusCode: http.StatusRequestTimeout}, want: true},
+		{name: "too many requests", err: &StatusError{StatusCode: http.StatusTooManyRequests}, want: true},
+		{name: "not found", err: fmt.Errorf("fetch: %w", &StatusError{StatusCode: http.StatusNotFound}), want: false},
+		{name: "forbidden", err: &StatusError{StatusCode: http.StatusForbidden}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Retryable(tt.err); got != tt.want {
+				t.Errorf("Retryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryFetch(t *testing.T) {
+	transient := &StatusError{StatusCode: http.StatusBadGateway, Status: "502 Bad Gateway"}
+	permanent := &StatusError{StatusCode: http.StatusUnauthorized, Status: "401 Unauthorized"}
+	errParse := errors.New("line 1: expected a KEY=value pair")
+
+	tests := []struct {
+		name      string
+		errs      []error
+		wantErr   error
+		wantCalls int
+	}{
+		{name: "success", wantCalls: 1},
+		{name: "transient failures", errs: []error{transient, transient}, wantCalls: 3},
+		{name: "attempts exhausted", errs: []error{transient, transient, transient}, wantErr: transient, wantCalls: 3},
+		{name: "permanent failure", errs: []error{permanent}, wantErr: permanent, wantCalls: 1},
+		{name: "parse error", errs: []error{errParse}, wantErr: errParse, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &flakyProvider{errs: tt.errs, values: map[string]string{"A": "1"}}
+			r := &Retry{Backoff: time.Millisecond}
+
+			m, err := r.fetch(context.Background(), p)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("fetch() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && m["A"] != "1" {
+				t.Errorf("fetch() = %v, want A=1", m)
+			}
+			if p.calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", p.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	transient := &StatusError{StatusCode: http.StatusServiceUnavailable, Status: "503 Service Unavailable"}
+	errDenied := errors.New("permission denied")
+
+	tests := []struct {
+		name      string
+		retry     Retry
+		errs      []error
+		timeout   time.Duration
+		wantErr   error
+		wantCalls int
+	}{
+		{name: "retried", retry: Retry{Backoff: time.Millisecond}, errs: []error{transient, transient}, wantCalls: 3},
+		{name: "max attempts", retry: Retry{MaxAttempts: 2, Backoff: time.Millisecond}, errs: []error{transient, transient}, wantErr: transient, wantCalls: 2},
+		{
+			name:      "custom retryable",
+			retry:     Retry{Backoff: time.Millisecond, Retryable: func(err error) bool { return errors.Is(err, errDenied) }},
+			errs:      []error{errDenied},
+			wantCalls: 2,
+		},
+		{
+			name:      "canceled during the backoff",
+			retry:     Retry{Backoff: time.Hour, MaxBackoff: time.Hour},
+			errs:      []error{transient},
+			timeout:   10 * time.Millisecond,
+			wantErr:   context.DeadlineExceeded,
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			p := &flakyProvider{errs: tt.errs, values: map[string]string{"PORT": "8080"}}
+			var cfg struct {
+				Port int `mapstructure:"PORT"`
+			}
+			err := LoadContext(ctx, &cfg, WithPrecedence(EnvOnly), WithProvider(p), WithRetry(tt.retry))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LoadContext() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && cfg.Port != 8080 {
+				t.Errorf("Port = %d, want 8080", cfg.Port)
+			}
+			if p.calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", p.calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package env
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+)
+
+// secretType is the interface of the pointers to Secret values, which the parser loads as their values instead
+// of as nested structs.
+var secretType = reflect.TypeOf((*secretValuer)(nil)).Elem()
+
+// secretValuer is implemented by the pointers to Secret values.
+type secretValuer interface {
+	secretValue() reflect.Value
+}
+
+// Secret holds a sensitive value (e.g. a password or an API key) that is never printed, it is formatted as
+// [REDACTED] by the fmt verbs, slog and encoding/json so that it can not leak through a log of the config
+// (e.g. fmt.Printf("%+v", cfg)). The value is only returned by Value. Secret fields are loaded like fields of
+// their value type and they are treated as tagged with secret:"true".
+type Secret[T any] struct {
+	value T
+}
+
+// NewSecret returns a Secret that holds the given value.
+func NewSecret[T any](value T) Secret[T] {
+	return Secret[T]{value: value}
+}
+
+// Value returns the value of the secret.
+func (s Secret[T]) Value() T {
+	return s.value
+}
+
+// String returns [REDACTED].
+func (s Secret[T]) String() string {
+	return redacted
+}
+
+// GoString returns [REDACTED], which is used by the %#v verb.
+func (s Secret[T]) GoString() string {
+	return redacted
+}
+
+// Format writes [REDACTED] for every fmt verb.
+func (s Secret[T]) Format(f fmt.State, _ rune) {
+	_, _ = io.WriteString(f, redacted)
+}
+
+// LogValue returns [REDACTED] for slog.
+func (s Secret[T]) LogValue() slog.Value {
+	return slog.StringValue(redacted)
+}
+
+// MarshalJSON returns [REDACTED] as a JSON string.
+func (s Secret[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(redacted)
+}
+
+// UnmarshalJSON decodes the value of the secret, so that secrets can be nested in the fields that are tagged
+// with envJSON.
+func (s *Secret[T]) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &s.value)
+}
+
+// secretValue returns the settable value of the secret.
+func (s *Secret[T]) secretValue() reflect.Value {
+	return reflect.ValueOf(&s.value).Elem()
+}
+
+// isSecret reports whether the given field holds a Secret or is tagged with secret:"true".
+func isSecret(field reflect.StructField) bool {
+	if field.Type == nil {
+		return false
+	}
+	if reflect.PointerTo(field.Type).Implements(secretType) {
+		return true
+	}
+	if field.Type.Kind() == reflect.Pointer && reflect.PointerTo(field.Type.Elem()).Implements(secretType) {
+		return true
+	}
+
+	secret, _ := boolTag(field, "secret")
+	return secret
+}
+
+// unwrapSecret returns the value that the given Secret value holds, other values are returned as they are.
+func unwrapSecret(v reflect.Value) reflect.Value {
+	if v.CanAddr() {
+		if s, ok := v.Addr().Interface().(secretValuer); ok {
+			return s.secretValue()
+		}
+	}
+
+	return v
+}
@@ -0,0 +1,106 @@
+package env
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSecretFormatting(t *testing.T) {
+	type config struct {
+		Host     string
+		Password Secret[string]
+	}
+	cfg := config{Host: "localhost", Password: NewSecret("hunter2")}
+
+	tests := []struct {
+		name   string
+		format func() string
+	}{
+		{name: "%v", format: func() string { return fmt.Sprintf("%v", cfg) }},
+		{name: "%+v", format: func() string { return fmt.Sprintf("%+v", cfg) }},
+		{name: "%#v", format: func() string { return fmt.Sprintf("%#v", cfg) }},
+		{name: "%s", format: func() string { return fmt.Sprintf("%s", cfg.Password) }},
+		{name: "%q", format: func() string { return fmt.Sprintf("%q", cfg.Password) }},
+		{name: "%x", format: func() string { return fmt.Sprintf("%x", cfg.Password) }},
+		{
+			name: "json",
+			format: func() string {
+				b, err := json.Marshal(cfg)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return string(b)
+			},
+		},
+		{
+			name: "slog",
+			format: func() string {
+				var buf bytes.Buffer
+				slog.New(slog.NewJSONHandler(&buf, nil)).Info("config", "password", cfg.Password)
+				return buf.String()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.format()
+			if strings.Contains(got, "hunter2") || !strings.Contains(got, redacted) {
+				t.Errorf("formatted = %s, want the value redacted", got)
+			}
+		})
+	}
+
+	if got := cfg.Password.Value(); got != "hunter2" {
+		t.Errorf("Value() = %q, want %q", got, "hunter2")
+	}
+}
+
+func TestLoadSecret(t *testing.T) {
+	type credentials struct {
+		Token Secret[string] `json:"token"`
+	}
+	type config struct {
+		Password    Secret[string]  `mapstructure:"PASSWORD"`
+		Port        Secret[int]     `mapstructure:"PORT" min:"1"`
+		Key         *Secret[string] `mapstructure:"KEY"`
+		Credentials credentials     `mapstructure:"CREDENTIALS" envJSON:"true"`
+	}
+
+	t.Run("values", func(t *testing.T) {
+		var cfg config
+		src := "PASSWORD=hunter2\nPORT=5432\nKEY=k\n" + `CREDENTIALS={"token":"t"}`
+		if err := LoadReader(strings.NewReader(src), &cfg, WithPrecedence(FileOnly)); err != nil {
+			t.Fatalf("LoadReader() error = %v", err)
+		}
+		if cfg.Password.Value() != "hunter2" || cfg.Port.Value() != 5432 || cfg.Key == nil || cfg.Key.Value() != "k" || cfg.Credentials.Token.Value() != "t" {
+			t.Errorf("config = %#v, want the loaded values", cfg)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{name: "invalid value", src: "PORT=hunter2", wantErr: "PORT"},
+		{name: "constraint", src: "PORT=-7", wantErr: "PORT must be at least 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := LoadReader(strings.NewReader(tt.src), &cfg, WithPrecedence(FileOnly))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("LoadReader() error = %v, want %q", err, tt.wantErr)
+			}
+			if strings.Contains(err.Error(), "hunter2") || strings.Contains(err.Error(), "-7") {
+				t.Errorf("LoadReader() error = %v, want the value redacted", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultSecretsDir is the directory that Docker mounts the secrets of a service at.
+const defaultSecretsDir = "/run/secrets"
+
+// SecretsDir fetches the secrets in a directory of secret files, such as the Docker secrets in /run/secrets
+// or the mounted Kubernetes Secrets. The name of every file is its key (upper cased with the dashes and the
+// dots replaced with underscores, e.g. db-password becomes DB_PASSWORD) and its contents, with a trailing
+// newline trimmed, are its value. Hidden files and subdirectories are skipped and a directory that does not
+// exist has no secrets.
+type SecretsDir struct {
+	// Path is the path of the directory, it defaults to /run/secrets.
+	Path string
+}
+
+// WithSecretsDir merges the secrets in the directory at the given path on top of the loaded values.
+func WithSecretsDir(path string) Option {
+	return WithProvider(&SecretsDir{Path: path})
+}
+
+// Fetch reads the secrets in the directory.
+func (s *SecretsDir) Fetch(_ context.Context) (map[string]string, error) {
+	dir := s.Path
+	if dir == "" {
+		dir = defaultSecretsDir
+	}
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the secrets directory %s: %w", dir, err)
+	}
+
+	m := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		// mounted secrets are often symlinks, so the files that they point to are checked
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the secret %s: %w", path, err)
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the secret %s: %w", path, err)
+		}
+
+		m[dataKey(entry.Name())] = strings.TrimSuffix(strings.TrimSuffix(string(b), "\n"), "\r")
+	}
+
+	return m, nil
+}
+
+// systemdCredentials reads the credentials that systemd passes to a service with LoadCredential= and
+// SetCredential= from $CREDENTIALS_DIRECTORY, there are no credentials if it is not set.
+func systemdCredentials(o *options) (map[string]string, error) {
+	dir := os.Getenv("CREDENTIALS_DIRECTORY")
+	if dir == "" {
+		return nil, nil
+	}
+
+	o.log().Debug("loading the systemd credentials", "dir", dir)
+
+	return (&SecretsDir{Path: dir}).Fetch(o.ctx)
+}
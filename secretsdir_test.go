@@ -0,0 +1,112 @@
+package env
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSecretsDirFetch(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"db-password":       "s3cret\n",
+		"api.key":           "key\r\n",
+		"token":             "multi\nline",
+		".hidden":           "hidden",
+		"sub/nested":        "nested",
+		"../outside/target": "linked",
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, "secrets", name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(dir, "outside", "target"), filepath.Join(dir, "secrets", "linked")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want map[string]string
+	}{
+		{
+			name: "secrets",
+			path: filepath.Join(dir, "secrets"),
+			want: map[string]string{"DB_PASSWORD": "s3cret", "API_KEY": "key", "TOKEN": "multi\nline", "LINKED": "linked"},
+		},
+		{name: "missing directory", path: filepath.Join(dir, "missing"), want: map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := (&SecretsDir{Path: tt.path}).Fetch(context.Background())
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fetch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithSecretsDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "db-password"), []byte("from-secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg struct {
+		Host     string `mapstructure:"DB_HOST"`
+		Password string `mapstructure:"DB_PASSWORD"`
+	}
+	if err := LoadReader(strings.NewReader("DB_HOST=localhost\nDB_PASSWORD=from-file"), &cfg, WithPrecedence(FileOnly), WithSecretsDir(dir)); err != nil {
+		t.Fatalf("LoadReader() error = %v", err)
+	}
+	if cfg.Host != "localhost" || cfg.Password != "from-secret" {
+		t.Errorf("config = %+v, want the password of the secrets directory", cfg)
+	}
+}
+
+func TestLoadSystemdCredentials(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "db-password"), []byte("from-credential\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		dir        string
+		precedence Precedence
+		want       string
+	}{
+		{name: "credentials", dir: dir, precedence: EnvOverFile, want: "from-credential"},
+		{name: "without credentials", precedence: EnvOverFile, want: "from-env"},
+		{name: "file only", dir: dir, precedence: FileOnly, want: "from-file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CREDENTIALS_DIRECTORY", tt.dir)
+			t.Setenv("DB_PASSWORD", "from-env")
+
+			var cfg struct {
+				Password string `mapstructure:"DB_PASSWORD"`
+			}
+			if err := LoadReader(strings.NewReader("DB_PASSWORD=from-file"), &cfg, WithPrecedence(tt.precedence)); err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if cfg.Password != tt.want {
+				t.Errorf("Password = %q, want %q", cfg.Password, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package env
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// WithReloadSignal makes Watch reload the config when the process receives any of the given signals, which
+// default to SIGHUP following the convention of the Unix daemons (e.g. kill -HUP <pid>).
+func WithReloadSignal(signals ...os.Signal) Option {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGHUP}
+	}
+
+	return func(o *options) {
+		o.reloadSignals = signals
+	}
+}
+
+// watchSignals reloads the config whenever the process receives one of the reload signals until the context
+// is done.
+func (r *reloader[T]) watchSignals(ctx context.Context) error {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, r.o.reloadSignals...)
+	defer signal.Stop(c)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case sig := <-c:
+			r.o.log().Info("reloading the config on signal", "signal", sig.String())
+			_ = r.reload(ctx)
+		}
+	}
+}
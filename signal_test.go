@@ -0,0 +1,93 @@
+package env
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWithReloadSignal(t *testing.T) {
+	tests := []struct {
+		name    string
+		signals []os.Signal
+		want    []os.Signal
+	}{
+		{name: "default", want: []os.Signal{syscall.SIGHUP}},
+		{name: "signals", signals: []os.Signal{os.Interrupt, syscall.SIGHUP}, want: []os.Signal{os.Interrupt, syscall.SIGHUP}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newOptions(WithReloadSignal(tt.signals...)).reloadSignals; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("reloadSignals = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatchSignals(t *testing.T) {
+	type config struct {
+		Host string `mapstructure:"HOST"`
+	}
+
+	tests := []struct {
+		name    string
+		signals []os.Signal
+		signal  os.Signal
+	}{
+		{name: "SIGHUP", signal: syscall.SIGHUP},
+		{name: "interrupt", signals: []os.Signal{os.Interrupt}, signal: os.Interrupt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &mutableProvider{values: map[string]string{"HOST": "localhost"}}
+			opts := []Option{WithPrecedence(EnvOnly), WithProvider(p), WithReloadSignal(tt.signals...)}
+
+			var cfg config
+			if err := LoadE(&cfg, opts...); err != nil {
+				t.Fatalf("LoadE() error = %v", err)
+			}
+
+			changes := make(chan config, 1)
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() {
+				done <- Watch(ctx, &cfg, func(_, new config) { changes <- new }, opts...)
+			}()
+			// the signals are only handled once the watch is running
+			time.Sleep(50 * time.Millisecond)
+
+			// the values are only reloaded on the signal as there is no poll interval
+			p.set(map[string]string{"HOST": "remote"})
+			select {
+			case c := <-changes:
+				t.Fatalf("the config was reloaded without a signal: %+v", c)
+			case <-time.After(50 * time.Millisecond):
+			}
+
+			process, err := os.FindProcess(os.Getpid())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := process.Signal(tt.signal); err != nil {
+				t.Fatal(err)
+			}
+
+			select {
+			case c := <-changes:
+				if c.Host != "remote" {
+					t.Errorf("Host = %q, want %q", c.Host, "remote")
+				}
+			case <-time.After(time.Second):
+				t.Error("the config was not reloaded on the signal")
+			}
+
+			cancel()
+			<-done
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package env
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// sigExt is the extension of the detached signatures of the config files.
+const sigExt = ".sig"
+
+// signatureComment is the comment that embeds the signature in the last line of a config file.
+const signatureComment = "# signature:"
+
+// verifier reports whether the given signature of the given data is valid.
+type verifier func(data, sig []byte) bool
+
+// WithHMAC refuses to load the config files whose HMAC-SHA256 with the given key does not match their
+// signature, which is read from a detached file with a .sig suffix (e.g. .env.sig) or from a
+// "# signature: <hmac>" comment in the last line of the file. The signature is encoded in hex or base64.
+func WithHMAC(key []byte) Option {
+	return func(o *options) {
+		o.verify = func(data, sig []byte) bool {
+			mac := hmac.New(sha256.New, key)
+			mac.Write(data)
+
+			return hmac.Equal(mac.Sum(nil), sig)
+		}
+	}
+}
+
+// WithSignature refuses to load the config files whose Ed25519 signature is not valid for the given public
+// key, the signature is read in the same way as with WithHMAC.
+func WithSignature(publicKey ed25519.PublicKey) Option {
+	return func(o *options) {
+		o.verify = func(data, sig []byte) bool {
+			return ed25519.Verify(publicKey, data, sig)
+		}
+	}
+}
+
+// verifiedParser returns a parser that verifies the signature of the config file in the given path before it
+// is parsed with the given parser, the embedded signature is removed before the file is parsed.
+func verifiedParser(o *options, file string, parse Parser) Parser {
+	return func(r io.Reader) (map[string]string, error) {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+
+		data, sig, err := signature(o.fsys, file, data)
+		if err != nil {
+			return nil, err
+		}
+		if !o.verify(data, sig) {
+			return nil, errors.New("invalid signature, the config file was tampered with or signed with another key")
+		}
+
+		o.log().Debug("verified the signature of the config file", "path", file)
+
+		return parse(bytes.NewReader(data))
+	}
+}
+
+// signature returns the signed data and the signature of the config file in the given path with the given
+// contents, from its detached signature if there is one and from its last line otherwise.
+func signature(fsys fs.FS, file string, data []byte) ([]byte, []byte, error) {
+	var b []byte
+	var err error
+	if fsys != nil {
+		b, err = fs.ReadFile(fsys, file+sigExt)
+	} else {
+		b, err = os.ReadFile(file + sigExt)
+	}
+	if err == nil {
+		sig, err := decodeSignature(string(b))
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid signature in %s: %w", file+sigExt, err)
+		}
+
+		return data, sig, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, fmt.Errorf("failed to read the signature %s: %w", file+sigExt, err)
+	}
+
+	trimmed := bytes.TrimRight(data, "\r\n")
+	i := bytes.LastIndexByte(trimmed, '\n') + 1
+	line := string(trimmed[i:])
+	if !strings.HasPrefix(line, signatureComment) {
+		return nil, nil, errors.New("the config file is not signed")
+	}
+
+	sig, err := decodeSignature(strings.TrimPrefix(line, signatureComment))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid signature: %w", err)
+	}
+
+	return data[:i], sig, nil
+}
+
+// decodeSignature decodes the given hex or base64 encoded signature.
+func decodeSignature(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if sig, err := hex.DecodeString(s); err == nil {
+		return sig, nil
+	}
+
+	return base64.StdEncoding.DecodeString(s)
+}
@@ -0,0 +1,121 @@
+package env
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestWithHMAC(t *testing.T) {
+	key := []byte("secret")
+	data := "PORT=8080\n"
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	sig := mac.Sum(nil)
+
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		want    int
+		wantErr string
+	}{
+		{
+			name: "embedded hex signature",
+			fsys: fstest.MapFS{".env": {Data: []byte(data + signatureComment + " " + hex.EncodeToString(sig) + "\n")}},
+			want: 8080,
+		},
+		{
+			name: "embedded base64 signature",
+			fsys: fstest.MapFS{".env": {Data: []byte(data + signatureComment + " " + base64.StdEncoding.EncodeToString(sig))}},
+			want: 8080,
+		},
+		{
+			name: "detached signature",
+			fsys: fstest.MapFS{".env": {Data: []byte(data)}, ".env.sig": {Data: []byte(hex.EncodeToString(sig) + "\n")}},
+			want: 8080,
+		},
+		{
+			name:    "tampered",
+			fsys:    fstest.MapFS{".env": {Data: []byte("PORT=9090\n" + signatureComment + " " + hex.EncodeToString(sig))}},
+			wantErr: "invalid signature, the config file was tampered with",
+		},
+		{name: "not signed", fsys: fstest.MapFS{".env": {Data: []byte(data)}}, wantErr: "the config file is not signed"},
+		{
+			name:    "invalid detached signature",
+			fsys:    fstest.MapFS{".env": {Data: []byte(data)}, ".env.sig": {Data: []byte("!")}},
+			wantErr: "invalid signature in .env.sig",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg struct {
+				Port int `mapstructure:"PORT"`
+			}
+			err := LoadE(&cfg, WithFS(tt.fsys), WithPrecedence(FileOnly), WithHMAC(key))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadE() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadE() error = %v", err)
+			}
+			if cfg.Port != tt.want {
+				t.Errorf("Port = %d, want %d", cfg.Port, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithSignature(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := "PORT=8080\n"
+	signed := data + signatureComment + " " + base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, []byte(data))) + "\n"
+
+	tests := []struct {
+		name      string
+		publicKey ed25519.PublicKey
+		wantErr   string
+	}{
+		{name: "signed", publicKey: publicKey},
+		{name: "other key", publicKey: otherKey, wantErr: "signed with another key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg struct {
+				Port int `mapstructure:"PORT"`
+			}
+			fsys := fstest.MapFS{".env": {Data: []byte(signed)}}
+			err := LoadE(&cfg, WithFS(fsys), WithPrecedence(FileOnly), WithSignature(tt.publicKey))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadE() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadE() error = %v", err)
+			}
+			if cfg.Port != 8080 {
+				t.Errorf("Port = %d, want 8080", cfg.Port)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package env
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// sizes maps the supported byte size units to their multipliers, units are matched case insensitively.
+var sizes = map[string]uint64{
+	"":    1,
+	"b":   1,
+	"kb":  1e3,
+	"mb":  1e6,
+	"gb":  1e9,
+	"tb":  1e12,
+	"pb":  1e15,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+	"pib": 1 << 50,
+}
+
+// parseSize parses a human readable byte size such as 25MB or 2GiB and returns the number of bytes.
+// Decimal units (KB, MB, GB, ...) are powers of 1000 while binary units (KiB, MiB, GiB, ...) are powers of 1024.
+func parseSize(s string) (uint64, error) {
+	s = strings.TrimSpace(s)
+
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i == -1 {
+		i = len(s)
+	}
+
+	number, unit := s[:i], strings.TrimSpace(s[i:])
+	multiplier, ok := sizes[strings.ToLower(unit)]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q in size %q", unit, s)
+	}
+
+	if n, err := strconv.ParseUint(number, 10, 64); err == nil {
+		if n > math.MaxUint64/multiplier {
+			return 0, fmt.Errorf("size %q is out of range", s)
+		}
+
+		return n * multiplier, nil
+	}
+
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	bytes := f * float64(multiplier)
+	if bytes >= math.MaxUint64 {
+		return 0, fmt.Errorf("size %q is out of range", s)
+	}
+
+	return uint64(bytes), nil
+}
@@ -0,0 +1,85 @@
+package env
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		size    string
+		want    uint64
+		wantErr bool
+	}{
+		{size: "512", want: 512},
+		{size: "512B", want: 512},
+		{size: "25MB", want: 25_000_000},
+		{size: "25 mb", want: 25_000_000},
+		{size: "2GiB", want: 2 << 30},
+		{size: "1.5KiB", want: 1536},
+		{size: "1PiB", want: 1 << 50},
+		{size: "10XB", wantErr: true},
+		{size: "MB", wantErr: true},
+		{size: "-1MB", wantErr: true},
+		{size: "20000PB", wantErr: true},
+		{size: "20000.5PB", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.size, func(t *testing.T) {
+			got, err := parseSize(tt.size)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseSize() = %d, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSize() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parseSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSizes(t *testing.T) {
+	type config struct {
+		MaxBody  int64  `mapstructure:"MAX_BODY" unit:"bytes"`
+		MaxCache uint32 `mapstructure:"MAX_CACHE" unit:"bytes"`
+		Small    int8   `mapstructure:"SMALL" unit:"bytes"`
+		Count    int    `mapstructure:"COUNT"`
+	}
+
+	tests := []struct {
+		name    string
+		src     string
+		want    config
+		wantErr string
+	}{
+		{name: "sizes", src: "MAX_BODY=10MiB\nMAX_CACHE=2GB", want: config{MaxBody: 10 << 20, MaxCache: 2_000_000_000}},
+		{name: "without unit tag", src: "COUNT=10MB", wantErr: "failed to parse COUNT as int"},
+		{name: "uint overflow", src: "MAX_CACHE=5GB", wantErr: "5GB overflows uint32"},
+		{name: "int overflow", src: "SMALL=1KiB", wantErr: "1KiB overflows int8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := LoadReader(strings.NewReader(tt.src), &cfg, WithPrecedence(FileOnly))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadReader() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadReader() error = %v", err)
+			}
+			if cfg != tt.want {
+				t.Errorf("config = %+v, want %+v", cfg, tt.want)
+			}
+		})
+	}
+}
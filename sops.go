@@ -0,0 +1,82 @@
+package env
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// sopsMarkers match the metadata that SOPS adds to the files that it encrypts in every format.
+var sopsMarkers = map[string]*regexp.Regexp{
+	"dotenv": regexp.MustCompile(`(?m)^sops_version=`),
+	"yaml":   regexp.MustCompile(`(?m)^sops:\s*$`),
+	"ini":    regexp.MustCompile(`(?m)^\[sops\]\s*$`),
+}
+
+// WithSOPS detects the config files that are encrypted with SOPS and decrypts them before they are parsed, so
+// that encrypted config can be committed to git. The files are decrypted by the sops binary, which supports all
+// of its key backends (age, AWS KMS, GCP KMS, Azure Key Vault, PGP) with their usual configuration. The format
+// of a file is derived from its extension, files without a known extension are treated as dotenv files.
+func WithSOPS() Option {
+	return func(o *options) {
+		o.sops = true
+	}
+}
+
+// sopsParser returns a parser that decrypts the config file in the given path with sops if it is encrypted
+// and parses it with the given parser.
+func sopsParser(o *options, file string, parse Parser) Parser {
+	return func(r io.Reader) (map[string]string, error) {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+
+		format := sopsFormat(file)
+		if !isSOPS(data, format) {
+			return parse(bytes.NewReader(data))
+		}
+
+		o.log().Debug("decrypting the config file with sops", "path", file)
+
+		out, err := runCommand(o.ctx, o.execTimeout, nil, bytes.NewReader(data),
+			"sops", "--decrypt", "--input-type", format, "--output-type", format, "/dev/stdin")
+		if err != nil {
+			return nil, err
+		}
+
+		return parse(bytes.NewReader(out))
+	}
+}
+
+// sopsFormat returns the SOPS format of the config file in the given path.
+func sopsFormat(file string) string {
+	switch strings.ToLower(path.Ext(file)) {
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".json":
+		return "json"
+	case ".ini":
+		return "ini"
+	default:
+		return "dotenv"
+	}
+}
+
+// isSOPS reports whether the given contents of a config file in the given format are encrypted with SOPS.
+func isSOPS(data []byte, format string) bool {
+	if format == "json" {
+		var v map[string]json.RawMessage
+		if err := json.Unmarshal(data, &v); err != nil {
+			return false
+		}
+
+		_, ok := v["sops"]
+		return ok
+	}
+
+	return sopsMarkers[format].Match(data)
+}
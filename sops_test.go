@@ -0,0 +1,93 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSOPSFormat(t *testing.T) {
+	tests := []struct {
+		file string
+		want string
+	}{
+		{file: ".env", want: "dotenv"},
+		{file: "config.env", want: "dotenv"},
+		{file: "config.yaml", want: "yaml"},
+		{file: "config.YML", want: "yaml"},
+		{file: "config.json", want: "json"},
+		{file: "config.ini", want: "ini"},
+		{file: "config.toml", want: "dotenv"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			if got := sopsFormat(tt.file); got != tt.want {
+				t.Errorf("sopsFormat(%q) = %q, want %q", tt.file, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSOPS(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		format string
+		want   bool
+	}{
+		{name: "dotenv", data: "PORT=ENC[AES256_GCM,data:abc]\nsops_version=3.8.1\n", format: "dotenv", want: true},
+		{name: "plain dotenv", data: "PORT=8080\n# sops_version=3.8.1\n", format: "dotenv"},
+		{name: "yaml", data: "port: ENC[AES256_GCM,data:abc]\nsops:\n    version: 3.8.1\n", format: "yaml", want: true},
+		{name: "plain yaml", data: "sops: enabled\n", format: "yaml"},
+		{name: "json", data: `{"port":"ENC[AES256_GCM,data:abc]","sops":{"version":"3.8.1"}}`, format: "json", want: true},
+		{name: "plain json", data: `{"port":8080}`, format: "json"},
+		{name: "invalid json", data: `{"sops"`, format: "json"},
+		{name: "ini", data: "[app]\nport = ENC[AES256_GCM,data:abc]\n[sops]\nversion = 3.8.1\n", format: "ini", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSOPS([]byte(tt.data), tt.format); got != tt.want {
+				t.Errorf("isSOPS() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithSOPS(t *testing.T) {
+	// the fake sops binary checks the format and prints the decrypted config
+	dir := t.TempDir()
+	script := "#!/bin/sh\n[ \"$3\" = dotenv ] && [ \"$5\" = dotenv ] || exit 1\ncat >/dev/null\necho PORT=9090\n"
+	if err := os.WriteFile(filepath.Join(dir, "sops"), []byte(script), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	tests := []struct {
+		name string
+		data string
+		opts []Option
+		want int
+	}{
+		{name: "encrypted", data: "PORT=ENC[AES256_GCM,data:abc]\nsops_version=3.8.1\n", opts: []Option{WithSOPS()}, want: 9090},
+		{name: "plain", data: "PORT=8080\n", opts: []Option{WithSOPS()}, want: 8080},
+		{name: "without sops", data: "PORT=8080\nsops_version=3.8.1\n", want: 8080},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg struct {
+				Port int `mapstructure:"PORT"`
+			}
+			fsys := fstest.MapFS{".env": {Data: []byte(tt.data)}}
+			if err := LoadE(&cfg, append([]Option{WithFS(fsys), WithPrecedence(FileOnly)}, tt.opts...)...); err != nil {
+				t.Fatalf("LoadE() error = %v", err)
+			}
+			if cfg.Port != tt.want {
+				t.Errorf("Port = %d, want %d", cfg.Port, tt.want)
+			}
+		})
+	}
+}
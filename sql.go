@@ -0,0 +1,64 @@
+package env
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// SQL fetches the keys and the values from the rows of a settings table or of a query through database/sql,
+// so it works with any driver (e.g. Postgres or MySQL) that is registered by the application.
+type SQL struct {
+	// DB is the database of the settings.
+	DB *sql.DB
+	// Query is the query that selects the keys and the values, it must return two columns (e.g.
+	// SELECT name, value FROM settings WHERE app = $1). It defaults to a query that selects the KeyColumn
+	// and the ValueColumn of all the rows of the Table.
+	Query string
+	// Args are the arguments of the Query.
+	Args []any
+	// Table is the table of the settings when the Query is empty, it is not escaped.
+	Table string
+	// KeyColumn is the column of the keys when the Query is empty, it defaults to name and it is not escaped.
+	KeyColumn string
+	// ValueColumn is the column of the values when the Query is empty, it defaults to value and it is not
+	// escaped.
+	ValueColumn string
+}
+
+// Fetch fetches the keys and the values from the database, rows with NULL values are skipped.
+func (s *SQL) Fetch(ctx context.Context) (map[string]string, error) {
+	query := s.Query
+	if query == "" {
+		if s.Table == "" {
+			return nil, fmt.Errorf("either the query or the table of the settings is required")
+		}
+
+		query = fmt.Sprintf("SELECT %s, %s FROM %s", cmp.Or(s.KeyColumn, "name"), cmp.Or(s.ValueColumn, "value"), s.Table)
+	}
+
+	rows, err := s.DB.QueryContext(ctx, query, s.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the settings: %w", err)
+	}
+	defer rows.Close()
+
+	m := make(map[string]string)
+	for rows.Next() {
+		var key string
+		var value sql.NullString
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan the settings: %w", err)
+		}
+
+		if value.Valid {
+			m[key] = value.String
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read the settings: %w", err)
+	}
+
+	return m, nil
+}
@@ -0,0 +1,122 @@
+package env
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeDB is a database/sql driver that records the queries and answers them with rows of keys and values.
+type fakeDB struct {
+	rows    [][2]any
+	err     error
+	queries []string
+	args    [][]driver.NamedValue
+}
+
+func (d *fakeDB) Connect(context.Context) (driver.Conn, error) { return d, nil }
+func (d *fakeDB) Driver() driver.Driver                        { return nil }
+func (d *fakeDB) Prepare(string) (driver.Stmt, error)          { return nil, errors.ErrUnsupported }
+func (d *fakeDB) Close() error                                 { return nil }
+func (d *fakeDB) Begin() (driver.Tx, error)                    { return nil, errors.ErrUnsupported }
+
+func (d *fakeDB) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	d.queries = append(d.queries, query)
+	d.args = append(d.args, args)
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	return &fakeRows{rows: d.rows}, nil
+}
+
+type fakeRows struct {
+	rows [][2]any
+}
+
+func (r *fakeRows) Columns() []string { return []string{"name", "value"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+
+	dest[0], dest[1] = r.rows[0][0], r.rows[0][1]
+	r.rows = r.rows[1:]
+
+	return nil
+}
+
+func TestSQLFetch(t *testing.T) {
+	rows := [][2]any{{"DB_HOST", "localhost"}, {"DB_PORT", "5432"}, {"DB_PASSWORD", nil}}
+
+	tests := []struct {
+		name      string
+		sql       SQL
+		err       error
+		want      map[string]string
+		wantQuery string
+		wantArgs  int
+		wantErr   string
+	}{
+		{
+			name:      "table",
+			sql:       SQL{Table: "settings"},
+			want:      map[string]string{"DB_HOST": "localhost", "DB_PORT": "5432"},
+			wantQuery: "SELECT name, value FROM settings",
+		},
+		{
+			name:      "columns",
+			sql:       SQL{Table: "config", KeyColumn: "k", ValueColumn: "v"},
+			want:      map[string]string{"DB_HOST": "localhost", "DB_PORT": "5432"},
+			wantQuery: "SELECT k, v FROM config",
+		},
+		{
+			name:      "query",
+			sql:       SQL{Query: "SELECT name, value FROM settings WHERE app = $1", Args: []any{"api"}},
+			want:      map[string]string{"DB_HOST": "localhost", "DB_PORT": "5432"},
+			wantQuery: "SELECT name, value FROM settings WHERE app = $1",
+			wantArgs:  1,
+		},
+		{name: "no table", wantErr: "either the query or the table"},
+		{
+			name:      "query error",
+			sql:       SQL{Table: "settings"},
+			err:       errors.New("relation does not exist"),
+			wantQuery: "SELECT name, value FROM settings",
+			wantErr:   "failed to query the settings: relation does not exist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDB{rows: rows, err: tt.err}
+			s := tt.sql
+			s.DB = sql.OpenDB(db)
+			defer s.DB.Close()
+
+			got, err := s.Fetch(context.Background())
+			if tt.wantQuery != "" && (len(db.queries) != 1 || db.queries[0] != tt.wantQuery || len(db.args[0]) != tt.wantArgs) {
+				t.Errorf("queries = %q with args %v, want %q with %d args", db.queries, db.args, tt.wantQuery, tt.wantArgs)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Fetch() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fetch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package env
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+)
+
+// Store holds the current config and is the target of the reloads, its readers always get a complete config
+// without taking locks. Every config that is swapped in gets a new generation, which increases monotonically.
+type Store[T any] struct {
+	v atomic.Value
+	r *reloader[T]
+}
+
+// storeEntry is a config of a store with its generation.
+type storeEntry[T any] struct {
+	config     T
+	generation uint64
+}
+
+// NewStore loads the config with the given options into a new store, the options are reused by the reloads.
+func NewStore[T any](ctx context.Context, opts ...Option) (*Store[T], error) {
+	s := &Store[T]{}
+
+	o := newOptions(append([]Option{withContext(ctx)}, opts...)...)
+	envMap, fileMap, err := loadEnvMap(o)
+	if err != nil {
+		return nil, err
+	}
+
+	var config T
+	p, err := load(o, &config, envMap, fileMap)
+	if err != nil {
+		return nil, err
+	}
+	s.v.Store(&storeEntry[T]{config: config, generation: 1})
+
+	s.r = &reloader[T]{
+		opts: opts,
+		o:    newOptions(opts...),
+		current: func() T {
+			config, _ := s.Get()
+			return config
+		},
+		swap: func(next T) {
+			_, generation := s.Get()
+			s.v.Store(&storeEntry[T]{config: next, generation: generation + 1})
+		},
+		// the reloads are compared with the values of the initial load, so that the first change set only
+		// contains the keys that changed
+		hash:   hashValues(envMap),
+		values: p.values(),
+	}
+
+	return s, nil
+}
+
+// Get returns the current config and its generation.
+func (s *Store[T]) Get() (T, uint64) {
+	e := s.v.Load().(*storeEntry[T])
+	return e.config, e.generation
+}
+
+// Reload loads the config again and swaps it in if it changed, the current config is kept and the error is
+// returned if the new config fails to load.
+func (s *Store[T]) Reload(ctx context.Context) error {
+	return s.r.reload(ctx)
+}
+
+// Watch reloads the config whenever the config files, the providers or the signals trigger a reload, in the
+// same way as the Watch function, until the context is done.
+func (s *Store[T]) Watch(ctx context.Context) error {
+	return s.r.watch(ctx)
+}
+
+// OnChange registers a callback that is called with the old and the new value of the given field whenever it
+// changes on a reload, in the same way as the OnChange option. The callback must be a function that takes two
+// arguments of the type of the field (e.g. func(old, new string)), OnChange panics otherwise.
+func (s *Store[T]) OnChange(field string, fn any) {
+	f := reflect.ValueOf(fn)
+	if f.Kind() != reflect.Func || f.Type().NumIn() != 2 || f.Type().In(0) != f.Type().In(1) {
+		panic(fmt.Sprintf("env: the callback of the field %s must be a func(old, new V) but it is %T", field, fn))
+	}
+
+	s.r.mu.Lock()
+	defer s.r.mu.Unlock()
+
+	s.r.o.fieldCallbacks = append(s.r.o.fieldCallbacks, fieldCallback{
+		field: field,
+		call: func(old, new reflect.Value) error {
+			if old.Type() != f.Type().In(0) {
+				return fmt.Errorf("the callback of the field %s expects %s but the field is %s", field, f.Type().In(0), old.Type())
+			}
+
+			f.Call([]reflect.Value{old, new})
+			return nil
+		},
+	})
+}
+
+// Subscribe returns a channel that receives a ChangeSet whenever a reload changes the config. The change sets
+// are dropped if the channel is full.
+func (s *Store[T]) Subscribe() <-chan ChangeSet {
+	ch := make(chan ChangeSet, 16)
+
+	s.r.mu.Lock()
+	defer s.r.mu.Unlock()
+
+	WithSubscriber(ch)(s.r.o)
+
+	return ch
+}
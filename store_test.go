@@ -0,0 +1,191 @@
+package env
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStoreReloadChangeSet(t *testing.T) {
+	type config struct {
+		A string `mapstructure:"A"`
+		B string `mapstructure:"B"`
+		C string `mapstructure:"C"`
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	if err := os.WriteFile(path, []byte("A=1\nB=2\nC=3\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewStore[config](context.Background(), WithPath(dir), WithPrecedence(FileOnly))
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	changes := s.Subscribe()
+
+	if err := os.WriteFile(path, []byte("A=10\nB=2\nC=3\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	want := ChangeSet{Modified: []Change{{Key: "A", Old: "1", New: "10"}}}
+	select {
+	case got := <-changes:
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ChangeSet = %+v, want %+v", got, want)
+		}
+	default:
+		t.Fatal("no ChangeSet was published")
+	}
+
+	cfg, generation := s.Get()
+	if cfg.A != "10" || generation != 2 {
+		t.Errorf("Get() = %+v, %d, want A=10 and generation 2", cfg, generation)
+	}
+}
+
+func TestStoreReloadUnchanged(t *testing.T) {
+	type config struct {
+		A string `mapstructure:"A"`
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("A=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewStore[config](context.Background(), WithPath(dir), WithPrecedence(FileOnly))
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	changes := s.Subscribe()
+
+	if err := s.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	select {
+	case got := <-changes:
+		t.Errorf("unexpected ChangeSet %+v", got)
+	default:
+	}
+	if _, generation := s.Get(); generation != 1 {
+		t.Errorf("generation = %d, want 1", generation)
+	}
+}
+
+func TestStoreGenerations(t *testing.T) {
+	type config struct {
+		Host string `mapstructure:"HOST"`
+		Port int    `mapstructure:"PORT"`
+	}
+
+	tests := []struct {
+		name           string
+		values         []map[string]string
+		want           config
+		wantGeneration uint64
+		wantErr        bool
+	}{
+		{name: "unchanged", values: []map[string]string{{"HOST": "a", "PORT": "80"}}, want: config{Host: "a", Port: 80}, wantGeneration: 1},
+		{
+			name:           "changed twice",
+			values:         []map[string]string{{"HOST": "b", "PORT": "80"}, {"HOST": "c", "PORT": "80"}},
+			want:           config{Host: "c", Port: 80},
+			wantGeneration: 3,
+		},
+		{
+			name:           "invalid config is kept out",
+			values:         []map[string]string{{"HOST": "b", "PORT": "eighty"}},
+			want:           config{Host: "a", Port: 80},
+			wantGeneration: 1,
+			wantErr:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &mutableProvider{values: map[string]string{"HOST": "a", "PORT": "80"}}
+			s, err := NewStore[config](context.Background(), withSource(p))
+			if err != nil {
+				t.Fatalf("NewStore() error = %v", err)
+			}
+
+			for _, values := range tt.values {
+				p.set(values)
+				if err := s.Reload(context.Background()); (err != nil) != tt.wantErr {
+					t.Errorf("Reload() error = %v, want error %v", err, tt.wantErr)
+				}
+			}
+
+			got, generation := s.Get()
+			if got != tt.want || generation != tt.wantGeneration {
+				t.Errorf("Get() = %+v, %d, want %+v, %d", got, generation, tt.want, tt.wantGeneration)
+			}
+		})
+	}
+}
+
+func TestStoreOnChange(t *testing.T) {
+	type config struct {
+		Host string `mapstructure:"HOST"`
+	}
+
+	tests := []struct {
+		name      string
+		fn        func(calls *[]string) any
+		want      []string
+		wantPanic bool
+	}{
+		{
+			name: "callback",
+			fn: func(calls *[]string) any {
+				return func(old, new string) { *calls = append(*calls, old+" -> "+new) }
+			},
+			want: []string{"a -> b"},
+		},
+		{
+			name: "type mismatch",
+			fn: func(calls *[]string) any {
+				return func(_, _ int) { *calls = append(*calls, "called") }
+			},
+		},
+		{name: "not a function", fn: func(*[]string) any { return "HOST" }, wantPanic: true},
+		{name: "wrong arguments", fn: func(*[]string) any { return func(string, int) {} }, wantPanic: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &mutableProvider{values: map[string]string{"HOST": "a"}}
+			s, err := NewStore[config](context.Background(), withSource(p))
+			if err != nil {
+				t.Fatalf("NewStore() error = %v", err)
+			}
+
+			var calls []string
+			func() {
+				defer func() {
+					if v := recover(); (v != nil) != tt.wantPanic {
+						t.Errorf("OnChange() panic = %v, want panic %v", v, tt.wantPanic)
+					}
+				}()
+
+				s.OnChange("Host", tt.fn(&calls))
+			}()
+
+			p.set(map[string]string{"HOST": "b"})
+			if err := s.Reload(context.Background()); err != nil {
+				t.Fatalf("Reload() error = %v", err)
+			}
+			if !reflect.DeepEqual(calls, tt.want) {
+				t.Errorf("calls = %q, want %q", calls, tt.want)
+			}
+		})
+	}
+}
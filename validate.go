@@ -0,0 +1,179 @@
+package env
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ErrInvalid is the error of a field whose value violates a rule of its validate tag.
+var ErrInvalid = errors.New("invalid environment variable")
+
+// validate validates the structs with the rules in the validate tags of their fields, it caches the rules
+// of the struct types.
+var validate = validator.New()
+
+// ValidationError is the error of a field whose value violates a rule of its validate tag, it wraps ErrInvalid.
+type ValidationError struct {
+	// Key is the key of the field (e.g. PORT).
+	Key string
+	// Rule is the rule that is violated (e.g. min).
+	Rule string
+	// Param is the parameter of the rule (e.g. 1 for min=1).
+	Param string
+}
+
+// Error returns the error message of the violation.
+func (e *ValidationError) Error() string {
+	rule := e.Rule
+	if e.Param != "" {
+		rule += "=" + e.Param
+	}
+
+	return fmt.Sprintf("%s: %s violates the %s rule", ErrInvalid, e.Key, rule)
+}
+
+// Unwrap returns ErrInvalid.
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalid
+}
+
+// Validator is implemented by the structs that check their own invariants (e.g. the certificate and the key
+// must both be set), Validate is called on the struct and its nested structs after they are loaded and its
+// error is returned with the errors of the fields.
+type Validator interface {
+	Validate() error
+}
+
+// parsedField is a field of the parsed struct with its key.
+type parsedField struct {
+	key   string
+	field reflect.StructField
+}
+
+// validate validates the parsed struct that the given pointer points to with the rules in the validate tags
+// of its fields and records the violations as the errors of the fields, the fields that already failed to
+// load are skipped.
+func (p *parser) validate(e any) {
+	err := validate.Struct(e)
+	if err == nil {
+		return
+	}
+
+	var violations validator.ValidationErrors
+	if !errors.As(err, &violations) {
+		p.errs = append(p.errs, FieldError{Err: fmt.Errorf("failed to validate the environment variables: %w", err)})
+		return
+	}
+
+	root := reflect.TypeOf(e).Elem()
+	for _, v := range violations {
+		// the namespace starts with the name of the type of the struct (e.g. Config.DB.Port)
+		_, fieldName, _ := strings.Cut(v.StructNamespace(), ".")
+		if p.failed(fieldName) {
+			continue
+		}
+
+		// the fields inside the values of envJSON fields are not parsed, they are reported with the key of the
+		// envJSON field and their path in it (e.g. OPTIONS.Limit)
+		f, rest := p.parsedAncestor(fieldName)
+		key := cmp.Or(f.key, fieldName) + rest
+
+		value := ""
+		if v.Value() != nil && !(rest != "" && isSecret(f.field)) {
+			value = fmt.Sprint(v.Value())
+		}
+
+		verr := &ValidationError{Key: key, Rule: v.Tag(), Param: v.Param()}
+		field, ok := structField(root, fieldName)
+		if !ok {
+			p.errs = append(p.errs, FieldError{Key: f.key, FieldName: fieldName, Err: verr})
+			continue
+		}
+
+		p.violate(field, fieldName, f.key, value, verr)
+	}
+}
+
+// parsedAncestor returns the parsed field with the given name, or the closest parsed field that it is nested
+// in with the rest of the name (e.g. .Limit for Options.Limit). A zero field is returned if there is none.
+func (p *parser) parsedAncestor(fieldName string) (parsedField, string) {
+	for name := fieldName; name != ""; {
+		if f, ok := p.fields[name]; ok {
+			return f, fieldName[len(name):]
+		}
+
+		i := strings.LastIndexAny(name, ".[")
+		if i == -1 {
+			break
+		}
+		name = name[:i]
+	}
+
+	return parsedField{}, ""
+}
+
+// structField returns the field of the given struct type with the given name, which can be nested in structs
+// and in the elements of slices, arrays and maps (e.g. Servers[0].Port).
+func structField(t reflect.Type, fieldName string) (reflect.StructField, bool) {
+	var field reflect.StructField
+	for _, name := range strings.Split(fieldName, ".") {
+		name, _, _ = strings.Cut(name, "[")
+		for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return reflect.StructField{}, false
+		}
+
+		var ok bool
+		field, ok = t.FieldByName(name)
+		if !ok {
+			return reflect.StructField{}, false
+		}
+		t = field.Type
+	}
+
+	return field, true
+}
+
+// validateHooks calls the Validate method of the given struct and its nested structs that implement Validator,
+// the nested structs are validated first. The errors are recorded with the path of the structs.
+func (p *parser) validateHooks(v reflect.Value, path string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || p.skipped(field) || !isNested(field.Type) {
+			continue
+		}
+		if isJSON, _ := boolTag(field, "envJSON"); isJSON {
+			continue
+		}
+
+		p.validateHooks(v.Field(i), path+field.Name+".")
+	}
+
+	h, ok := v.Addr().Interface().(Validator)
+	if !ok {
+		return
+	}
+
+	if err := h.Validate(); err != nil {
+		p.errs = append(p.errs, FieldError{FieldName: strings.TrimSuffix(path, "."), Err: err})
+	}
+}
+
+// failed reports whether the field with the given name already failed to load.
+func (p *parser) failed(fieldName string) bool {
+	for _, err := range p.errs {
+		if err.FieldName == fieldName {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,213 @@
+package env
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateViolations(t *testing.T) {
+	type plainConfig struct {
+		Port int `mapstructure:"PORT" validate:"min=1"`
+	}
+	type options struct {
+		Limit int `json:"limit" validate:"max=5"`
+	}
+	type jsonConfig struct {
+		Options options `mapstructure:"OPTIONS" envJSON:"true"`
+	}
+	type secretJSONConfig struct {
+		Options options `mapstructure:"OPTIONS" envJSON:"true" secret:"true"`
+	}
+	type mapConfig struct {
+		Limits map[string]int `prefix:"LIMIT_" validate:"min=2"`
+	}
+
+	tests := []struct {
+		name          string
+		load          func() error
+		wantKey       string
+		wantFieldName string
+		wantValue     string
+		wantErr       string
+	}{
+		{
+			name: "field",
+			load: func() error {
+				return LoadReader(strings.NewReader("PORT=0"), &plainConfig{}, WithPrecedence(FileOnly))
+			},
+			wantKey:       "PORT",
+			wantFieldName: "Port",
+			wantValue:     "0",
+			wantErr:       "PORT violates the min=1 rule",
+		},
+		{
+			name: "json struct field",
+			load: func() error {
+				return LoadReader(strings.NewReader(`OPTIONS={"limit":10}`), &jsonConfig{}, WithPrecedence(FileOnly))
+			},
+			wantKey:       "OPTIONS",
+			wantFieldName: "Options.Limit",
+			wantValue:     "10",
+			wantErr:       "OPTIONS.Limit violates the max=5 rule",
+		},
+		{
+			name: "secret json struct field",
+			load: func() error {
+				return LoadReader(strings.NewReader(`OPTIONS={"limit":10}`), &secretJSONConfig{}, WithPrecedence(FileOnly))
+			},
+			wantKey:       "OPTIONS",
+			wantFieldName: "Options.Limit",
+			wantErr:       "OPTIONS.Limit violates the max=5 rule",
+		},
+		{
+			name: "prefixed map",
+			load: func() error {
+				return LoadReader(strings.NewReader("LIMIT_A=1"), &mapConfig{}, WithPrecedence(FileOnly))
+			},
+			wantKey:       "LIMIT_*",
+			wantFieldName: "Limits",
+			wantValue:     "map[A:1]",
+			wantErr:       "LIMIT_* violates the min=2 rule",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.load()
+
+			var fieldErrs FieldErrors
+			if !errors.As(err, &fieldErrs) || len(fieldErrs) != 1 || !errors.Is(err, ErrInvalid) {
+				t.Fatalf("LoadReader() error = %v, want one invalid field", err)
+			}
+
+			got := fieldErrs[0]
+			if got.Key != tt.wantKey || got.FieldName != tt.wantFieldName || got.Value != tt.wantValue || !strings.Contains(got.Error(), tt.wantErr) {
+				t.Errorf("FieldError = %+v, want key %q, field %q, value %q and error %q", got, tt.wantKey, tt.wantFieldName, tt.wantValue, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	type database struct {
+		URL string `mapstructure:"URL" validate:"required,url"`
+	}
+	type config struct {
+		Name     string   `mapstructure:"NAME" validate:"required"`
+		Port     int      `mapstructure:"PORT" validate:"min=1,max=65535"`
+		Database database `prefix:"DB_"`
+	}
+
+	tests := []struct {
+		name     string
+		src      string
+		wantErrs []string
+	}{
+		{name: "valid", src: "NAME=api\nPORT=80\nDB_URL=postgres://localhost"},
+		{name: "required", src: "PORT=80\nDB_URL=postgres://localhost", wantErrs: []string{"NAME violates the required rule"}},
+		{name: "url", src: "NAME=api\nPORT=80\nDB_URL=localhost", wantErrs: []string{"DB_URL violates the url rule"}},
+		{
+			name:     "several rules",
+			src:      "NAME=api\nPORT=70000",
+			wantErrs: []string{"PORT violates the max=65535 rule", "DB_URL violates the required rule"},
+		},
+		{name: "failed field", src: "NAME=api\nPORT=eighty\nDB_URL=postgres://localhost", wantErrs: []string{"PORT"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config
+			err := LoadReader(strings.NewReader(tt.src), &cfg, WithPrecedence(FileOnly))
+
+			if len(tt.wantErrs) == 0 {
+				if err != nil {
+					t.Fatalf("LoadReader() error = %v", err)
+				}
+				return
+			}
+
+			var fieldErrs FieldErrors
+			if !errors.As(err, &fieldErrs) || len(fieldErrs) != len(tt.wantErrs) {
+				t.Fatalf("LoadReader() error = %v, want %d field errors", err, len(tt.wantErrs))
+			}
+			for i, want := range tt.wantErrs {
+				if !strings.Contains(fieldErrs[i].Error(), want) {
+					t.Errorf("field error %d = %v, want %q", i, fieldErrs[i], want)
+				}
+			}
+		})
+	}
+}
+
+// tlsConfig requires the certificate and the key to be set together.
+type tlsConfig struct {
+	Cert string `mapstructure:"CERT"`
+	Key  string `mapstructure:"KEY"`
+}
+
+// Validate checks that the certificate and the key are set together.
+func (c *tlsConfig) Validate() error {
+	if (c.Cert == "") != (c.Key == "") {
+		return errors.New("the certificate and the key must both be set")
+	}
+
+	return nil
+}
+
+// serverConfig requires the port to be set if TLS is enabled.
+type serverConfig struct {
+	Port int       `mapstructure:"PORT"`
+	TLS  tlsConfig `prefix:"TLS_"`
+}
+
+// Validate checks that the port is set if TLS is enabled.
+func (c *serverConfig) Validate() error {
+	if c.TLS.Cert != "" && c.Port == 0 {
+		return errors.New("the port must be set with TLS")
+	}
+
+	return nil
+}
+
+func TestValidateHooks(t *testing.T) {
+	tests := []struct {
+		name          string
+		src           string
+		wantFieldName []string
+		wantErrs      []string
+	}{
+		{name: "valid", src: "PORT=443\nTLS_CERT=cert\nTLS_KEY=key"},
+		{name: "nested struct", src: "PORT=443\nTLS_CERT=cert", wantFieldName: []string{"TLS"}, wantErrs: []string{"the certificate and the key"}},
+		{name: "root struct", src: "TLS_CERT=cert\nTLS_KEY=key", wantFieldName: []string{""}, wantErrs: []string{"the port must be set"}},
+		{
+			name:          "nested structs first",
+			src:           "TLS_CERT=cert",
+			wantFieldName: []string{"TLS", ""},
+			wantErrs:      []string{"the certificate and the key", "the port must be set"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg serverConfig
+			err := LoadReader(strings.NewReader(tt.src), &cfg, WithPrecedence(FileOnly))
+			if len(tt.wantErrs) == 0 {
+				if err != nil {
+					t.Fatalf("LoadReader() error = %v", err)
+				}
+				return
+			}
+
+			var fieldErrs FieldErrors
+			if !errors.As(err, &fieldErrs) || len(fieldErrs) != len(tt.wantErrs) {
+				t.Fatalf("LoadReader() error = %v, want %d field errors", err, len(tt.wantErrs))
+			}
+			for i, want := range tt.wantErrs {
+				if fieldErrs[i].FieldName != tt.wantFieldName[i] || !strings.Contains(fieldErrs[i].Error(), want) {
+					t.Errorf("field error %d = %+v, want field %q and error %q", i, fieldErrs[i], tt.wantFieldName[i], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package env
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// defaultVaultAddress is the address of Vault when neither the Address nor VAULT_ADDR is set.
+const defaultVaultAddress = "https://127.0.0.1:8200"
+
+// Vault fetches secrets from the KV v2 secrets engine of HashiCorp Vault. It merges the secrets at the
+// Paths when it is used as a provider and resolves the fields that are tagged with a path and a key
+// (e.g. vault:"secret/data/app#db_password") when it is registered with WithVault.
+type Vault struct {
+	// Address is the address of Vault, it defaults to VAULT_ADDR.
+	Address string
+	// Token is the token that authenticates the requests, it defaults to VAULT_TOKEN.
+	Token string
+	// Namespace is the namespace of the secrets (Vault Enterprise), it defaults to VAULT_NAMESPACE.
+	Namespace string
+	// Paths are the API paths of the secrets that are merged (e.g. secret/data/app), the keys of the
+	// secrets are upper cased and the keys of the later paths override the keys of the earlier ones.
+	Paths []string
+	// Dynamic are the dynamic secrets (e.g. database credentials) that are issued with leases, the
+	// credentials are reused until their leases expire and RenewLeases keeps them alive.
+	Dynamic []VaultDynamicSecret
+	// Client is the client that sends the requests, it defaults to a client with a 10 second timeout.
+	Client *http.Client
+
+	mu     sync.Mutex
+	leases map[string]*vaultLease
+}
+
+// WithVault resolves the fields that are tagged with vault with the given Vault.
+func WithVault(v *Vault) Option {
+	return WithResolver("vault", v)
+}
+
+// Fetch fetches the secrets at the Paths and the credentials of the dynamic secrets.
+func (v *Vault) Fetch(ctx context.Context) (map[string]string, error) {
+	m := make(map[string]string)
+	for _, path := range v.Paths {
+		data, err := v.read(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+
+		for key, value := range data {
+			m[strings.ToUpper(key)] = stringify(value)
+		}
+	}
+
+	dynamic, err := v.fetchDynamic(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return merge(m, dynamic), nil
+}
+
+// Resolve returns the value of the key of the secret that the given reference (path#key) points to.
+func (v *Vault) Resolve(ctx context.Context, ref string) (string, error) {
+	path, key, ok := strings.Cut(ref, "#")
+	if !ok || key == "" {
+		return "", fmt.Errorf("invalid Vault reference %s, expected path#key", ref)
+	}
+
+	data, err := v.read(ctx, path)
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found in the Vault secret %s", key, path)
+	}
+
+	return stringify(value), nil
+}
+
+// read returns the data of the KV v2 secret at the given path.
+func (v *Vault) read(ctx context.Context, path string) (map[string]any, error) {
+	var res struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := v.request(ctx, http.MethodGet, path, nil, &res); err != nil {
+		return nil, fmt.Errorf("failed to read the Vault secret %s: %w", path, err)
+	}
+
+	return res.Data.Data, nil
+}
+
+// request sends a request with the given body to the given API path of Vault and decodes the response
+// into res.
+func (v *Vault) request(ctx context.Context, method, path string, body, res any) error {
+	address := cmp.Or(v.Address, os.Getenv("VAULT_ADDR"), defaultVaultAddress)
+
+	header := http.Header{}
+	if token := cmp.Or(v.Token, os.Getenv("VAULT_TOKEN")); token != "" {
+		header.Set("X-Vault-Token", token)
+	}
+	if namespace := cmp.Or(v.Namespace, os.Getenv("VAULT_NAMESPACE")); namespace != "" {
+		header.Set("X-Vault-Namespace", namespace)
+	}
+
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+
+	return doJSON(ctx, v.Client, method, strings.TrimSuffix(address, "/")+"/v1/"+strings.TrimPrefix(path, "/"), header, r, res)
+}
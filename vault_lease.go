@@ -0,0 +1,188 @@
+package env
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// vaultRetryInterval is the interval between the attempts to issue new credentials after a failure.
+const vaultRetryInterval = 10 * time.Second
+
+// VaultDynamicSecret is a secret that Vault issues with a lease, e.g. the credentials of a database role.
+type VaultDynamicSecret struct {
+	// Path is the API path of the secret, e.g. database/creds/readonly.
+	Path string
+	// Prefix is prepended to the upper cased keys of the secret (e.g. DB_ maps the username of the
+	// credentials to DB_USERNAME).
+	Prefix string
+}
+
+// vaultLease is the lease of the credentials of a dynamic secret.
+type vaultLease struct {
+	id        string
+	renewable bool
+	duration  time.Duration
+	renewed   time.Time
+	values    map[string]string
+}
+
+// expired reports whether the lease has expired.
+func (l *vaultLease) expired() bool {
+	return l.duration > 0 && time.Since(l.renewed) >= l.duration
+}
+
+// renewAt returns the time at which the lease should be renewed, two thirds into its duration.
+func (l *vaultLease) renewAt() time.Time {
+	return l.renewed.Add(l.duration * 2 / 3)
+}
+
+// fetchDynamic returns the credentials of the dynamic secrets, new credentials are only issued for the
+// secrets whose leases have expired.
+func (v *Vault) fetchDynamic(ctx context.Context) (map[string]string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	m := make(map[string]string)
+	for _, secret := range v.Dynamic {
+		lease, ok := v.leases[secret.Path]
+		if !ok || lease.expired() {
+			var err error
+			if lease, err = v.issue(ctx, secret); err != nil {
+				return nil, err
+			}
+		}
+
+		maps.Copy(m, lease.values)
+	}
+
+	return m, nil
+}
+
+// issue issues new credentials for the given dynamic secret, it must be called with the mutex held.
+func (v *Vault) issue(ctx context.Context, secret VaultDynamicSecret) (*vaultLease, error) {
+	var res struct {
+		LeaseID       string         `json:"lease_id"`
+		LeaseDuration int            `json:"lease_duration"`
+		Renewable     bool           `json:"renewable"`
+		Data          map[string]any `json:"data"`
+	}
+	if err := v.request(ctx, http.MethodGet, secret.Path, nil, &res); err != nil {
+		return nil, fmt.Errorf("failed to issue the Vault secret %s: %w", secret.Path, err)
+	}
+
+	lease := &vaultLease{
+		id:        res.LeaseID,
+		renewable: res.Renewable,
+		duration:  time.Duration(res.LeaseDuration) * time.Second,
+		renewed:   time.Now(),
+		values:    make(map[string]string, len(res.Data)),
+	}
+	for key, value := range res.Data {
+		lease.values[secret.Prefix+strings.ToUpper(key)] = stringify(value)
+	}
+
+	if v.leases == nil {
+		v.leases = make(map[string]*vaultLease)
+	}
+	v.leases[secret.Path] = lease
+
+	return lease, nil
+}
+
+// renew renews the given lease and reports whether it was extended for at least a third of its
+// duration, it must be called with the mutex held.
+func (v *Vault) renew(ctx context.Context, lease *vaultLease) bool {
+	if !lease.renewable || lease.id == "" {
+		return false
+	}
+
+	var res struct {
+		LeaseDuration int `json:"lease_duration"`
+	}
+	body := map[string]any{"lease_id": lease.id, "increment": int(lease.duration / time.Second)}
+	if err := v.request(ctx, http.MethodPut, "sys/leases/renew", body, &res); err != nil {
+		return false
+	}
+
+	// the lease can not be extended past the max TTL, so new credentials are issued before it expires
+	duration := time.Duration(res.LeaseDuration) * time.Second
+	if duration < lease.duration/3 {
+		return false
+	}
+
+	lease.renewed = time.Now()
+	lease.duration = duration
+
+	return true
+}
+
+// RenewLeases renews the leases of the dynamic secrets in the background until the context is done. Leases
+// are renewed two thirds into their durations, new credentials are issued when a lease can not be renewed
+// (e.g. when it has reached its max TTL) and fn is called with the credentials of all the dynamic secrets
+// whenever new credentials are issued, so that the config can be reloaded. Errors are passed to fn.
+func (v *Vault) RenewLeases(ctx context.Context, fn func(map[string]string, error)) error {
+	if _, err := v.fetchDynamic(ctx); err != nil {
+		fn(nil, err)
+	}
+
+	var retry time.Time
+	for {
+		v.mu.Lock()
+		next := time.Now().Add(time.Minute)
+		for _, lease := range v.leases {
+			if lease.duration > 0 && lease.renewAt().Before(next) {
+				next = lease.renewAt()
+			}
+		}
+		v.mu.Unlock()
+
+		// back off after a failure so that the leases that can not be renewed are not retried in a busy loop
+		if next.Before(retry) {
+			next = retry
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Until(next)):
+		}
+
+		rotated, err := v.renewDue(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			retry = time.Now().Add(vaultRetryInterval)
+		}
+		if rotated || err != nil {
+			values, _ := v.fetchDynamic(ctx)
+			fn(values, err)
+		}
+	}
+}
+
+// renewDue renews the leases that are due and issues new credentials for the leases that can not be
+// renewed, it reports whether new credentials were issued.
+func (v *Vault) renewDue(ctx context.Context) (bool, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	rotated := false
+	for _, secret := range v.Dynamic {
+		lease, ok := v.leases[secret.Path]
+		if ok && (lease.duration == 0 || time.Now().Before(lease.renewAt()) || v.renew(ctx, lease)) {
+			continue
+		}
+
+		if _, err := v.issue(ctx, secret); err != nil {
+			return rotated, err
+		}
+		rotated = true
+	}
+
+	return rotated, nil
+}
@@ -0,0 +1,102 @@
+package env
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// dynamicVault is a fake Vault that issues numbered database credentials and renews their leases up to the
+// given duration.
+type dynamicVault struct {
+	mu      sync.Mutex
+	issued  int
+	renewed int
+	// renewTo is the duration in seconds that the leases are renewed to
+	renewTo int
+}
+
+// ServeHTTP issues credentials on database/creds/app and renews the leases on sys/leases/renew.
+func (v *dynamicVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/v1/database/creds/app":
+		v.issued++
+		json.NewEncoder(w).Encode(map[string]any{
+			"lease_id":       "database/creds/app/" + strconv.Itoa(v.issued),
+			"lease_duration": 3600,
+			"renewable":      true,
+			"data":           map[string]any{"username": "user-" + strconv.Itoa(v.issued), "password": "s3cret"},
+		})
+	case "/v1/sys/leases/renew":
+		v.renewed++
+		json.NewEncoder(w).Encode(map[string]any{"lease_duration": v.renewTo})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestVaultDynamicSecrets(t *testing.T) {
+	// the leases are issued for an hour and renewed two thirds into it
+	tests := []struct {
+		name        string
+		renewTo     int
+		age         time.Duration
+		wantRotated bool
+		wantIssued  int
+		wantRenewed int
+		wantUser    string
+	}{
+		{name: "not due", renewTo: 3600, age: time.Minute, wantIssued: 1, wantUser: "user-1"},
+		{name: "renewed", renewTo: 3600, age: 50 * time.Minute, wantIssued: 1, wantRenewed: 1, wantUser: "user-1"},
+		{name: "max ttl", renewTo: 60, age: 50 * time.Minute, wantRotated: true, wantIssued: 2, wantRenewed: 1, wantUser: "user-2"},
+		{name: "expired", renewTo: 3600, age: 2 * time.Hour, wantIssued: 2, wantUser: "user-2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &dynamicVault{renewTo: tt.renewTo}
+			srv := httptest.NewServer(fake)
+			defer srv.Close()
+
+			v := &Vault{Address: srv.URL, Token: "root", Dynamic: []VaultDynamicSecret{{Path: "database/creds/app", Prefix: "DB_"}}}
+			got, err := v.Fetch(context.Background())
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if want := map[string]string{"DB_USERNAME": "user-1", "DB_PASSWORD": "s3cret"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("Fetch() = %q, want %q", got, want)
+			}
+
+			v.leases["database/creds/app"].renewed = time.Now().Add(-tt.age)
+			rotated := false
+			if tt.age < time.Hour {
+				if rotated, err = v.renewDue(context.Background()); err != nil {
+					t.Fatalf("renewDue() error = %v", err)
+				}
+			}
+			// the credentials are reused by the fetches until their lease expires
+			if got, err = v.Fetch(context.Background()); err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+
+			if rotated != tt.wantRotated {
+				t.Errorf("renewDue() = %v, want %v", rotated, tt.wantRotated)
+			}
+			if fake.issued != tt.wantIssued || fake.renewed != tt.wantRenewed {
+				t.Errorf("issued, renewed = %d, %d, want %d, %d", fake.issued, fake.renewed, tt.wantIssued, tt.wantRenewed)
+			}
+			if got["DB_USERNAME"] != tt.wantUser {
+				t.Errorf("DB_USERNAME = %q, want %q", got["DB_USERNAME"], tt.wantUser)
+			}
+		})
+	}
+}
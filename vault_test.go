@@ -0,0 +1,129 @@
+package env
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// vaultServer returns a server that serves the given KV v2 secrets, keyed by their API paths, to the requests
+// with the root token in the app namespace.
+func vaultServer(t *testing.T, secrets map[string]map[string]any) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" || r.Header.Get("X-Vault-Namespace") != "app" {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+
+		data, ok := secrets[strings.TrimPrefix(r.URL.Path, "/v1/")]
+		if !ok {
+			http.Error(w, `{"errors":[]}`, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"data": data}})
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestVault(t *testing.T) {
+	srv := vaultServer(t, map[string]map[string]any{
+		"secret/data/app":    {"db_password": "s3cret", "port": 8080},
+		"secret/data/shared": {"db_password": "shared", "api_key": "key"},
+	})
+
+	t.Run("fetch", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			paths   []string
+			token   string
+			want    map[string]string
+			wantErr string
+		}{
+			{name: "path", paths: []string{"secret/data/app"}, token: "root", want: map[string]string{"DB_PASSWORD": "s3cret", "PORT": "8080"}},
+			{
+				name:  "later paths override",
+				paths: []string{"/secret/data/app", "secret/data/shared"},
+				token: "root",
+				want:  map[string]string{"DB_PASSWORD": "shared", "PORT": "8080", "API_KEY": "key"},
+			},
+			{name: "missing secret", paths: []string{"secret/data/missing"}, token: "root", wantErr: "failed to read the Vault secret secret/data/missing"},
+			{name: "invalid token", paths: []string{"secret/data/app"}, token: "other", wantErr: "403"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				v := &Vault{Address: srv.URL, Token: tt.token, Namespace: "app", Paths: tt.paths}
+				got, err := v.Fetch(context.Background())
+				if tt.wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+						t.Errorf("Fetch() error = %v, want %q", err, tt.wantErr)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("Fetch() error = %v", err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Fetch() = %q, want %q", got, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("resolve", func(t *testing.T) {
+		t.Setenv("VAULT_ADDR", srv.URL)
+		t.Setenv("VAULT_TOKEN", "root")
+		t.Setenv("VAULT_NAMESPACE", "app")
+
+		tests := []struct {
+			name    string
+			ref     string
+			want    string
+			wantErr string
+		}{
+			{name: "key", ref: "secret/data/app#db_password", want: "s3cret"},
+			{name: "number", ref: "secret/data/app#port", want: "8080"},
+			{name: "without key", ref: "secret/data/app", wantErr: "expected path#key"},
+			{name: "missing key", ref: "secret/data/app#api_key", wantErr: "key api_key not found in the Vault secret secret/data/app"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got, err := (&Vault{}).Resolve(context.Background(), tt.ref)
+				if tt.wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+						t.Errorf("Resolve() error = %v, want %q", err, tt.wantErr)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("Resolve() error = %v", err)
+				}
+				if got != tt.want {
+					t.Errorf("Resolve() = %q, want %q", got, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("tag", func(t *testing.T) {
+		var cfg struct {
+			Password string `mapstructure:"DB_PASSWORD" vault:"secret/data/app#db_password"`
+		}
+		v := &Vault{Address: srv.URL, Token: "root", Namespace: "app"}
+		if err := LoadReader(strings.NewReader(""), &cfg, WithPrecedence(FileOnly), WithVault(v)); err != nil {
+			t.Fatalf("LoadReader() error = %v", err)
+		}
+		if cfg.Password != "s3cret" {
+			t.Errorf("Password = %q, want s3cret", cfg.Password)
+		}
+	})
+}
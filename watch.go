@@ -0,0 +1,321 @@
+package env
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is the delay between a change of a config file and the reload, so that the burst of events
+// of a single save (e.g. a write followed by a rename) only reloads the config once.
+const watchDebounce = 100 * time.Millisecond
+
+// Watch watches the config files and the providers for changes until the context is done and reloads the
+// config into the struct that the given pointer points to, which should already be loaded with the same
+// options. The new config is parsed and validated in full before it replaces the current one and onChange is
+// called with the old and the new config, a config that fails to load is logged and the current one is kept.
+// Watch is typically run in a goroutine, readers of the struct in other goroutines should copy the new config
+// in onChange instead of reading the struct directly.
+// The providers that implement Watcher are watched natively, the other providers (e.g. HTTP or S3) are only
+// watched if a poll interval is set with WithPollInterval. The config is reloaded on the signals that are set
+// with WithReloadSignal as well. The callbacks that are registered with OnChange are called after onChange for
+// the fields that changed. Like with LoadE, the keys that are absent leave the values of the struct alone.
+func Watch[T any](ctx context.Context, e *T, onChange func(old, new T), opts ...Option) error {
+	var mu sync.Mutex
+	r := &reloader[T]{
+		opts: opts,
+		o:    newOptions(opts...),
+		current: func() T {
+			mu.Lock()
+			defer mu.Unlock()
+
+			return *e
+		},
+		swap: func(next T) {
+			mu.Lock()
+			old := *e
+			*e = next
+			mu.Unlock()
+
+			if onChange != nil {
+				onChange(old, next)
+			}
+		},
+	}
+	r.seed(ctx)
+
+	return r.watch(ctx)
+}
+
+// reloader reloads the config with the options and swaps the new config in if it loads and it differs from
+// the current config.
+type reloader[T any] struct {
+	opts    []Option
+	o       *options
+	current func() T
+	swap    func(next T)
+
+	mu     sync.Mutex
+	hash   string
+	failed string
+	values map[string]string
+}
+
+// seed records the values that the current config is loaded from, so that the first reload only swaps the
+// config in if the config files or the providers changed. The values are recorded by the first reload instead
+// if they fail to load.
+func (r *reloader[T]) seed(ctx context.Context) {
+	o := newOptions(append([]Option{withContext(ctx)}, r.opts...)...)
+	envMap, fileMap, err := loadEnvMap(o)
+	if err != nil {
+		return
+	}
+
+	config := r.current()
+	p, err := load(o, &config, envMap, fileMap)
+	if err != nil {
+		return
+	}
+
+	r.hash, r.values = hashValues(envMap), p.values()
+}
+
+// reload loads a new config and swaps it in, the current config is kept if it fails to load or validate. The
+// new config is only parsed if the loaded values changed since the last reload, and the error of values that
+// already failed to load is only reported once.
+func (r *reloader[T]) reload(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, p, hash, err := r.candidate(ctx)
+	if err != nil {
+		if hash == "" || hash != r.failed {
+			r.o.log().Error("failed to reload the config, keeping the current config", "error", err)
+			if r.o.reloadError != nil {
+				r.o.reloadError(err)
+			}
+		}
+
+		r.failed = hash
+		return err
+	}
+	if p == nil {
+		return nil
+	}
+
+	values := p.values()
+	oldValues := r.values
+	r.hash, r.values, r.failed = hash, values, ""
+
+	old := r.current()
+	if reflect.DeepEqual(old, next) {
+		return nil
+	}
+
+	r.o.log().Info("reloaded the config")
+	r.swap(next)
+	notifyFields(r.o, old, next)
+	r.publish(p.diff(oldValues, values))
+
+	return nil
+}
+
+// candidate loads and validates a new config on top of a copy of the current config, so that the keys that
+// are absent keep their current values. The parser is nil if the loaded values did not change since the
+// last reload. The hash of the loaded values is returned even if the config fails to load, and a panic while
+// loading (e.g. in a custom decoder) is returned as an error so that it never crashes a running service.
+func (r *reloader[T]) candidate(ctx context.Context) (next T, p *parser, hash string, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("panic while loading the config: %v", v)
+		}
+	}()
+
+	o := newOptions(append([]Option{withContext(ctx)}, r.opts...)...)
+	next = r.current()
+
+	envMap, fileMap, err := loadEnvMap(o)
+	if err != nil {
+		return next, nil, "", err
+	}
+
+	hash = hashValues(envMap)
+	if hash == r.hash {
+		return next, nil, hash, nil
+	}
+
+	p, err = load(o, &next, envMap, fileMap)
+	if err != nil {
+		return next, nil, hash, err
+	}
+
+	return next, p, hash, nil
+}
+
+// watch reloads the config whenever the config files or the providers change until the context is done.
+func (r *reloader[T]) watch(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// the values that the next reloads are compared with are recorded by a first reload if they are not seeded
+	if r.hash == "" {
+		_ = r.reload(ctx)
+	}
+
+	errs := make(chan error, 1)
+	var wg sync.WaitGroup
+	run := func(fn func(context.Context) error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := fn(ctx); err != nil && ctx.Err() == nil {
+				select {
+				case errs <- err:
+				default:
+				}
+				cancel()
+			}
+		}()
+	}
+
+	run(r.watchFiles)
+	if r.o.pollInterval > 0 {
+		run(r.poll)
+	}
+	if len(r.o.reloadSignals) > 0 {
+		run(r.watchSignals)
+	}
+	for _, p := range r.o.providers {
+		if w, ok := p.(Watcher); ok {
+			run(func(ctx context.Context) error {
+				return w.Watch(ctx, func(_ map[string]string, err error) {
+					if err != nil {
+						r.o.log().Error("failed to watch the provider", "provider", fmt.Sprintf("%T", w), "error", err)
+						return
+					}
+
+					_ = r.reload(ctx)
+				})
+			})
+		}
+	}
+
+	<-ctx.Done()
+	wg.Wait()
+
+	select {
+	case err := <-errs:
+		return err
+	default:
+		return ctx.Err()
+	}
+}
+
+// poll reloads the config at the poll interval until the context is done, the config is only swapped when the
+// values of the config files or the providers changed.
+func (r *reloader[T]) poll(ctx context.Context) error {
+	ticker := time.NewTicker(r.o.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			_ = r.reload(ctx)
+		}
+	}
+}
+
+// watchFiles reloads the config whenever the config files change until the context is done.
+func (r *reloader[T]) watchFiles(ctx context.Context) error {
+	if r.o.fsys != nil || r.o.reader != nil || r.o.source != nil || r.o.precedence == EnvOnly {
+		return nil
+	}
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch the config files: %w", err)
+	}
+	defer w.Close()
+
+	// the directories are watched instead of the files so that the files that are replaced by a rename
+	// (as most editors and Kubernetes do) or created later are picked up
+	names := make(map[string]bool)
+	dirs := make(map[string]bool)
+	for _, path := range r.o.configFiles() {
+		names[filepath.Base(path)] = true
+		dirs[filepath.Dir(path)] = true
+	}
+	for dir := range dirs {
+		if err := w.Add(dir); err != nil {
+			return fmt.Errorf("failed to watch the config files in %s: %w", dir, err)
+		}
+	}
+
+	timer := time.NewTimer(0)
+	if !timer.Stop() {
+		<-timer.C
+	}
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if !watched(names, filepath.Base(event.Name)) {
+				continue
+			}
+
+			r.o.log().Debug("the config file changed", "path", event.Name, "op", event.Op.String())
+			timer.Reset(watchDebounce)
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+
+			r.o.log().Error("failed to watch the config files", "error", err)
+		case <-timer.C:
+			_ = r.reload(ctx)
+		}
+	}
+}
+
+// hashValues returns a hash of the given keys and values that changes whenever any of them changes.
+func hashValues(m map[string]string) string {
+	keys := slices.Sorted(maps.Keys(m))
+
+	h := sha256.New()
+	for _, key := range keys {
+		fmt.Fprintf(h, "%d:%s%d:%s", len(key), key, len(m[key]), m[key])
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// watched reports whether the file with the given name is one of the config files with the given names or one
+// of their variants (e.g. .env.age or .env.sig for .env).
+func watched(names map[string]bool, name string) bool {
+	for n := range names {
+		if name == n || strings.HasPrefix(name, n+".") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,375 @@
+package env
+
+import (
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatchKeepsPresetValues(t *testing.T) {
+	type config struct {
+		Host string `mapstructure:"HOST"`
+		Port int    `mapstructure:"PORT"`
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	if err := os.WriteFile(path, []byte("HOST=localhost\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := []Option{WithPath(dir), WithPrecedence(FileOnly)}
+	cfg := config{Port: 3000}
+	if err := LoadE(&cfg, opts...); err != nil {
+		t.Fatalf("LoadE() error = %v", err)
+	}
+
+	changes := make(chan config, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- Watch(ctx, &cfg, func(_, new config) { changes <- new }, opts...)
+	}()
+
+	select {
+	case got := <-changes:
+		t.Fatalf("the config was swapped without a change: %+v", got)
+	case <-time.After(3 * watchDebounce):
+	}
+
+	if err := os.WriteFile(path, []byte("HOST=example.com\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case got := <-changes:
+		if want := (config{Host: "example.com", Port: 3000}); got != want {
+			t.Errorf("config = %+v, want %+v", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the config was not reloaded")
+	}
+
+	cancel()
+	<-done
+}
+
+func TestReloaderCandidate(t *testing.T) {
+	type config struct {
+		Host string `mapstructure:"HOST"`
+		Port int    `mapstructure:"PORT"`
+	}
+
+	tests := []struct {
+		name    string
+		current config
+		values  map[string]string
+		want    config
+	}{
+		{name: "absent key", current: config{Port: 3000}, values: map[string]string{"HOST": "a"}, want: config{Host: "a", Port: 3000}},
+		{name: "present key", current: config{Port: 3000}, values: map[string]string{"PORT": "80"}, want: config{Port: 80}},
+		{name: "empty current", values: map[string]string{"HOST": "a"}, want: config{Host: "a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &reloader[config]{
+				opts:    []Option{withSource(staticProvider(tt.values))},
+				current: func() config { return tt.current },
+			}
+
+			got, p, _, err := r.candidate(context.Background())
+			if err != nil || p == nil {
+				t.Fatalf("candidate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("candidate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatchFiles(t *testing.T) {
+	type config struct {
+		Host string `mapstructure:"HOST"`
+	}
+
+	tests := []struct {
+		name   string
+		change func(t *testing.T, dir string)
+		want   string
+	}{
+		{
+			name: "write",
+			change: func(t *testing.T, dir string) {
+				if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("HOST=written"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: "written",
+		},
+		{
+			name: "rename",
+			change: func(t *testing.T, dir string) {
+				if err := os.WriteFile(filepath.Join(dir, ".env.tmp"), []byte("HOST=renamed"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Rename(filepath.Join(dir, ".env.tmp"), filepath.Join(dir, ".env")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: "renamed",
+		},
+		{
+			name: "other file",
+			change: func(t *testing.T, dir string) {
+				if err := os.WriteFile(filepath.Join(dir, "other.env"), []byte("HOST=other"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "invalid config",
+			change: func(t *testing.T, dir string) {
+				if err := os.WriteFile(filepath.Join(dir, ".env"), []byte(`HOST="unterminated`), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("HOST=localhost"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			opts := []Option{WithPath(dir), WithPrecedence(FileOnly)}
+			var cfg config
+			if err := LoadE(&cfg, opts...); err != nil {
+				t.Fatalf("LoadE() error = %v", err)
+			}
+
+			changes := make(chan config, 1)
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() {
+				done <- Watch(ctx, &cfg, func(_, new config) { changes <- new }, opts...)
+			}()
+			// the watch is set up before the config file changes
+			time.Sleep(3 * watchDebounce)
+
+			tt.change(t, dir)
+
+			var got string
+			select {
+			case c := <-changes:
+				got = c.Host
+			case <-time.After(5 * watchDebounce):
+			}
+			if got != tt.want {
+				t.Errorf("reloaded Host = %q, want %q", got, tt.want)
+			}
+
+			cancel()
+			if err := <-done; err != context.Canceled {
+				t.Errorf("Watch() error = %v, want %v", err, context.Canceled)
+			}
+		})
+	}
+}
+
+func TestWatched(t *testing.T) {
+	names := map[string]bool{".env": true, "config.env": true}
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: ".env", want: true},
+		{name: ".env.age", want: true},
+		{name: ".env.sig", want: true},
+		{name: "config.env", want: true},
+		{name: ".envrc"},
+		{name: "other.env"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := watched(names, tt.name); got != tt.want {
+				t.Errorf("watched(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+// mutableProvider is a provider whose values can be replaced while it is watched.
+type mutableProvider struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+// Fetch returns the current values.
+func (p *mutableProvider) Fetch(_ context.Context) (map[string]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return maps.Clone(p.values), nil
+}
+
+// set replaces the values.
+func (p *mutableProvider) set(values map[string]string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.values = values
+}
+
+func TestWatchPoll(t *testing.T) {
+	type config struct {
+		Host string `mapstructure:"HOST"`
+	}
+
+	tests := []struct {
+		name     string
+		interval time.Duration
+		values   map[string]string
+		want     string
+	}{
+		{name: "changed", interval: 10 * time.Millisecond, values: map[string]string{"HOST": "remote"}, want: "remote"},
+		{name: "unchanged", interval: 10 * time.Millisecond, values: map[string]string{"HOST": "localhost"}},
+		{name: "without interval", values: map[string]string{"HOST": "remote"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &mutableProvider{values: map[string]string{"HOST": "localhost"}}
+			opts := []Option{WithPrecedence(EnvOnly), WithProvider(p), WithPollInterval(tt.interval)}
+
+			var cfg config
+			if err := LoadE(&cfg, opts...); err != nil {
+				t.Fatalf("LoadE() error = %v", err)
+			}
+
+			changes := make(chan config, 1)
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() {
+				done <- Watch(ctx, &cfg, func(_, new config) { changes <- new }, opts...)
+			}()
+			// the watch is seeded with the values of the current config before they change
+			time.Sleep(50 * time.Millisecond)
+
+			p.set(tt.values)
+
+			var got string
+			select {
+			case c := <-changes:
+				got = c.Host
+			case <-time.After(200 * time.Millisecond):
+			}
+			if got != tt.want {
+				t.Errorf("reloaded Host = %q, want %q", got, tt.want)
+			}
+
+			cancel()
+			<-done
+		})
+	}
+}
+
+// panicky is a type whose decoder panics on the value boom.
+type panicky string
+
+func TestReloadFailures(t *testing.T) {
+	RegisterDecoderFunc(func(value string) (panicky, error) {
+		if value == "boom" {
+			panic("boom")
+		}
+		return panicky(value), nil
+	})
+
+	type config struct {
+		Host  string  `mapstructure:"HOST" required:"true"`
+		Port  int     `mapstructure:"PORT"`
+		Label panicky `mapstructure:"LABEL"`
+	}
+
+	tests := []struct {
+		name       string
+		values     []map[string]string
+		want       config
+		wantErrors []string
+	}{
+		{
+			name:       "invalid value",
+			values:     []map[string]string{{"HOST": "b", "PORT": "eighty"}},
+			want:       config{Host: "a", Port: 80},
+			wantErrors: []string{"PORT"},
+		},
+		{
+			name:       "reported once",
+			values:     []map[string]string{{"HOST": "b", "PORT": "eighty"}, {"HOST": "b", "PORT": "eighty"}},
+			want:       config{Host: "a", Port: 80},
+			wantErrors: []string{"PORT"},
+		},
+		{
+			name:       "other invalid value",
+			values:     []map[string]string{{"HOST": "b", "PORT": "eighty"}, {"HOST": "b", "PORT": "ninety"}},
+			want:       config{Host: "a", Port: 80},
+			wantErrors: []string{"eighty", "ninety"},
+		},
+		{
+			name:       "fixed",
+			values:     []map[string]string{{"HOST": "b", "PORT": "eighty"}, {"HOST": "b", "PORT": "81"}},
+			want:       config{Host: "b", Port: 81},
+			wantErrors: []string{"PORT"},
+		},
+		{
+			name:       "required",
+			values:     []map[string]string{{"PORT": "81"}},
+			want:       config{Host: "a", Port: 80},
+			wantErrors: []string{"HOST"},
+		},
+		{
+			name:       "panic",
+			values:     []map[string]string{{"HOST": "b", "PORT": "80", "LABEL": "boom"}},
+			want:       config{Host: "a", Port: 80},
+			wantErrors: []string{"panic while loading the config: boom"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errs []error
+			p := &mutableProvider{values: map[string]string{"HOST": "a", "PORT": "80"}}
+			s, err := NewStore[config](context.Background(), withSource(p), OnReloadError(func(err error) { errs = append(errs, err) }))
+			if err != nil {
+				t.Fatalf("NewStore() error = %v", err)
+			}
+
+			for _, values := range tt.values {
+				p.set(values)
+				_ = s.Reload(context.Background())
+			}
+
+			if got, _ := s.Get(); got != tt.want {
+				t.Errorf("Get() = %+v, want %+v", got, tt.want)
+			}
+			if len(errs) != len(tt.wantErrors) {
+				t.Fatalf("reported errors = %v, want %d errors", errs, len(tt.wantErrors))
+			}
+			for i, want := range tt.wantErrors {
+				if !strings.Contains(errs[i].Error(), want) {
+					t.Errorf("reported error %d = %v, want %q", i, errs[i], want)
+				}
+			}
+		})
+	}
+}